@@ -1,8 +1,8 @@
 package ezex
 
 import (
+	"context"
 	"database/sql"
-	"errors"
 	"fmt"
 	"time"
 )
@@ -17,6 +17,14 @@ type Account struct {
 
 // AddAccount creates a new account and returns the new account ID if successful
 func AddAccount(db *sql.DB, account Account) (int, error) {
+	return addAccount(db, account)
+}
+
+func AddAccountContext(ctx context.Context, db *sql.DB, account Account) (int, error) {
+	return addAccount(withContext(ctx, db), account)
+}
+
+func addAccount(db dbExecutor, account Account) (int, error) {
 	return dbAdd(
 		db,
 		`
@@ -31,6 +39,14 @@ func AddAccount(db *sql.DB, account Account) (int, error) {
 }
 
 func DeleteAccount(db *sql.DB, id int) (int, error) {
+	return deleteAccount(db, id)
+}
+
+func DeleteAccountContext(ctx context.Context, db *sql.DB, id int) (int, error) {
+	return deleteAccount(withContext(ctx, db), id)
+}
+
+func deleteAccount(db dbExecutor, id int) (int, error) {
 	return dbUpdate(
 		db,
 		`UPDATE accounts SET delete_date_unix = $date WHERE id = $id`,
@@ -39,7 +55,28 @@ func DeleteAccount(db *sql.DB, id int) (int, error) {
 	)
 }
 
+// UndeleteAccount restores a soft-deleted account (see DeleteAccount) and returns the number of affected rows
+func UndeleteAccount(db *sql.DB, id int) (int, error) {
+	return undeleteAccount(db, id)
+}
+
+func UndeleteAccountContext(ctx context.Context, db *sql.DB, id int) (int, error) {
+	return undeleteAccount(withContext(ctx, db), id)
+}
+
+func undeleteAccount(db dbExecutor, id int) (int, error) {
+	return dbUpdate(db, `UPDATE accounts SET delete_date_unix = NULL WHERE id = $id`, id)
+}
+
 func UpdateAccount(db *sql.DB, account Account) (int, error) {
+	return updateAccount(db, account)
+}
+
+func UpdateAccountContext(ctx context.Context, db *sql.DB, account Account) (int, error) {
+	return updateAccount(withContext(ctx, db), account)
+}
+
+func updateAccount(db dbExecutor, account Account) (int, error) {
 	return dbUpdate(
 		db,
 		`
@@ -59,6 +96,14 @@ func UpdateAccount(db *sql.DB, account Account) (int, error) {
 }
 
 func UpdateAccountBalance(db *sql.DB, accountID int, amountInCents int64) (int, error) {
+	return updateAccountBalance(db, accountID, amountInCents)
+}
+
+func UpdateAccountBalanceContext(ctx context.Context, db *sql.DB, accountID int, amountInCents int64) (int, error) {
+	return updateAccountBalance(withContext(ctx, db), accountID, amountInCents)
+}
+
+func updateAccountBalance(db dbExecutor, accountID int, amountInCents int64) (int, error) {
 	return dbUpdate(
 		db,
 		`
@@ -72,6 +117,14 @@ func UpdateAccountBalance(db *sql.DB, accountID int, amountInCents int64) (int,
 }
 
 func GetAccounts(db *sql.DB) []Account {
+	return getAccounts(db)
+}
+
+func GetAccountsContext(ctx context.Context, db *sql.DB) []Account {
+	return getAccounts(withContext(ctx, db))
+}
+
+func getAccounts(db dbExecutor) []Account {
 	return dbGet[Account](
 		db,
 		`
@@ -87,6 +140,14 @@ func GetAccounts(db *sql.DB) []Account {
 }
 
 func GetAccount(db *sql.DB, id int) (Account, error) {
+	return getAccount(db, id)
+}
+
+func GetAccountContext(ctx context.Context, db *sql.DB, id int) (Account, error) {
+	return getAccount(withContext(ctx, db), id)
+}
+
+func getAccount(db dbExecutor, id int) (Account, error) {
 	results := dbGet[Account](
 		db,
 		`
@@ -102,7 +163,7 @@ func GetAccount(db *sql.DB, id int) (Account, error) {
 	)
 
 	if len(results) == 0 {
-		return Account{}, errors.New(fmt.Sprintf("no accounts with id: %d", id))
+		return Account{}, fmt.Errorf("%w: account %d", ErrNotFound, id)
 	}
 
 	return results[0], nil
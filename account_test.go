@@ -40,7 +40,7 @@ func TestAddAccount_Unique(t *testing.T) {
 		BalanceInCents:        0,
 	})
 
-	assert.Error(t, err)
+	assert.ErrorIs(t, err, ErrDuplicateName)
 }
 
 func TestDeleteAccount(t *testing.T) {
@@ -56,6 +56,18 @@ func TestDeleteAccount(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestUndeleteAccount(t *testing.T) {
+	id, _ := AddAccount(testDB, Account{Name: "TestUndeleteAccount"})
+	_, _ = DeleteAccount(testDB, id)
+	assert.NotContains(t, GetAccounts(testDB), Account{ID: id, Name: "TestUndeleteAccount"})
+
+	n, err := UndeleteAccount(testDB, id)
+
+	assert.Equal(t, 1, n)
+	assert.Nil(t, err)
+	assert.Contains(t, GetAccounts(testDB), Account{ID: id, Name: "TestUndeleteAccount"})
+}
+
 func TestUpdateAccount(t *testing.T) {
 	id, _ := AddAccount(testDB, Account{
 		Name:                  "TestUpdateAccount",
@@ -97,7 +109,7 @@ func TestUpdateAccount_Unique(t *testing.T) {
 	})
 
 	assert.Equal(t, n, 0)
-	assert.Error(t, err)
+	assert.ErrorIs(t, err, ErrDuplicateName)
 }
 
 func TestUpdateAccountBalance(t *testing.T) {
@@ -197,5 +209,5 @@ func TestGetAccount(t *testing.T) {
 func TestGetAccount_NoMatch(t *testing.T) {
 	_, err := GetAccount(testDB, -1)
 
-	assert.Error(t, err)
+	assert.ErrorIs(t, err, ErrNotFound)
 }
@@ -0,0 +1,203 @@
+package ezex
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// CashFlow holds the income (positive amounts) and expenses (negative amounts) over a period
+type CashFlow struct {
+	IncomeInCents  int64
+	ExpenseInCents int64
+}
+
+// CategoryTotal is the sum of the transaction amounts of a category, including its subcategories, over a period
+type CategoryTotal struct {
+	CategoryID    int
+	CategoryName  string
+	ParentID      int
+	Path          string
+	AmountInCents int64
+}
+
+// GetTotalBalance returns the sum of the balances of every non-deleted account
+func GetTotalBalance(db *sql.DB) int64 {
+	return getTotalBalance(db)
+}
+
+func GetTotalBalanceContext(ctx context.Context, db *sql.DB) int64 {
+	return getTotalBalance(withContext(ctx, db))
+}
+
+func getTotalBalance(db dbExecutor) int64 {
+	results := dbGet[struct{ BalanceInCents int64 }](
+		db,
+		`SELECT COALESCE(SUM(balance_in_cents), 0) FROM accounts WHERE delete_date_unix IS NULL`,
+	)
+
+	if len(results) == 0 {
+		return 0
+	}
+
+	return results[0].BalanceInCents
+}
+
+// GetCashFlow returns income and expenses across all accounts between minDate and maxDate (excluded)
+func GetCashFlow(db *sql.DB, minDate time.Time, maxDate time.Time) CashFlow {
+	return getCashFlow(db, minDate, maxDate)
+}
+
+func GetCashFlowContext(ctx context.Context, db *sql.DB, minDate time.Time, maxDate time.Time) CashFlow {
+	return getCashFlow(withContext(ctx, db), minDate, maxDate)
+}
+
+func getCashFlow(db dbExecutor, minDate time.Time, maxDate time.Time) CashFlow {
+	results := dbGet[CashFlow](
+		db,
+		`
+		SELECT		COALESCE(SUM(CASE WHEN t.amount_in_cents > 0 THEN t.amount_in_cents END), 0),
+					COALESCE(SUM(CASE WHEN t.amount_in_cents < 0 THEN t.amount_in_cents END), 0)
+		FROM		transactions t
+		JOIN        accounts a
+		ON          a.id = t.account_id
+		WHERE			t.transaction_date_unix >= $minDateUnix
+					AND t.transaction_date_unix < $maxDateUnix
+					AND t.delete_date_unix IS NULL
+					AND a.delete_date_unix IS NULL
+		`,
+		minDate.Unix(),
+		maxDate.Unix(),
+	)
+
+	if len(results) == 0 {
+		return CashFlow{}
+	}
+
+	return results[0]
+}
+
+// GetCategoryTotals returns the per-category totals across all accounts between minDate and maxDate (excluded),
+// ordered from the biggest expense to the biggest income. Split transactions count towards their splits categories,
+// subcategories totals are rolled up into their ancestors (only top-level totals add up to the cash-flow)
+func GetCategoryTotals(db *sql.DB, minDate time.Time, maxDate time.Time) []CategoryTotal {
+	return getCategoryTotals(db, minDate, maxDate)
+}
+
+func GetCategoryTotalsContext(ctx context.Context, db *sql.DB, minDate time.Time, maxDate time.Time) []CategoryTotal {
+	return getCategoryTotals(withContext(ctx, db), minDate, maxDate)
+}
+
+func getCategoryTotals(db dbExecutor, minDate time.Time, maxDate time.Time) []CategoryTotal {
+	return dbGet[CategoryTotal](
+		db,
+		`
+		SELECT		c.id,
+					c.name,
+					c.parent_id,
+					cp.path,
+					SUM(l.amount_in_cents)		AS AmountInCents
+		FROM		transaction_lines l
+		JOIN		transactions t
+		ON			t.id = l.transaction_id
+		JOIN        accounts a
+		ON          a.id = t.account_id
+		JOIN		category_ancestors ca
+		ON			ca.category_id = l.category_id
+		JOIN        categories c
+		ON          c.id = ca.ancestor_id
+		JOIN		category_paths cp
+		ON			cp.id = c.id
+		WHERE			t.transaction_date_unix >= $minDateUnix
+					AND t.transaction_date_unix < $maxDateUnix
+					AND t.delete_date_unix IS NULL
+					AND a.delete_date_unix IS NULL
+		GROUP BY	c.id, c.name, c.parent_id, cp.path
+		ORDER BY	AmountInCents, c.id
+		`,
+		minDate.Unix(),
+		maxDate.Unix(),
+	)
+}
+
+// TagTotal is the sum of the amounts of the transactions with a tag over a period
+type TagTotal struct {
+	TagID         int
+	TagName       string
+	AmountInCents int64
+}
+
+// GetTagTotals returns the per-tag totals across all accounts between minDate and maxDate (excluded),
+// ordered from the biggest expense to the biggest income. Transactions with many tags count towards each of them
+func GetTagTotals(db *sql.DB, minDate time.Time, maxDate time.Time) []TagTotal {
+	return getTagTotals(db, minDate, maxDate)
+}
+
+func GetTagTotalsContext(ctx context.Context, db *sql.DB, minDate time.Time, maxDate time.Time) []TagTotal {
+	return getTagTotals(withContext(ctx, db), minDate, maxDate)
+}
+
+func getTagTotals(db dbExecutor, minDate time.Time, maxDate time.Time) []TagTotal {
+	return dbGet[TagTotal](
+		db,
+		`
+		SELECT		tg.id,
+					tg.name,
+					SUM(t.amount_in_cents)		AS AmountInCents
+		FROM		transaction_tags tt
+		JOIN		tags tg
+		ON			tg.id = tt.tag_id
+		JOIN		transactions t
+		ON			t.id = tt.transaction_id
+		JOIN        accounts a
+		ON          a.id = t.account_id
+		WHERE			t.transaction_date_unix >= $minDateUnix
+					AND t.transaction_date_unix < $maxDateUnix
+					AND t.delete_date_unix IS NULL
+					AND a.delete_date_unix IS NULL
+		GROUP BY	tg.id, tg.name
+		ORDER BY	AmountInCents, tg.id
+		`,
+		minDate.Unix(),
+		maxDate.Unix(),
+	)
+}
+
+// MonthlyCashFlow is the CashFlow of a single month, Month is formatted as YYYY-MM
+type MonthlyCashFlow struct {
+	Month          string
+	IncomeInCents  int64
+	ExpenseInCents int64
+}
+
+// GetMonthlyCashFlow returns income and expenses across all accounts between minDate and maxDate (excluded),
+// grouped by month (local time). Months without transactions are omitted
+func GetMonthlyCashFlow(db *sql.DB, minDate time.Time, maxDate time.Time) []MonthlyCashFlow {
+	return getMonthlyCashFlow(db, minDate, maxDate)
+}
+
+func GetMonthlyCashFlowContext(ctx context.Context, db *sql.DB, minDate time.Time, maxDate time.Time) []MonthlyCashFlow {
+	return getMonthlyCashFlow(withContext(ctx, db), minDate, maxDate)
+}
+
+func getMonthlyCashFlow(db dbExecutor, minDate time.Time, maxDate time.Time) []MonthlyCashFlow {
+	return dbGet[MonthlyCashFlow](
+		db,
+		`
+		SELECT		strftime('%Y-%m', t.transaction_date_unix, 'unixepoch', 'localtime')	AS Month,
+					COALESCE(SUM(CASE WHEN t.amount_in_cents > 0 THEN t.amount_in_cents END), 0),
+					COALESCE(SUM(CASE WHEN t.amount_in_cents < 0 THEN t.amount_in_cents END), 0)
+		FROM		transactions t
+		JOIN        accounts a
+		ON          a.id = t.account_id
+		WHERE			t.transaction_date_unix >= $minDateUnix
+					AND t.transaction_date_unix < $maxDateUnix
+					AND t.delete_date_unix IS NULL
+					AND a.delete_date_unix IS NULL
+		GROUP BY	Month
+		ORDER BY	Month
+		`,
+		minDate.Unix(),
+		maxDate.Unix(),
+	)
+}
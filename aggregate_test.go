@@ -0,0 +1,149 @@
+package ezex
+
+import (
+	"database/sql"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestGetTotalBalance(t *testing.T) {
+	before := GetTotalBalance(testDB)
+
+	_, _ = AddAccount(testDB, Account{
+		Name:                  "TestGetTotalBalance1",
+		Description:           sql.NullString{},
+		InitialBalanceInCents: 1000,
+		BalanceInCents:        1500,
+	})
+	deletedID, _ := AddAccount(testDB, Account{
+		Name:                  "TestGetTotalBalance2",
+		Description:           sql.NullString{},
+		InitialBalanceInCents: 1000,
+		BalanceInCents:        1000,
+	})
+	_, _ = DeleteAccount(testDB, deletedID)
+
+	assert.Equal(t, before+1500, GetTotalBalance(testDB))
+}
+
+func TestGetCashFlow(t *testing.T) {
+	payeeID, _ := AddPayee(testDB, Payee{Name: "TestGetCashFlow"})
+	accountID, _ := AddAccount(testDB, Account{Name: "TestGetCashFlow"})
+
+	date := time.Date(2101, 1, 10, 0, 0, 0, 0, time.UTC).Unix()
+	for _, amount := range []int64{1000, 250, -300, -50} {
+		_, _ = AddTransaction(testDB, Transaction{
+			PayeeID:             payeeID,
+			AccountID:           accountID,
+			AmountInCents:       amount,
+			TransactionDateUnix: date,
+		})
+	}
+	_, _ = AddTransaction(testDB, Transaction{
+		PayeeID:             payeeID,
+		AccountID:           accountID,
+		AmountInCents:       -10_000,
+		TransactionDateUnix: date,
+		DeleteDateUnix:      sql.NullInt64{Int64: 1, Valid: true},
+	})
+
+	cashFlow := GetCashFlow(
+		testDB,
+		time.Date(2101, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2101, 2, 1, 0, 0, 0, 0, time.UTC),
+	)
+
+	assert.Equal(t, CashFlow{IncomeInCents: 1250, ExpenseInCents: -350}, cashFlow)
+}
+
+func TestGetCategoryTotals(t *testing.T) {
+	payeeID, _ := AddPayee(testDB, Payee{Name: "TestGetCategoryTotals"})
+	accountID, _ := AddAccount(testDB, Account{Name: "TestGetCategoryTotals"})
+	category1ID, _ := AddCategory(testDB, Category{Name: "TestGetCategoryTotals1"})
+	category2ID, _ := AddCategory(testDB, Category{Name: "TestGetCategoryTotals2"})
+
+	date := time.Date(2102, 1, 10, 0, 0, 0, 0, time.UTC).Unix()
+	transactions := []Transaction{
+		{CategoryID: category1ID, AmountInCents: -100},
+		{CategoryID: category1ID, AmountInCents: -200},
+		{CategoryID: category2ID, AmountInCents: 50},
+	}
+	for _, transaction := range transactions {
+		transaction.PayeeID = payeeID
+		transaction.AccountID = accountID
+		transaction.TransactionDateUnix = date
+		_, _ = AddTransaction(testDB, transaction)
+	}
+
+	totals := GetCategoryTotals(
+		testDB,
+		time.Date(2102, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2102, 2, 1, 0, 0, 0, 0, time.UTC),
+	)
+
+	assert.Equal(t, []CategoryTotal{
+		{CategoryID: category1ID, CategoryName: "TestGetCategoryTotals1", Path: "TestGetCategoryTotals1", AmountInCents: -300},
+		{CategoryID: category2ID, CategoryName: "TestGetCategoryTotals2", Path: "TestGetCategoryTotals2", AmountInCents: 50},
+	}, totals)
+}
+
+func TestGetMonthlyCashFlow(t *testing.T) {
+	payeeID, _ := AddPayee(testDB, Payee{Name: "TestGetMonthlyCashFlow"})
+	accountID, _ := AddAccount(testDB, Account{Name: "TestGetMonthlyCashFlow"})
+
+	transactions := []Transaction{
+		{AmountInCents: 100, TransactionDateUnix: time.Date(2103, 1, 10, 0, 0, 0, 0, time.Local).Unix()},
+		{AmountInCents: -40, TransactionDateUnix: time.Date(2103, 1, 20, 0, 0, 0, 0, time.Local).Unix()},
+		{AmountInCents: -60, TransactionDateUnix: time.Date(2103, 3, 1, 0, 0, 0, 0, time.Local).Unix()},
+	}
+	for _, transaction := range transactions {
+		transaction.PayeeID = payeeID
+		transaction.AccountID = accountID
+		_, _ = AddTransaction(testDB, transaction)
+	}
+
+	cashFlow := GetMonthlyCashFlow(
+		testDB,
+		time.Date(2103, 1, 1, 0, 0, 0, 0, time.Local),
+		time.Date(2104, 1, 1, 0, 0, 0, 0, time.Local),
+	)
+
+	assert.Equal(t, []MonthlyCashFlow{
+		{Month: "2103-01", IncomeInCents: 100, ExpenseInCents: -40},
+		{Month: "2103-03", IncomeInCents: 0, ExpenseInCents: -60},
+	}, cashFlow)
+}
+
+func TestGetCategoryTotals_RollUp(t *testing.T) {
+	payeeID, _ := AddPayee(testDB, Payee{Name: "TestGetCategoryTotals_RollUp"})
+	accountID, _ := AddAccount(testDB, Account{Name: "TestGetCategoryTotals_RollUp"})
+	carID, _ := AddCategoryPath(testDB, "TestGetCategoryTotals_RollUp")
+	fuelID, _ := AddCategoryPath(testDB, "TestGetCategoryTotals_RollUp:Fuel")
+	insuranceID, _ := AddCategoryPath(testDB, "TestGetCategoryTotals_RollUp:Insurance")
+
+	date := time.Date(2105, 1, 10, 0, 0, 0, 0, time.UTC).Unix()
+	transactions := []Transaction{
+		{CategoryID: carID, AmountInCents: -50},
+		{CategoryID: fuelID, AmountInCents: -100},
+		{CategoryID: insuranceID, AmountInCents: -300},
+	}
+	for _, transaction := range transactions {
+		transaction.PayeeID = payeeID
+		transaction.AccountID = accountID
+		transaction.TransactionDateUnix = date
+		_, _ = AddTransaction(testDB, transaction)
+	}
+
+	totals := GetCategoryTotals(
+		testDB,
+		time.Date(2105, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2105, 2, 1, 0, 0, 0, 0, time.UTC),
+	)
+
+	assert.Equal(t, []CategoryTotal{
+		{CategoryID: carID, CategoryName: "TestGetCategoryTotals_RollUp", Path: "TestGetCategoryTotals_RollUp", AmountInCents: -450},
+		{CategoryID: insuranceID, CategoryName: "Insurance", ParentID: carID, Path: "TestGetCategoryTotals_RollUp:Insurance", AmountInCents: -300},
+		{CategoryID: fuelID, CategoryName: "Fuel", ParentID: carID, Path: "TestGetCategoryTotals_RollUp:Fuel", AmountInCents: -100},
+	}, totals)
+}
@@ -0,0 +1,398 @@
+// Package archive encrypts ez-ex backups and exports with a passphrase.
+//
+// An archive starts with a self-describing header (format version and key derivation parameters) followed by the
+// data split into chunks, each one sealed with AES-256-GCM using a key derived from the passphrase with
+// PBKDF2-HMAC-SHA256. Chunks are authenticated along with the header and their position, so reordered, truncated
+// or tampered archives are rejected, and large exports are encrypted as they're written.
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	// Version is the archive format version written by NewWriter
+	Version = 1
+	// KDFPBKDF2SHA256 identifies PBKDF2-HMAC-SHA256 as the key derivation function
+	KDFPBKDF2SHA256 = 1
+	// DefaultIterations is the PBKDF2 iterations count of new archives
+	DefaultIterations = 600_000
+	// DefaultChunkSize is the plaintext size of each encrypted chunk
+	DefaultChunkSize = 64 * 1024
+)
+
+const (
+	keySize         = 32
+	saltSize        = 16
+	noncePrefixSize = 8
+	maxChunkSize    = 16 * 1024 * 1024
+	// maxIterations bounds the key derivation cost of the archives read, so that a crafted header can't stall it
+	maxIterations = 10 * DefaultIterations
+	// lastChunk flags the chunk closing the archive
+	lastChunk byte = 1
+)
+
+var magic = []byte("EZEXARC\x00")
+
+var (
+	// ErrNotEncrypted is returned when reading data without the archive header
+	ErrNotEncrypted = errors.New("not an encrypted archive")
+	// ErrUnsupportedArchive is returned when the archive format version or KDF is unknown
+	ErrUnsupportedArchive = errors.New("unsupported archive")
+	// ErrDecryption is returned when a chunk can't be authenticated
+	ErrDecryption = errors.New("wrong passphrase or corrupted archive")
+)
+
+// Header describes how an archive was encrypted, it's stored unencrypted at the start of the archive
+type Header struct {
+	Version    uint8
+	KDF        uint8
+	Iterations uint32
+	Salt       []byte
+	ChunkSize  uint32
+	// NoncePrefix is combined with the chunk counter to get each chunk nonce
+	NoncePrefix []byte
+}
+
+// MarshalBinary encodes the header as stored in archives
+func (h Header) MarshalBinary() ([]byte, error) {
+	if len(h.Salt) > 255 {
+		return nil, errors.New("archive salt too long")
+	}
+
+	buf := bytes.Buffer{}
+	buf.Write(magic)
+	buf.WriteByte(h.Version)
+	buf.WriteByte(h.KDF)
+	_ = binary.Write(&buf, binary.BigEndian, h.Iterations)
+	buf.WriteByte(byte(len(h.Salt)))
+	buf.Write(h.Salt)
+	_ = binary.Write(&buf, binary.BigEndian, h.ChunkSize)
+	buf.Write(h.NoncePrefix)
+
+	return buf.Bytes(), nil
+}
+
+// ReadHeader reads the header at the start of an archive
+func ReadHeader(r io.Reader) (Header, error) {
+	prefix := make([]byte, len(magic)+2)
+	if _, err := io.ReadFull(r, prefix); err != nil || !bytes.Equal(prefix[:len(magic)], magic) {
+		return Header{}, ErrNotEncrypted
+	}
+
+	h := Header{Version: prefix[len(magic)], KDF: prefix[len(magic)+1]}
+	if h.Version != Version || h.KDF != KDFPBKDF2SHA256 {
+		return Header{}, fmt.Errorf("%w: version %d, KDF %d", ErrUnsupportedArchive, h.Version, h.KDF)
+	}
+
+	var saltLen uint8
+	if err := binary.Read(r, binary.BigEndian, &h.Iterations); err != nil {
+		return Header{}, err
+	}
+	if err := binary.Read(r, binary.BigEndian, &saltLen); err != nil {
+		return Header{}, err
+	}
+	h.Salt = make([]byte, saltLen)
+	if _, err := io.ReadFull(r, h.Salt); err != nil {
+		return Header{}, err
+	}
+	if err := binary.Read(r, binary.BigEndian, &h.ChunkSize); err != nil {
+		return Header{}, err
+	}
+	h.NoncePrefix = make([]byte, noncePrefixSize)
+	if _, err := io.ReadFull(r, h.NoncePrefix); err != nil {
+		return Header{}, err
+	}
+
+	// Short salts would weaken the key derivation
+	if h.Iterations == 0 || h.Iterations > maxIterations || len(h.Salt) < saltSize ||
+		h.ChunkSize == 0 || h.ChunkSize > maxChunkSize {
+		return Header{}, fmt.Errorf("%w: invalid parameters", ErrUnsupportedArchive)
+	}
+
+	return h, nil
+}
+
+// IsEncryptedFile reports whether the file starts with the archive header
+func IsEncryptedFile(name string) (bool, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return false, err
+	}
+	defer func(file *os.File) {
+		_ = file.Close()
+	}(file)
+
+	prefix := make([]byte, len(magic))
+	if _, err = io.ReadFull(file, prefix); errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return bytes.Equal(prefix, magic), nil
+}
+
+// Writer encrypts the data written to it, Close must be called to write the last chunk
+type Writer struct {
+	w      io.Writer
+	aead   cipher.AEAD
+	header []byte
+	prefix []byte
+	chunk  []byte
+	count  uint32
+	closed bool
+}
+
+// NewWriter writes the archive header to w and returns a Writer encrypting with passphrase
+func NewWriter(w io.Writer, passphrase string) (*Writer, error) {
+	return newWriter(w, passphrase, DefaultIterations)
+}
+
+func newWriter(w io.Writer, passphrase string, iterations uint32) (*Writer, error) {
+	h := Header{
+		Version:     Version,
+		KDF:         KDFPBKDF2SHA256,
+		Iterations:  iterations,
+		Salt:        make([]byte, saltSize),
+		ChunkSize:   DefaultChunkSize,
+		NoncePrefix: make([]byte, noncePrefixSize),
+	}
+	if _, err := rand.Read(h.Salt); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(h.NoncePrefix); err != nil {
+		return nil, err
+	}
+
+	header, err := h.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(passphrase, h)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = w.Write(header); err != nil {
+		return nil, err
+	}
+
+	return &Writer{
+		w:      w,
+		aead:   aead,
+		header: header,
+		prefix: h.NoncePrefix,
+		chunk:  make([]byte, 0, h.ChunkSize),
+	}, nil
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, errors.New("write to closed archive")
+	}
+
+	written := 0
+	for len(p) > 0 {
+		n := min(len(p), cap(w.chunk)-len(w.chunk))
+		w.chunk = append(w.chunk, p[:n]...)
+		p = p[n:]
+		written += n
+
+		// The last chunk is only known on Close, so full chunks are written once more data comes
+		if len(w.chunk) == cap(w.chunk) && len(p) > 0 {
+			if err := w.flush(0); err != nil {
+				return written, err
+			}
+		}
+	}
+
+	return written, nil
+}
+
+// Close writes the last chunk, it doesn't close the underlying writer
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+
+	return w.flush(lastChunk)
+}
+
+func (w *Writer) flush(flag byte) error {
+	sealed := w.aead.Seal(nil, chunkNonce(w.prefix, w.count), w.chunk, chunkAdditionalData(w.header, w.count, flag))
+	w.chunk = w.chunk[:0]
+	w.count++
+
+	chunkHeader := make([]byte, 5)
+	chunkHeader[0] = flag
+	binary.BigEndian.PutUint32(chunkHeader[1:], uint32(len(sealed)))
+	if _, err := w.w.Write(chunkHeader); err != nil {
+		return err
+	}
+	_, err := w.w.Write(sealed)
+
+	return err
+}
+
+// Reader decrypts an archive, it returns io.EOF only after the last chunk is authenticated
+type Reader struct {
+	r         *bufio.Reader
+	aead      cipher.AEAD
+	header    []byte
+	prefix    []byte
+	chunkSize uint32
+	chunk     []byte
+	count     uint32
+	done      bool
+}
+
+// NewReader reads the archive header from r and returns a Reader decrypting with passphrase
+func NewReader(r io.Reader, passphrase string) (*Reader, error) {
+	br := bufio.NewReader(r)
+	h, err := ReadHeader(br)
+	if err != nil {
+		return nil, err
+	}
+
+	header, _ := h.MarshalBinary()
+	aead, err := newAEAD(passphrase, h)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Reader{r: br, aead: aead, header: header, prefix: h.NoncePrefix, chunkSize: h.ChunkSize}, nil
+}
+
+func (r *Reader) Read(p []byte) (int, error) {
+	for len(r.chunk) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		if err := r.next(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, r.chunk)
+	r.chunk = r.chunk[n:]
+
+	return n, nil
+}
+
+func (r *Reader) next() error {
+	chunkHeader := make([]byte, 5)
+	if _, err := io.ReadFull(r.r, chunkHeader); err != nil {
+		return fmt.Errorf("%w: truncated archive", ErrDecryption)
+	}
+
+	flag, size := chunkHeader[0], binary.BigEndian.Uint32(chunkHeader[1:])
+	if size > r.chunkSize+uint32(r.aead.Overhead()) {
+		return fmt.Errorf("%w: invalid chunk size", ErrDecryption)
+	}
+
+	sealed := make([]byte, size)
+	if _, err := io.ReadFull(r.r, sealed); err != nil {
+		return fmt.Errorf("%w: truncated archive", ErrDecryption)
+	}
+
+	chunk, err := r.aead.Open(nil, chunkNonce(r.prefix, r.count), sealed, chunkAdditionalData(r.header, r.count, flag))
+	if err != nil {
+		return ErrDecryption
+	}
+	r.chunk = chunk
+	r.count++
+
+	if flag == lastChunk {
+		r.done = true
+		if _, err = r.r.ReadByte(); err != io.EOF {
+			return fmt.Errorf("%w: data after the last chunk", ErrDecryption)
+		}
+	}
+
+	return nil
+}
+
+// EncryptFile encrypts src into dest (see NewWriter)
+func EncryptFile(src string, dest string, passphrase string) error {
+	return transformFile(src, dest, func(w io.Writer, r io.Reader) error {
+		aw, err := NewWriter(w, passphrase)
+		if err != nil {
+			return err
+		}
+		if _, err = io.Copy(aw, r); err != nil {
+			return err
+		}
+
+		return aw.Close()
+	})
+}
+
+// DecryptFile decrypts the src archive into dest (see NewReader)
+func DecryptFile(src string, dest string, passphrase string) error {
+	return transformFile(src, dest, func(w io.Writer, r io.Reader) error {
+		ar, err := NewReader(r, passphrase)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(w, ar)
+
+		return err
+	})
+}
+
+// transformFile writes src transformed by fn into dest, dest is removed if fn fails
+func transformFile(src string, dest string, fn func(w io.Writer, r io.Reader) error) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func(in *os.File) {
+		_ = in.Close()
+	}(in)
+
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	if err = fn(out, in); err == nil {
+		err = out.Close()
+	} else {
+		_ = out.Close()
+	}
+	if err != nil {
+		_ = os.Remove(dest)
+	}
+
+	return err
+}
+
+func newAEAD(passphrase string, h Header) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(pbkdf2SHA256([]byte(passphrase), h.Salt, int(h.Iterations), keySize))
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// chunkNonce is the nonce prefix followed by the chunk counter, unique per chunk since the prefix is random
+func chunkNonce(prefix []byte, count uint32) []byte {
+	return binary.BigEndian.AppendUint32(append([]byte{}, prefix...), count)
+}
+
+// chunkAdditionalData binds each chunk to the header, its position and whether it's the last one
+func chunkAdditionalData(header []byte, count uint32, flag byte) []byte {
+	data := binary.BigEndian.AppendUint32(append([]byte{}, header...), count)
+	return append(data, flag)
+}
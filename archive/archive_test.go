@@ -0,0 +1,128 @@
+package archive
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"os"
+	"path"
+	"testing"
+)
+
+// testIterations keeps the tests fast, the KDF cost doesn't change the format
+const testIterations = 10
+
+func encrypt(t *testing.T, data []byte, passphrase string) []byte {
+	buf := bytes.Buffer{}
+	w, err := newWriter(&buf, passphrase, testIterations)
+	assert.Nil(t, err)
+	_, err = w.Write(data)
+	assert.Nil(t, err)
+	assert.Nil(t, w.Close())
+
+	return buf.Bytes()
+}
+
+func decrypt(data []byte, passphrase string) ([]byte, error) {
+	r, err := NewReader(bytes.NewReader(data), passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	return io.ReadAll(r)
+}
+
+func TestPBKDF2SHA256(t *testing.T) {
+	cases := []struct {
+		iterations int
+		key        string
+	}{
+		{1, "120fb6cffcf8b32c43e7225256c4f837a86548c92ccc35480805987cb70be17b"},
+		{2, "ae4d0c95af6b46d32d0adff928f06dd02a303f8ef3c251dfd6e2d85a95474c43"},
+		{4096, "c5e478d59288c841aa530db6845c4c8d962893a001ce4e11a4963873aa98134a"},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.key, hex.EncodeToString(pbkdf2SHA256([]byte("password"), []byte("salt"), c.iterations, 32)))
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, size := range []int{0, 10, DefaultChunkSize, DefaultChunkSize*3 + 7} {
+		data := bytes.Repeat([]byte("ez-ex "), size/6+1)[:size]
+		archive := encrypt(t, data, "secret")
+
+		header, err := ReadHeader(bytes.NewReader(archive))
+		assert.Nil(t, err)
+		assert.Equal(t, uint8(Version), header.Version)
+		assert.Equal(t, uint32(testIterations), header.Iterations)
+		assert.NotContains(t, string(archive), "ez-ex ez-ex")
+
+		decrypted, err := decrypt(archive, "secret")
+		assert.Nil(t, err)
+		assert.Equal(t, data, decrypted)
+	}
+}
+
+func TestReader_Errors(t *testing.T) {
+	data := bytes.Repeat([]byte{42}, DefaultChunkSize*2)
+	archive := encrypt(t, data, "secret")
+
+	_, err := decrypt(archive, "wrong")
+	assert.ErrorIs(t, err, ErrDecryption)
+
+	// Truncated last chunk
+	_, err = decrypt(archive[:len(archive)-40], "secret")
+	assert.ErrorIs(t, err, ErrDecryption)
+
+	tampered := append([]byte{}, archive...)
+	tampered[len(tampered)-1] ^= 1
+	_, err = decrypt(tampered, "secret")
+	assert.ErrorIs(t, err, ErrDecryption)
+
+	_, err = decrypt(append(append([]byte{}, archive...), 0), "secret")
+	assert.ErrorIs(t, err, ErrDecryption)
+
+	_, err = decrypt([]byte("SQLite format 3\x00"), "secret")
+	assert.ErrorIs(t, err, ErrNotEncrypted)
+
+	unsupported := append([]byte{}, archive...)
+	unsupported[len(magic)] = Version + 1
+	_, err = decrypt(unsupported, "secret")
+	assert.ErrorIs(t, err, ErrUnsupportedArchive)
+
+	tooManyIterations := append([]byte{}, archive...)
+	binary.BigEndian.PutUint32(tooManyIterations[len(magic)+2:], maxIterations+1)
+	_, err = decrypt(tooManyIterations, "secret")
+	assert.ErrorIs(t, err, ErrUnsupportedArchive)
+
+	// The salt follows the 4 bytes of iterations and its length byte
+	saltStart := len(magic) + 2 + 4 + 1
+	noSalt := append(append([]byte{}, archive[:saltStart-1]...), 0)
+	noSalt = append(noSalt, archive[saltStart+saltSize:]...)
+	_, err = decrypt(noSalt, "secret")
+	assert.ErrorIs(t, err, ErrUnsupportedArchive)
+}
+
+func TestEncryptFile(t *testing.T) {
+	dir := t.TempDir()
+	plain := path.Join(dir, "plain.db")
+	encrypted := path.Join(dir, "plain.db.enc")
+	decrypted := path.Join(dir, "decrypted.db")
+	_ = os.WriteFile(plain, []byte("every purchase we make"), 0600)
+
+	assert.Nil(t, EncryptFile(plain, encrypted, "secret"))
+	isEncrypted, err := IsEncryptedFile(encrypted)
+	assert.Nil(t, err)
+	assert.True(t, isEncrypted)
+	isEncrypted, _ = IsEncryptedFile(plain)
+	assert.False(t, isEncrypted)
+
+	assert.ErrorIs(t, DecryptFile(encrypted, decrypted, "wrong"), ErrDecryption)
+	assert.NoFileExists(t, decrypted)
+
+	assert.Nil(t, DecryptFile(encrypted, decrypted, "secret"))
+	content, _ := os.ReadFile(decrypted)
+	assert.Equal(t, "every purchase we make", string(content))
+}
@@ -0,0 +1,301 @@
+package ezex
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// AttachmentsDirName is the directory, inside the data directory (see DataDir), where attachments files are stored
+const AttachmentsDirName = "attachments"
+
+// Attachment is a file (e.g. a receipt) linked to a transaction, FileName is the original file name while the stored
+// file is named after its content hash (see StoredName), so identical files are only stored once
+type Attachment struct {
+	ID             int
+	TransactionID  int
+	FileName       string
+	ContentHash    string
+	SizeInBytes    int64
+	CreateDateUnix int64
+}
+
+// BrokenAttachment is an attachment whose file is missing or whose content no longer matches its hash
+type BrokenAttachment struct {
+	Attachment Attachment
+	Missing    bool
+}
+
+// DefaultAttachmentsDir returns the default attachments directory, inside DataDir
+func DefaultAttachmentsDir() string {
+	return path.Join(DataDir(), AttachmentsDirName)
+}
+
+// StoredName returns the name of the attachment file inside the attachments directory
+func (a Attachment) StoredName() string {
+	return a.ContentHash + strings.ToLower(filepath.Ext(a.FileName))
+}
+
+// Path returns the path of the attachment file inside dir
+func (a Attachment) Path(dir string) string {
+	return filepath.Join(dir, a.StoredName())
+}
+
+// AttachFile copies the file at filePath to dir and links it to a transaction
+func AttachFile(db *sql.DB, dir string, transactionID int, filePath string) (Attachment, error) {
+	return attachFile(db, dir, transactionID, filePath)
+}
+
+func AttachFileContext(ctx context.Context, db *sql.DB, dir string, transactionID int, filePath string) (Attachment, error) {
+	return attachFile(withContext(ctx, db), dir, transactionID, filePath)
+}
+
+func attachFile(db dbExecutor, dir string, transactionID int, filePath string) (Attachment, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return Attachment{}, err
+	}
+	defer func(file *os.File) {
+		_ = file.Close()
+	}(file)
+
+	return addAttachment(db, dir, transactionID, filepath.Base(filePath), file)
+}
+
+// AddAttachment stores the content of r in dir and links it to a transaction as fileName
+func AddAttachment(db *sql.DB, dir string, transactionID int, fileName string, r io.Reader) (Attachment, error) {
+	return addAttachment(db, dir, transactionID, fileName, r)
+}
+
+func AddAttachmentContext(ctx context.Context, db *sql.DB, dir string, transactionID int, fileName string, r io.Reader) (Attachment, error) {
+	return addAttachment(withContext(ctx, db), dir, transactionID, fileName, r)
+}
+
+func addAttachment(db dbExecutor, dir string, transactionID int, fileName string, r io.Reader) (Attachment, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return Attachment{}, err
+	}
+
+	// Write to a temporary file first, the final name depends on the content hash
+	tmp, err := os.CreateTemp(dir, ".attachment-*")
+	if err != nil {
+		return Attachment{}, err
+	}
+	defer func(name string) {
+		_ = os.Remove(name)
+	}(tmp.Name())
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return Attachment{}, err
+	}
+
+	attachment := Attachment{
+		TransactionID:  transactionID,
+		FileName:       fileName,
+		ContentHash:    hex.EncodeToString(hash.Sum(nil)),
+		SizeInBytes:    size,
+		CreateDateUnix: time.Now().Unix(),
+	}
+	stored := false
+	if _, err = os.Stat(attachment.Path(dir)); errors.Is(err, os.ErrNotExist) {
+		if err = os.Rename(tmp.Name(), attachment.Path(dir)); err != nil {
+			return Attachment{}, err
+		}
+		stored = true
+	}
+
+	attachment.ID, err = dbAdd(
+		db,
+		`
+		INSERT INTO attachments	(transaction_id, file_name, content_hash, size_in_bytes, create_date_unix)
+		VALUES					($transaction_id, $file_name, $content_hash, $size_in_bytes, $create_date_unix)
+		`,
+		attachment.TransactionID,
+		attachment.FileName,
+		attachment.ContentHash,
+		attachment.SizeInBytes,
+		attachment.CreateDateUnix,
+	)
+	if err != nil {
+		// Don't leave behind files no attachment refers to
+		if stored {
+			_ = os.Remove(attachment.Path(dir))
+		}
+		return Attachment{}, err
+	}
+
+	return attachment, nil
+}
+
+// GetAttachment returns an attachment by ID
+func GetAttachment(db *sql.DB, id int) (Attachment, error) {
+	return getAttachment(db, id)
+}
+
+func GetAttachmentContext(ctx context.Context, db *sql.DB, id int) (Attachment, error) {
+	return getAttachment(withContext(ctx, db), id)
+}
+
+func getAttachment(db dbExecutor, id int) (Attachment, error) {
+	attachments := dbGet[Attachment](db, attachmentSelectQuery+`WHERE id = $id`, id)
+	if len(attachments) == 0 {
+		return Attachment{}, fmt.Errorf("%w: attachment %d", ErrNotFound, id)
+	}
+
+	return attachments[0], nil
+}
+
+// GetAttachments returns the attachments of a transaction, oldest first
+func GetAttachments(db *sql.DB, transactionID int) []Attachment {
+	return getAttachments(db, transactionID)
+}
+
+func GetAttachmentsContext(ctx context.Context, db *sql.DB, transactionID int) []Attachment {
+	return getAttachments(withContext(ctx, db), transactionID)
+}
+
+func getAttachments(db dbExecutor, transactionID int) []Attachment {
+	return dbGet[Attachment](
+		db,
+		attachmentSelectQuery+`WHERE transaction_id = $transactionID ORDER BY id`,
+		transactionID,
+	)
+}
+
+// DetachFile removes an attachment, its file is deleted from dir once no other attachment uses it
+func DetachFile(db *sql.DB, dir string, id int) error {
+	return detachFile(db, dir, id)
+}
+
+func DetachFileContext(ctx context.Context, db *sql.DB, dir string, id int) error {
+	return detachFile(withContext(ctx, db), dir, id)
+}
+
+func detachFile(db dbExecutor, dir string, id int) error {
+	attachment, err := getAttachment(db, id)
+	if err != nil {
+		return err
+	}
+
+	if _, err := dbDelete(db, `DELETE FROM attachments WHERE id = $id`, id); errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("%w: attachment %d", ErrNotFound, id)
+	} else if err != nil {
+		return err
+	}
+
+	sameContent := dbGet[Attachment](
+		db,
+		attachmentSelectQuery+`WHERE content_hash = $content_hash`,
+		attachment.ContentHash,
+	)
+	for _, other := range sameContent {
+		if other.StoredName() == attachment.StoredName() {
+			return nil
+		}
+	}
+
+	if err = os.Remove(attachment.Path(dir)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// ExportAttachment writes the content of an attachment to w
+func ExportAttachment(db *sql.DB, dir string, id int, w io.Writer) error {
+	return exportAttachment(db, dir, id, w)
+}
+
+func ExportAttachmentContext(ctx context.Context, db *sql.DB, dir string, id int, w io.Writer) error {
+	return exportAttachment(withContext(ctx, db), dir, id, w)
+}
+
+func exportAttachment(db dbExecutor, dir string, id int, w io.Writer) error {
+	attachment, err := getAttachment(db, id)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(attachment.Path(dir))
+	if err != nil {
+		return err
+	}
+	defer func(file *os.File) {
+		_ = file.Close()
+	}(file)
+
+	_, err = io.Copy(w, file)
+	return err
+}
+
+// CheckAttachments verifies that the file of every attachment exists in dir and matches its hash
+func CheckAttachments(db *sql.DB, dir string) ([]BrokenAttachment, error) {
+	return checkAttachments(db, dir)
+}
+
+func CheckAttachmentsContext(ctx context.Context, db *sql.DB, dir string) ([]BrokenAttachment, error) {
+	return checkAttachments(withContext(ctx, db), dir)
+}
+
+func checkAttachments(db dbExecutor, dir string) ([]BrokenAttachment, error) {
+	var broken []BrokenAttachment
+
+	err := dbEach[Attachment](db, func(attachment Attachment) error {
+		hash, err := hashFile(attachment.Path(dir))
+		if errors.Is(err, os.ErrNotExist) {
+			broken = append(broken, BrokenAttachment{Attachment: attachment, Missing: true})
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if hash != attachment.ContentHash {
+			broken = append(broken, BrokenAttachment{Attachment: attachment})
+		}
+		return nil
+	}, attachmentSelectQuery+`ORDER BY id`)
+
+	return broken, err
+}
+
+const attachmentSelectQuery = `
+		SELECT	id,
+				transaction_id,
+				file_name,
+				content_hash,
+				size_in_bytes,
+				create_date_unix
+		FROM	attachments
+		`
+
+func hashFile(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer func(file *os.File) {
+		_ = file.Close()
+	}(file)
+
+	hash := sha256.New()
+	if _, err = io.Copy(hash, file); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
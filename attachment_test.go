@@ -0,0 +1,97 @@
+package ezex
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func addAttachmentTestTransaction(name string) int {
+	payeeID, _ := AddPayee(testDB, Payee{Name: name})
+	accountID, _ := AddAccount(testDB, Account{Name: name})
+	id, _ := AddTransaction(testDB, Transaction{PayeeID: payeeID, AccountID: accountID, AmountInCents: -100})
+
+	return id
+}
+
+func TestAttachFile(t *testing.T) {
+	dir := t.TempDir()
+	transactionID := addAttachmentTestTransaction("TestAttachFile")
+	src := filepath.Join(t.TempDir(), "Receipt.PDF")
+	_ = os.WriteFile(src, []byte("receipt"), 0600)
+
+	attachment, err := AttachFile(testDB, dir, transactionID, src)
+
+	assert.Nil(t, err)
+	assert.Greater(t, attachment.ID, 0)
+	assert.Equal(t, "Receipt.PDF", attachment.FileName)
+	assert.Equal(t, int64(7), attachment.SizeInBytes)
+	assert.True(t, strings.HasSuffix(attachment.StoredName(), ".pdf"))
+
+	content, _ := os.ReadFile(attachment.Path(dir))
+	assert.Equal(t, "receipt", string(content))
+
+	attachments := GetAttachments(testDB, transactionID)
+	assert.Equal(t, []Attachment{attachment}, attachments)
+
+	transactions := FilterTransactions(testDB, TransactionFilter{Text: "TestAttachFile"})
+	assert.Equal(t, 1, transactions[0].AttachmentCount)
+}
+
+func TestDetachFile(t *testing.T) {
+	dir := t.TempDir()
+	transactionID := addAttachmentTestTransaction("TestDetachFile")
+
+	first, _ := AddAttachment(testDB, dir, transactionID, "a.jpg", strings.NewReader("photo"))
+	// Same content, stored once
+	second, _ := AddAttachment(testDB, dir, transactionID, "b.jpg", strings.NewReader("photo"))
+	assert.Equal(t, first.Path(dir), second.Path(dir))
+
+	assert.Nil(t, DetachFile(testDB, dir, first.ID))
+	assert.FileExists(t, second.Path(dir))
+
+	assert.Nil(t, DetachFile(testDB, dir, second.ID))
+	assert.NoFileExists(t, second.Path(dir))
+	assert.Empty(t, GetAttachments(testDB, transactionID))
+
+	assert.Error(t, DetachFile(testDB, dir, second.ID))
+}
+
+func TestExportAttachment(t *testing.T) {
+	dir := t.TempDir()
+	transactionID := addAttachmentTestTransaction("TestExportAttachment")
+	attachment, _ := AddAttachment(testDB, dir, transactionID, "warranty.pdf", strings.NewReader("warranty"))
+
+	buf := bytes.Buffer{}
+	err := ExportAttachment(testDB, dir, attachment.ID, &buf)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "warranty", buf.String())
+}
+
+func TestCheckAttachments(t *testing.T) {
+	dir := t.TempDir()
+	transactionID := addAttachmentTestTransaction("TestCheckAttachments")
+	missing, _ := AddAttachment(testDB, dir, transactionID, "missing.pdf", strings.NewReader("missing"))
+	corrupted, _ := AddAttachment(testDB, dir, transactionID, "corrupted.pdf", strings.NewReader("corrupted"))
+	_, _ = AddAttachment(testDB, dir, transactionID, "ok.pdf", strings.NewReader("ok"))
+
+	_ = os.Remove(missing.Path(dir))
+	_ = os.WriteFile(corrupted.Path(dir), []byte("changed"), 0600)
+
+	broken, err := CheckAttachments(testDB, dir)
+
+	var brokenIDs []int
+	for _, b := range broken {
+		// Attachments of the other tests are stored in other directories
+		if b.Attachment.TransactionID == transactionID {
+			brokenIDs = append(brokenIDs, b.Attachment.ID)
+			assert.Equal(t, b.Attachment.ID == missing.ID, b.Missing)
+		}
+	}
+	assert.Nil(t, err)
+	assert.Equal(t, []int{missing.ID, corrupted.ID}, brokenIDs)
+}
@@ -0,0 +1,164 @@
+package ezex
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sort"
+)
+
+// AuditSource tells where a change recorded in the audit log comes from (see WithAuditSource)
+type AuditSource string
+
+const (
+	// AuditSourceAPI is the source of the changes made without WithAuditSource
+	AuditSourceAPI    AuditSource = "api"
+	AuditSourceTUI    AuditSource = "tui"
+	AuditSourceCLI    AuditSource = "cli"
+	AuditSourceImport AuditSource = "import"
+)
+
+// AuditEntity is a table whose changes are recorded in the audit log
+type AuditEntity string
+
+const (
+	AuditAccount     AuditEntity = "accounts"
+	AuditTransaction AuditEntity = "transactions"
+	AuditPayee       AuditEntity = "payees"
+	AuditCategory    AuditEntity = "categories"
+)
+
+// AuditAction is the kind of change recorded in the audit log
+type AuditAction string
+
+const (
+	AuditInsert AuditAction = "insert"
+	AuditUpdate AuditAction = "update"
+	AuditDelete AuditAction = "delete"
+)
+
+// AuditEntry is a change recorded in the audit log. OldValues and NewValues are the JSON of the entity columns
+// before and after it, OldValues is NULL for inserts and NewValues for deletes
+type AuditEntry struct {
+	ID        int
+	Entity    AuditEntity
+	EntityID  int
+	Action    AuditAction
+	OldValues sql.NullString
+	NewValues sql.NullString
+	DateUnix  int64
+	Source    AuditSource
+}
+
+// AuditChange is a column changed by an AuditEntry, Old is nil for inserts and New for deletes.
+// Values are strings, json.Number or nil for NULL columns
+type AuditChange struct {
+	Column string
+	Old    any
+	New    any
+}
+
+type auditSourceKey struct{}
+
+// WithAuditSource returns a copy of ctx recording the changes made with it (see the XContext functions) as coming
+// from source. Changes made without it come from AuditSourceAPI
+func WithAuditSource(ctx context.Context, source AuditSource) context.Context {
+	return context.WithValue(ctx, auditSourceKey{}, source)
+}
+
+// withAuditSource runs fn recording the changes it makes within tx as coming from the source of ctx, if any.
+// The source is cleared before the transaction ends, so that other changes come from AuditSourceAPI
+func withAuditSource(ctx context.Context, tx dbExecutor, fn func() error) error {
+	source, ok := ctx.Value(auditSourceKey{}).(AuditSource)
+	if !ok {
+		return fn()
+	}
+
+	if _, err := tx.Exec(`UPDATE audit_source SET source = $source`, source); err != nil {
+		return err
+	}
+	if err := fn(); err != nil {
+		return err
+	}
+
+	_, err := tx.Exec(`UPDATE audit_source SET source = NULL`)
+	return err
+}
+
+// GetHistory returns the changes of an entity recorded in the audit log, oldest first
+func GetHistory(db *sql.DB, entity AuditEntity, id int) []AuditEntry {
+	return getHistory(db, entity, id)
+}
+
+func GetHistoryContext(ctx context.Context, db *sql.DB, entity AuditEntity, id int) []AuditEntry {
+	return getHistory(withContext(ctx, db), entity, id)
+}
+
+func getHistory(db dbExecutor, entity AuditEntity, id int) []AuditEntry {
+	return dbGet[AuditEntry](
+		db,
+		`
+		SELECT		id,
+					entity,
+					entity_id,
+					action,
+					old_values,
+					new_values,
+					date_unix,
+					source
+		FROM		audit_log
+		WHERE		entity = $entity AND entity_id = $entity_id
+		ORDER BY	id
+		`,
+		entity,
+		id,
+	)
+}
+
+// Changes returns the columns changed by the entry sorted by name, every column for inserts and deletes
+func (e AuditEntry) Changes() ([]AuditChange, error) {
+	oldValues, err := decodeAuditValues(e.OldValues)
+	if err != nil {
+		return nil, err
+	}
+	newValues, err := decodeAuditValues(e.NewValues)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := map[string]bool{}
+	for column := range oldValues {
+		columns[column] = true
+	}
+	for column := range newValues {
+		columns[column] = true
+	}
+
+	var changes []AuditChange
+	for column := range columns {
+		change := AuditChange{Column: column, Old: oldValues[column], New: newValues[column]}
+		if e.Action != AuditUpdate || change.Old != change.New {
+			changes = append(changes, change)
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Column < changes[j].Column
+	})
+
+	return changes, nil
+}
+
+func decodeAuditValues(values sql.NullString) (map[string]any, error) {
+	if !values.Valid {
+		return nil, nil
+	}
+
+	decoded := map[string]any{}
+	decoder := json.NewDecoder(bytes.NewBufferString(values.String))
+	// Amounts and IDs are int64, which float64 can't always represent
+	decoder.UseNumber()
+	err := decoder.Decode(&decoded)
+
+	return decoded, err
+}
@@ -0,0 +1,138 @@
+package ezex
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"strconv"
+	"testing"
+)
+
+func openAuditTestDB(t *testing.T) *sql.DB {
+	db, err := OpenDB(WithInMemory())
+	assert.Nil(t, err)
+	assert.Nil(t, MigrateDB(db))
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	return db
+}
+
+func TestGetHistory(t *testing.T) {
+	db := openAuditTestDB(t)
+
+	id, err := AddPayee(db, Payee{Name: "TestGetHistory"})
+	assert.Nil(t, err)
+	_, err = UpdatePayee(db, Payee{ID: id, Name: "TestGetHistory renamed"})
+	assert.Nil(t, err)
+	// Updates changing nothing aren't logged
+	_, err = UpdatePayee(db, Payee{ID: id, Name: "TestGetHistory renamed"})
+	assert.Nil(t, err)
+	_, err = DeletePayee(db, id)
+	assert.Nil(t, err)
+
+	history := GetHistory(db, AuditPayee, id)
+	assert.Len(t, history, 3)
+	for i, action := range []AuditAction{AuditInsert, AuditUpdate, AuditDelete} {
+		assert.Equal(t, AuditPayee, history[i].Entity)
+		assert.Equal(t, id, history[i].EntityID)
+		assert.Equal(t, action, history[i].Action)
+		assert.Equal(t, AuditSourceAPI, history[i].Source)
+		assert.NotZero(t, history[i].DateUnix)
+	}
+
+	assert.False(t, history[0].OldValues.Valid)
+	assert.JSONEq(t, fmt.Sprintf(`{"id": %d, "name": "TestGetHistory", "description": null}`, id),
+		history[0].NewValues.String)
+	assert.False(t, history[2].NewValues.Valid)
+
+	changes, err := history[1].Changes()
+	assert.Nil(t, err)
+	assert.Equal(t, []AuditChange{{Column: "name", Old: "TestGetHistory", New: "TestGetHistory renamed"}}, changes)
+
+	changes, err = history[2].Changes()
+	assert.Nil(t, err)
+	assert.Equal(t, []AuditChange{
+		{Column: "description"},
+		{Column: "id", Old: json.Number(strconv.Itoa(id))},
+		{Column: "name", Old: "TestGetHistory renamed"},
+	}, changes)
+
+	assert.Len(t, GetHistory(db, AuditAccount, id), 0)
+}
+
+func TestGetHistory_Transaction(t *testing.T) {
+	db := openAuditTestDB(t)
+	accountID, _ := AddAccount(db, Account{Name: "TestGetHistory_Transaction"})
+	payeeID, _ := AddPayee(db, Payee{Name: "TestGetHistory_Transaction"})
+
+	id, err := AddTransaction(db, Transaction{AccountID: accountID, PayeeID: payeeID, AmountInCents: -1000})
+	assert.Nil(t, err)
+	_, err = UpdateTransaction(db, Transaction{ID: id, AccountID: accountID, PayeeID: payeeID, AmountInCents: -1200})
+	assert.Nil(t, err)
+
+	history := GetHistory(db, AuditTransaction, id)
+	assert.Len(t, history, 2)
+	changes, err := history[1].Changes()
+	assert.Nil(t, err)
+	assert.Equal(t, []AuditChange{{Column: "amount_in_cents", Old: json.Number("-1000"), New: json.Number("-1200")}}, changes)
+
+	assert.Len(t, GetHistory(db, AuditAccount, accountID), 1)
+}
+
+func TestWithAuditSource(t *testing.T) {
+	db := openAuditTestDB(t)
+	ctx := WithAuditSource(context.Background(), AuditSourceTUI)
+
+	id, err := AddAccountContext(ctx, db, Account{Name: "TestWithAuditSource"})
+	assert.Nil(t, err)
+	_, err = UpdateAccountContext(WithAuditSource(ctx, AuditSourceCLI), db, Account{ID: id, Name: "CLI"})
+	assert.Nil(t, err)
+	// The source doesn't outlive the transactions setting it
+	_, err = UpdateAccount(db, Account{ID: id, Name: "API"})
+	assert.Nil(t, err)
+	_, err = UpdateAccountContext(context.Background(), db, Account{ID: id, Name: "API context"})
+	assert.Nil(t, err)
+
+	var sources []AuditSource
+	for _, entry := range GetHistory(db, AuditAccount, id) {
+		sources = append(sources, entry.Source)
+	}
+	assert.Equal(t, []AuditSource{AuditSourceTUI, AuditSourceCLI, AuditSourceAPI, AuditSourceAPI}, sources)
+}
+
+func TestWithAuditSource_Rollback(t *testing.T) {
+	db := openAuditTestDB(t)
+	ctx := WithAuditSource(context.Background(), AuditSourceImport)
+	errTest := errors.New("test")
+
+	var id int
+	err := dbTransaction(withContext(ctx, db), func(tx dbExecutor) error {
+		var err error
+		id, err = addPayee(tx, Payee{Name: "TestWithAuditSource_Rollback"})
+		assert.Nil(t, err)
+		assert.Len(t, getHistory(tx, AuditPayee, id), 1)
+
+		return errTest
+	})
+	assert.ErrorIs(t, err, errTest)
+	assert.Len(t, GetHistory(db, AuditPayee, id), 0)
+
+	var source sql.NullString
+	assert.Nil(t, db.QueryRow(`SELECT source FROM audit_source`).Scan(&source))
+	assert.False(t, source.Valid)
+}
+
+func TestAuditLog_AppendOnly(t *testing.T) {
+	db := openAuditTestDB(t)
+	_, _ = AddPayee(db, Payee{Name: "TestAuditLog_AppendOnly"})
+
+	_, err := db.Exec(`UPDATE audit_log SET source = 'tui'`)
+	assert.ErrorContains(t, err, "append-only")
+	_, err = db.Exec(`DELETE FROM audit_log`)
+	assert.ErrorContains(t, err, "append-only")
+}
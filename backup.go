@@ -0,0 +1,369 @@
+package ezex
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/mattn/go-sqlite3"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+)
+
+const BackupsDirName = "backups"
+
+// backupTimeLayout is the timestamp of backup file names, sortable and safe on every file system
+const backupTimeLayout = "20060102-150405.000"
+
+// ErrInvalidBackup is returned when restoring a file that isn't an ez-ex DB
+var ErrInvalidBackup = errors.New("invalid backup")
+
+// BackupFile is a timestamped backup created by CreateBackup
+type BackupFile struct {
+	Path string
+	Time time.Time
+}
+
+// RetentionPolicy is how many backups PruneBackups keeps: the newest of the last Daily days, Weekly weeks
+// and Monthly months (a backup can count for more than one of them)
+type RetentionPolicy struct {
+	Daily   int
+	Weekly  int
+	Monthly int
+}
+
+var DefaultRetentionPolicy = RetentionPolicy{Daily: 7, Weekly: 4, Monthly: 12}
+
+// backupRequiredColumns are the columns a backup must have to be restored, later additions are migrated (see MigrateDB)
+var backupRequiredColumns = map[string][]string{
+	"accounts":     {"id", "name", "initial_balance_in_cents", "balance_in_cents"},
+	"payees":       {"id", "name"},
+	"categories":   {"id", "name"},
+	"transactions": {"id", "category_id", "payee_id", "account_id", "amount_in_cents", "transaction_date_unix"},
+}
+
+// DefaultBackupsDir returns the default backups directory, inside DataDir
+func DefaultBackupsDir() string {
+	return path.Join(DataDir(), BackupsDirName)
+}
+
+// Backup copies the DB to dest with SQLite online backup, so it's safe while the DB is in use.
+// dest is overwritten if it exists
+func Backup(db *sql.DB, dest string) error {
+	return BackupContext(context.Background(), db, dest)
+}
+
+func BackupContext(ctx context.Context, db *sql.DB, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0700); err != nil {
+		return err
+	}
+	if err := os.Remove(dest); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return withSQLiteConn(ctx, db, func(src *sqlite3.SQLiteConn) error {
+		return copyDB(ctx, src, dest, true)
+	})
+}
+
+// BackupPath returns the path of a new backup of name inside dir, `<name>-<timestamp>.db`
+func BackupPath(dir string, name string) string {
+	return path.Join(dir, fmt.Sprintf("%s-%s.db", name, time.Now().Format(backupTimeLayout)))
+}
+
+// CreateBackup backs up the DB into dir (see BackupPath) and returns the backup path,
+// name is usually the DB file name without extension. Existing backups are never overwritten
+func CreateBackup(db *sql.DB, dir string, name string) (string, error) {
+	return CreateBackupContext(context.Background(), db, dir, name)
+}
+
+func CreateBackupContext(ctx context.Context, db *sql.DB, dir string, name string) (string, error) {
+	dest := BackupPath(dir, name)
+	if _, err := os.Stat(dest); err == nil {
+		return "", fmt.Errorf("backup already exists: %s", dest)
+	}
+
+	return dest, BackupContext(ctx, db, dest)
+}
+
+// GetBackups returns the backups of name (see CreateBackup) inside dir, newest first.
+// Encrypted backups (`.db.enc`) are included
+func GetBackups(dir string, name string) ([]BackupFile, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	pattern := regexp.MustCompile(`^` + regexp.QuoteMeta(name) + `-(\d{8}-\d{6}\.\d{3})\.db(\.enc)?$`)
+	var backups []BackupFile
+	for _, entry := range entries {
+		match := pattern.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		date, err := time.ParseInLocation(backupTimeLayout, match[1], time.Local)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, BackupFile{Path: path.Join(dir, entry.Name()), Time: date})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Time.After(backups[j].Time)
+	})
+
+	return backups, nil
+}
+
+// PruneBackups removes the backups of name not kept by the policy and returns the removed ones,
+// the newest backup is always kept
+func PruneBackups(dir string, name string, policy RetentionPolicy) ([]BackupFile, error) {
+	backups, err := GetBackups(dir, name)
+	if err != nil || len(backups) == 0 {
+		return nil, err
+	}
+
+	kept := map[string]bool{backups[0].Path: true}
+	keepNewestPerPeriod := func(count int, period func(time.Time) string) {
+		periods := map[string]bool{}
+		for _, backup := range backups {
+			key := period(backup.Time)
+			if len(periods) >= count {
+				return
+			}
+			if !periods[key] {
+				periods[key] = true
+				kept[backup.Path] = true
+			}
+		}
+	}
+	keepNewestPerPeriod(policy.Daily, func(t time.Time) string {
+		return t.Format(time.DateOnly)
+	})
+	keepNewestPerPeriod(policy.Weekly, func(t time.Time) string {
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-%d", year, week)
+	})
+	keepNewestPerPeriod(policy.Monthly, func(t time.Time) string {
+		return t.Format("2006-01")
+	})
+
+	var removed []BackupFile
+	for _, backup := range backups {
+		if kept[backup.Path] {
+			continue
+		}
+		if err := os.Remove(backup.Path); err != nil {
+			return removed, err
+		}
+		removed = append(removed, backup)
+	}
+
+	return removed, nil
+}
+
+// ValidateBackup checks that src is a healthy SQLite DB with the ez-ex tables, wrapping ErrInvalidBackup otherwise
+func ValidateBackup(src string) error {
+	if _, err := os.Stat(src); err != nil {
+		return err
+	}
+
+	// The FTS5 integrity check writes to the search index, so it runs on a writable copy of src
+	checked, err := copyToTemp(src)
+	if err != nil {
+		return err
+	}
+	defer func(checked string) {
+		_ = os.Remove(checked)
+	}(checked)
+
+	backup, err := sql.Open("sqlite3", fileDSN(checked, nil))
+	if err != nil {
+		return err
+	}
+	defer func(backup *sql.DB) {
+		_ = backup.Close()
+	}(backup)
+
+	var integrity string
+	if err = backup.QueryRow(`PRAGMA integrity_check`).Scan(&integrity); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidBackup, err)
+	}
+	if integrity != "ok" {
+		return fmt.Errorf("%w: %s", ErrInvalidBackup, integrity)
+	}
+
+	for table, columns := range backupRequiredColumns {
+		type columnRow struct{ Name string }
+		found := map[string]bool{}
+		for _, column := range dbGet[columnRow](backup, `SELECT name FROM pragma_table_info($table)`, table) {
+			found[column.Name] = true
+		}
+
+		for _, column := range columns {
+			if !found[column] {
+				return fmt.Errorf("%w: missing column %s.%s", ErrInvalidBackup, table, column)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Restore replaces the DB content with the src backup (see ValidateBackup) using SQLite online backup,
+// MigrateDB must be called afterwards since the backup may be older than the app
+func Restore(db *sql.DB, src string) error {
+	return RestoreContext(context.Background(), db, src)
+}
+
+func RestoreContext(ctx context.Context, db *sql.DB, src string) error {
+	if err := ValidateBackup(src); err != nil {
+		return err
+	}
+
+	return withSQLiteConn(ctx, db, func(dest *sqlite3.SQLiteConn) error {
+		return copyDB(ctx, dest, src, false)
+	})
+}
+
+// needsMigration reports whether MigrateDB would change an existing DB, new (empty) DBs don't need it
+func needsMigration(db dbExecutor) bool {
+	type objectRow struct{ Name string }
+	objects := map[string]bool{}
+	for _, object := range dbGet[objectRow](db, `SELECT name FROM sqlite_master`) {
+		objects[object.Name] = true
+	}
+	if len(objects) == 0 {
+		return false
+	}
+
+	type columnsRow struct {
+		ParentColumns int
+		StatusColumns int
+	}
+	columns := dbGet[columnsRow](
+		db,
+		`
+		SELECT	(SELECT COUNT(*) FROM pragma_table_info('categories') WHERE name = 'parent_id'),
+				(SELECT COUNT(*) FROM pragma_table_info('transactions') WHERE name = 'status')
+		`,
+	)
+	if len(columns) == 0 || columns[0].ParentColumns == 0 || columns[0].StatusColumns == 0 {
+		return true
+	}
+
+	scripts := dbInitScriptSQL + dbAuditScriptSQL
+	if isFTS5Available(db) {
+		scripts += dbSearchScriptSQL
+	}
+	created := regexp.MustCompile(`(?i)CREATE\s+(?:VIRTUAL\s+)?(?:TABLE|INDEX|VIEW|TRIGGER)\s+IF\s+NOT\s+EXISTS\s+(\w+)`)
+	for _, match := range created.FindAllStringSubmatch(scripts, -1) {
+		if !objects[match[1]] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// withSQLiteConn runs fn with the driver connection of a pooled connection
+func withSQLiteConn(ctx context.Context, db *sql.DB, fn func(conn *sqlite3.SQLiteConn) error) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer func(conn *sql.Conn) {
+		_ = conn.Close()
+	}(conn)
+
+	return conn.Raw(func(driverConn any) error {
+		sqliteConn, ok := driverConn.(*sqlite3.SQLiteConn)
+		if !ok {
+			return errors.New("backups are only supported on SQLite DBs")
+		}
+
+		return fn(sqliteConn)
+	})
+}
+
+// copyDB copies conn to the file (toFile = true) or the file to conn with SQLite backup API,
+// it stops once ctx is done
+func copyDB(ctx context.Context, conn *sqlite3.SQLiteConn, file string, toFile bool) error {
+	dsn := fileDSN(file, nil)
+	if !toFile {
+		dsn = fileDSN(file, url.Values{"mode": {"ro"}})
+	}
+	driverConn, err := (&sqlite3.SQLiteDriver{}).Open(dsn)
+	if err != nil {
+		return err
+	}
+	fileConn := driverConn.(*sqlite3.SQLiteConn)
+	defer func(fileConn *sqlite3.SQLiteConn) {
+		_ = fileConn.Close()
+	}(fileConn)
+
+	src, dest := conn, fileConn
+	if !toFile {
+		src, dest = fileConn, conn
+	}
+
+	backup, err := dest.Backup("main", src, "main")
+	if err != nil {
+		return err
+	}
+
+	// Copy every page at once, Step is retried while the source is locked by a writer
+	for {
+		done, err := backup.Step(-1)
+		if err != nil {
+			_ = backup.Finish()
+			return err
+		}
+		if done {
+			break
+		}
+		if err = ctx.Err(); err != nil {
+			_ = backup.Finish()
+			return err
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	return backup.Finish()
+}
+
+// copyToTemp copies src to a new temporary file and returns its path, removing it is up to the caller
+func copyToTemp(src string) (string, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return "", err
+	}
+	defer func(in *os.File) {
+		_ = in.Close()
+	}(in)
+
+	out, err := os.CreateTemp("", "ez-ex-*.db")
+	if err != nil {
+		return "", err
+	}
+	_, err = io.Copy(out, in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(out.Name())
+		return "", err
+	}
+
+	return out.Name(), nil
+}
@@ -0,0 +1,102 @@
+package ezex
+
+import (
+	"database/sql"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+func TestBackupAndRestore(t *testing.T) {
+	// Characters with a meaning in URIs are part of the path
+	dir := path.Join(t.TempDir(), "my backups #1?")
+	accountID, _ := AddAccount(testDB, Account{Name: "TestBackupAndRestore"})
+
+	backupPath, err := CreateBackup(testDB, dir, "user-data")
+	assert.Nil(t, err)
+	assert.Nil(t, ValidateBackup(backupPath))
+
+	restored, err := sql.Open("sqlite3", path.Join(dir, "restored.db"))
+	assert.Nil(t, err)
+	defer func(db *sql.DB) {
+		_ = db.Close()
+	}(restored)
+
+	assert.Nil(t, Restore(restored, backupPath))
+	assert.Nil(t, MigrateDB(restored))
+	account, err := GetAccount(restored, accountID)
+	assert.Nil(t, err)
+	assert.Equal(t, "TestBackupAndRestore", account.Name)
+}
+
+func TestValidateBackup(t *testing.T) {
+	dir := t.TempDir()
+
+	notDB := path.Join(dir, "not-a-db.db")
+	_ = os.WriteFile(notDB, []byte("not a database, just some text long enough to be a header"), 0600)
+	assert.ErrorIs(t, ValidateBackup(notDB), ErrInvalidBackup)
+
+	otherDB := path.Join(dir, "other.db")
+	other, _ := sql.Open("sqlite3", otherDB)
+	_, _ = other.Exec(`CREATE TABLE accounts (id INTEGER PRIMARY KEY)`)
+	_ = other.Close()
+	assert.ErrorIs(t, ValidateBackup(otherDB), ErrInvalidBackup)
+
+	assert.Error(t, ValidateBackup(path.Join(dir, "missing.db")))
+}
+
+func TestPruneBackups(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2108, 3, 15, 12, 0, 0, 0, time.Local)
+	var names []string
+	for i := 0; i < 60; i++ {
+		name := "user-data-" + now.AddDate(0, 0, -i).Format(backupTimeLayout) + ".db"
+		if i%2 == 1 {
+			name += ".enc"
+		}
+		names = append(names, name)
+		_ = os.WriteFile(path.Join(dir, name), nil, 0600)
+	}
+	_ = os.WriteFile(path.Join(dir, "other-20080101-000000.000.db"), nil, 0600)
+
+	removed, err := PruneBackups(dir, "user-data", RetentionPolicy{Daily: 3, Weekly: 2, Monthly: 2})
+	assert.Nil(t, err)
+
+	backups, _ := GetBackups(dir, "user-data")
+	var kept []string
+	for _, backup := range backups {
+		kept = append(kept, path.Base(backup.Path))
+	}
+	assert.Equal(t, []string{
+		names[0], names[1], names[2], // last 3 days
+		names[4],  // newest of the previous week (2108-03-15 is a Thursday)
+		names[15], // newest of the previous month
+	}, kept)
+	assert.Len(t, removed, 60-len(kept))
+	assert.FileExists(t, path.Join(dir, "other-20080101-000000.000.db"))
+}
+
+func TestMigrateDBBackup(t *testing.T) {
+	dir := t.TempDir()
+	db, _ := sql.Open("sqlite3", path.Join(dir, "legacy.db"))
+	defer func(db *sql.DB) {
+		_ = db.Close()
+	}(db)
+
+	// New DBs aren't backed up
+	assert.Nil(t, MigrateDB(db, WithMigrationBackup(dir, "legacy")))
+	backups, _ := GetBackups(dir, "legacy")
+	assert.Len(t, backups, 0)
+
+	// Up to date DBs aren't either
+	assert.Nil(t, MigrateDB(db, WithMigrationBackup(dir, "legacy")))
+	backups, _ = GetBackups(dir, "legacy")
+	assert.Len(t, backups, 0)
+
+	_, _ = db.Exec(`DROP TABLE dismissed_duplicates`)
+	assert.Nil(t, MigrateDB(db, WithMigrationBackup(dir, "legacy")))
+	backups, _ = GetBackups(dir, "legacy")
+	assert.Len(t, backups, 1)
+}
@@ -1,36 +1,173 @@
 package ezex
 
-import "database/sql"
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// CategoryPathSeparator separates the names of a category path, e.g. `Car:Fuel`
+const CategoryPathSeparator = ":"
 
 type Category struct {
 	ID          int
 	Name        string
 	Description sql.NullString
+	// ParentID is the parent category, 0 ("no category") for top-level categories
+	ParentID int
+	// Path (`Parent:Child`) and Depth (0 for top-level categories) are read-only, filled when getting categories
+	Path  string
+	Depth int
 }
 
+// ErrInvalidCategoryName is returned when a category name is empty or contains CategoryPathSeparator
+var ErrInvalidCategoryName = fmt.Errorf("category names can't be empty or contain %q", CategoryPathSeparator)
+
+// ErrCategoryCycle is returned when moving a category under itself or one of its subcategories
+var ErrCategoryCycle = errors.New("a category can't be moved under itself or its subcategories")
+
+// categorySelectQuery selects Category fields, categories as `c`
+const categorySelectQuery = `
+		SELECT		c.id,
+					c.name,
+					c.description,
+					c.parent_id,
+					cp.path,
+					cp.depth
+		FROM		categories c
+		JOIN		category_paths cp
+		ON			cp.id = c.id
+		`
+
 func (c Category) GetName() string {
 	return c.Name
 }
 
 // AddCategory creates a new category and returns the new category ID if successful
 func AddCategory(db *sql.DB, category Category) (int, error) {
-	return dbAdd(db, `INSERT INTO categories (name, description) VALUES ($name, $description)`, category.Name, category.Description)
+	return addCategory(db, category)
+}
+
+func AddCategoryContext(ctx context.Context, db *sql.DB, category Category) (int, error) {
+	return addCategory(withContext(ctx, db), category)
+}
+
+func addCategory(db dbExecutor, category Category) (int, error) {
+	if !isValidCategoryName(category.Name) {
+		return -1, ErrInvalidCategoryName
+	}
+
+	return dbAdd(
+		db,
+		`INSERT INTO categories (name, description, parent_id) VALUES ($name, $description, $parent_id)`,
+		category.Name,
+		category.Description,
+		category.ParentID,
+	)
+}
+
+// AddCategoryPath returns the ID of the category at path (e.g. `Car:Fuel`), creating it and its missing ancestors
+func AddCategoryPath(db *sql.DB, path string) (int, error) {
+	return addCategoryPath(db, path)
+}
+
+func AddCategoryPathContext(ctx context.Context, db *sql.DB, path string) (int, error) {
+	return addCategoryPath(withContext(ctx, db), path)
 }
 
-// DeleteCategory deletes a category, trying to delete ID 0 is not allowed and will be noop
-// returns the number of affected rows
-func DeleteCategory(db *sql.DB, id int) int {
+func addCategoryPath(db dbExecutor, path string) (int, error) {
+	names := strings.Split(path, CategoryPathSeparator)
+	for i, name := range names {
+		names[i] = strings.TrimSpace(name)
+		if !isValidCategoryName(names[i]) {
+			return -1, ErrInvalidCategoryName
+		}
+	}
+
+	parentID := 0
+	err := dbTransaction(db, func(tx dbExecutor) error {
+		for _, name := range names {
+			existing := dbGet[struct{ ID int }](
+				tx,
+				`SELECT id FROM categories WHERE parent_id = $parent_id AND name = $name AND id != 0`,
+				parentID,
+				name,
+			)
+			if len(existing) > 0 {
+				parentID = existing[0].ID
+				continue
+			}
+
+			id, err := dbAdd(tx, `INSERT INTO categories (name, parent_id) VALUES ($name, $parent_id)`, name, parentID)
+			if err != nil {
+				return err
+			}
+			parentID = id
+		}
+
+		return nil
+	})
+	if err != nil {
+		return -1, err
+	}
+
+	return parentID, nil
+}
+
+// DeleteCategory deletes a category, trying to delete ID 0 is not allowed (ErrProtectedCategory).
+// Its subcategories are moved to its parent, returns the number of affected rows
+func DeleteCategory(db *sql.DB, id int) (int, error) {
+	return deleteCategory(db, id)
+}
+
+func DeleteCategoryContext(ctx context.Context, db *sql.DB, id int) (int, error) {
+	return deleteCategory(withContext(ctx, db), id)
+}
+
+func deleteCategory(db dbExecutor, id int) (int, error) {
 	if id == 0 {
-		return 0
+		return 0, ErrProtectedCategory
 	}
 
-	return dbDelete(db, `DELETE FROM categories WHERE id = $id`, id)
+	n := 0
+	err := dbTransaction(db, func(tx dbExecutor) error {
+		// Fails with ErrDuplicateName if a subcategory has the name of one under the parent
+		_, err := tx.Exec(
+			`UPDATE categories SET parent_id = (SELECT parent_id FROM categories WHERE id = $id) WHERE parent_id = $id`,
+			id,
+		)
+		if err != nil {
+			return dbError(err, ErrNotFound)
+		}
+
+		n, err = dbDelete(tx, `DELETE FROM categories WHERE id = $id`, id)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return n, nil
 }
 
-// UpdateCategory updates a category, trying to update ID 0 is not allowed and will be noop
+// UpdateCategory updates a category name and description, trying to update ID 0 is not allowed (ErrProtectedCategory).
+// Use MoveCategory to change its parent
 func UpdateCategory(db *sql.DB, category Category) (int, error) {
+	return updateCategory(db, category)
+}
+
+func UpdateCategoryContext(ctx context.Context, db *sql.DB, category Category) (int, error) {
+	return updateCategory(withContext(ctx, db), category)
+}
+
+func updateCategory(db dbExecutor, category Category) (int, error) {
 	if category.ID == 0 {
-		return 0, nil
+		return 0, ErrProtectedCategory
+	}
+	if !isValidCategoryName(category.Name) {
+		return 0, ErrInvalidCategoryName
 	}
 
 	return dbUpdate(
@@ -47,9 +184,94 @@ func UpdateCategory(db *sql.DB, category Category) (int, error) {
 	)
 }
 
+// MoveCategory moves a category (along with its subcategories) under parentID, 0 makes it top-level.
+// Trying to move ID 0 is not allowed (ErrProtectedCategory)
+func MoveCategory(db *sql.DB, id int, parentID int) error {
+	return moveCategory(db, id, parentID)
+}
+
+func MoveCategoryContext(ctx context.Context, db *sql.DB, id int, parentID int) error {
+	return moveCategory(withContext(ctx, db), id, parentID)
+}
+
+func moveCategory(db dbExecutor, id int, parentID int) error {
+	if id == 0 {
+		return ErrProtectedCategory
+	}
+
+	return dbTransaction(db, func(tx dbExecutor) error {
+		if parentID != 0 {
+			ancestors := dbGet[struct{ AncestorID int }](
+				tx,
+				`SELECT ancestor_id FROM category_ancestors WHERE category_id = $parent_id`,
+				parentID,
+			)
+			if len(ancestors) == 0 {
+				return fmt.Errorf("%w: category %d", ErrNotFound, parentID)
+			}
+
+			for _, ancestor := range ancestors {
+				if ancestor.AncestorID == id {
+					return ErrCategoryCycle
+				}
+			}
+		}
+
+		_, err := dbUpdate(tx, `UPDATE categories SET parent_id = $parent_id WHERE id = $id`, parentID, id)
+		return err
+	})
+}
+
+// GetCategories returns every category, newest first
 func GetCategories(db *sql.DB) []Category {
+	return getCategories(db)
+}
+
+func GetCategoriesContext(ctx context.Context, db *sql.DB) []Category {
+	return getCategories(withContext(ctx, db))
+}
+
+func getCategories(db dbExecutor) []Category {
 	return dbGet[Category](
 		db,
-		`SELECT id, name, description FROM categories ORDER BY id DESC`,
+		categorySelectQuery+`ORDER BY c.id DESC`,
 	)
 }
+
+// GetCategoryTree returns every category depth-first (each category followed by its subcategories),
+// siblings sorted by name and "no category" first
+func GetCategoryTree(db *sql.DB) []Category {
+	return getCategoryTree(db)
+}
+
+func GetCategoryTreeContext(ctx context.Context, db *sql.DB) []Category {
+	return getCategoryTree(withContext(ctx, db))
+}
+
+func getCategoryTree(db dbExecutor) []Category {
+	return dbGet[Category](
+		db,
+		categorySelectQuery+`ORDER BY c.id != 0, REPLACE(cp.path, ':', CHAR(1)) COLLATE NOCASE`,
+	)
+}
+
+// GetSubcategories returns the direct subcategories of a category sorted by name, 0 returns the top-level ones
+func GetSubcategories(db *sql.DB, parentID int) []Category {
+	return getSubcategories(db, parentID)
+}
+
+func GetSubcategoriesContext(ctx context.Context, db *sql.DB, parentID int) []Category {
+	return getSubcategories(withContext(ctx, db), parentID)
+}
+
+func getSubcategories(db dbExecutor, parentID int) []Category {
+	return dbGet[Category](
+		db,
+		categorySelectQuery+`WHERE c.parent_id = $parent_id AND c.id != 0 ORDER BY c.name COLLATE NOCASE`,
+		parentID,
+	)
+}
+
+func isValidCategoryName(name string) bool {
+	return strings.TrimSpace(name) != "" && !strings.Contains(name, CategoryPathSeparator)
+}
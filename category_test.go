@@ -3,6 +3,8 @@ package ezex
 import (
 	"database/sql"
 	"github.com/stretchr/testify/assert"
+	"path"
+	"strings"
 	"testing"
 )
 
@@ -40,14 +42,16 @@ func TestDeleteCategory(t *testing.T) {
 		Name:        "TestDeleteCategory",
 		Description: sql.NullString{},
 	})
-	n := DeleteCategory(testDB, id)
+	n, err := DeleteCategory(testDB, id)
 
 	assert.Greater(t, n, 0)
+	assert.Nil(t, err)
 }
 
 func TestDeleteCategory_DefaultCategory(t *testing.T) {
-	n := DeleteCategory(testDB, 0)
+	n, err := DeleteCategory(testDB, 0)
 	assert.Equal(t, 0, n)
+	assert.ErrorIs(t, err, ErrProtectedCategory)
 }
 
 func TestUpdateCategory(t *testing.T) {
@@ -81,7 +85,7 @@ func TestUpdateCategory_Unique(t *testing.T) {
 	})
 
 	assert.Equal(t, 0, n)
-	assert.Error(t, err)
+	assert.ErrorIs(t, err, ErrDuplicateName)
 }
 
 func TestUpdateCategory_DefaultCategory(t *testing.T) {
@@ -92,7 +96,7 @@ func TestUpdateCategory_DefaultCategory(t *testing.T) {
 	})
 
 	assert.Equal(t, 0, n)
-	assert.Nil(t, err)
+	assert.ErrorIs(t, err, ErrProtectedCategory)
 }
 
 func TestGetCategories(t *testing.T) {
@@ -149,3 +153,154 @@ func TestCategory_GetName(t *testing.T) {
 	cat := Category{Name: "TestCategory_GetName"}
 	assert.Equal(t, "TestCategory_GetName", cat.GetName())
 }
+
+func TestAddCategory_InvalidName(t *testing.T) {
+	_, err := AddCategory(testDB, Category{Name: "TestAddCategory:InvalidName"})
+	assert.ErrorIs(t, err, ErrInvalidCategoryName)
+
+	_, err = AddCategory(testDB, Category{Name: " "})
+	assert.ErrorIs(t, err, ErrInvalidCategoryName)
+}
+
+func TestAddCategory_SameNameDifferentParent(t *testing.T) {
+	parent1ID, _ := AddCategory(testDB, Category{Name: "TestAddCategory_SameNameDifferentParent1"})
+	parent2ID, _ := AddCategory(testDB, Category{Name: "TestAddCategory_SameNameDifferentParent2"})
+
+	_, err1 := AddCategory(testDB, Category{Name: "Insurance", ParentID: parent1ID})
+	_, err2 := AddCategory(testDB, Category{Name: "Insurance", ParentID: parent2ID})
+	_, err3 := AddCategory(testDB, Category{Name: "Insurance", ParentID: parent2ID})
+
+	assert.Nil(t, err1)
+	assert.Nil(t, err2)
+	assert.Error(t, err3)
+}
+
+func TestAddCategoryPath(t *testing.T) {
+	fuelID, err := AddCategoryPath(testDB, "TestAddCategoryPath: Car:Fuel")
+	assert.Nil(t, err)
+
+	// Existing categories are reused
+	insuranceID, err := AddCategoryPath(testDB, "TestAddCategoryPath:Car:Insurance")
+	assert.Nil(t, err)
+	sameFuelID, err := AddCategoryPath(testDB, "TestAddCategoryPath:Car:Fuel")
+	assert.Nil(t, err)
+	assert.Equal(t, fuelID, sameFuelID)
+
+	_, err = AddCategoryPath(testDB, "TestAddCategoryPath::Fuel")
+	assert.ErrorIs(t, err, ErrInvalidCategoryName)
+
+	var paths []string
+	for _, category := range GetCategoryTree(testDB) {
+		if category.ID == fuelID || category.ID == insuranceID {
+			paths = append(paths, category.Path)
+			assert.Equal(t, 2, category.Depth)
+		}
+	}
+	assert.Equal(t, []string{"TestAddCategoryPath:Car:Fuel", "TestAddCategoryPath:Car:Insurance"}, paths)
+}
+
+func TestMoveCategory(t *testing.T) {
+	parentID, _ := AddCategoryPath(testDB, "TestMoveCategory")
+	childID, _ := AddCategoryPath(testDB, "TestMoveCategory:Child")
+	otherID, _ := AddCategory(testDB, Category{Name: "TestMoveCategory other"})
+
+	assert.Nil(t, MoveCategory(testDB, otherID, childID))
+	assert.ErrorIs(t, MoveCategory(testDB, parentID, otherID), ErrCategoryCycle)
+	assert.ErrorIs(t, MoveCategory(testDB, parentID, parentID), ErrCategoryCycle)
+	assert.ErrorIs(t, MoveCategory(testDB, otherID, -1), ErrNotFound)
+	assert.ErrorIs(t, MoveCategory(testDB, 0, otherID), ErrProtectedCategory)
+
+	subcategories := GetSubcategories(testDB, childID)
+	assert.Len(t, subcategories, 1)
+	assert.Equal(t, "TestMoveCategory:Child:TestMoveCategory other", subcategories[0].Path)
+
+	assert.Nil(t, MoveCategory(testDB, otherID, 0))
+	assert.Empty(t, GetSubcategories(testDB, childID))
+}
+
+func TestDeleteCategory_MovesSubcategories(t *testing.T) {
+	parentID, _ := AddCategoryPath(testDB, "TestDeleteCategory_MovesSubcategories")
+	middleID, _ := AddCategoryPath(testDB, "TestDeleteCategory_MovesSubcategories:Middle")
+	childID, _ := AddCategoryPath(testDB, "TestDeleteCategory_MovesSubcategories:Middle:Child")
+
+	n, err := DeleteCategory(testDB, middleID)
+
+	subcategories := GetSubcategories(testDB, parentID)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, n)
+	assert.Len(t, subcategories, 1)
+	assert.Equal(t, childID, subcategories[0].ID)
+	assert.Equal(t, "TestDeleteCategory_MovesSubcategories:Child", subcategories[0].Path)
+}
+
+func TestDeleteCategory_DuplicateSubcategory(t *testing.T) {
+	parentID, _ := AddCategoryPath(testDB, "TestDeleteCategory_DuplicateSubcategory")
+	middleID, _ := AddCategoryPath(testDB, "TestDeleteCategory_DuplicateSubcategory:Car")
+	_, _ = AddCategoryPath(testDB, "TestDeleteCategory_DuplicateSubcategory:Car:Fuel")
+	_, _ = AddCategoryPath(testDB, "TestDeleteCategory_DuplicateSubcategory:Fuel")
+
+	n, err := DeleteCategory(testDB, middleID)
+
+	assert.Equal(t, 0, n)
+	assert.ErrorIs(t, err, ErrDuplicateName)
+	assert.Len(t, GetSubcategories(testDB, parentID), 2)
+}
+
+func TestGetCategoryTree(t *testing.T) {
+	_, _ = AddCategoryPath(testDB, "TestGetCategoryTree:B")
+	_, _ = AddCategoryPath(testDB, "TestGetCategoryTree A")
+	_, _ = AddCategoryPath(testDB, "TestGetCategoryTree:A")
+
+	var paths []string
+	tree := GetCategoryTree(testDB)
+	for _, category := range tree {
+		if strings.HasPrefix(category.Path, "TestGetCategoryTree") {
+			paths = append(paths, category.Path)
+		}
+	}
+
+	assert.Equal(t, 0, tree[0].ID)
+	assert.Equal(t, []string{"TestGetCategoryTree", "TestGetCategoryTree:A", "TestGetCategoryTree:B", "TestGetCategoryTree A"}, paths)
+}
+
+func TestMigrateDB_CategoriesTree(t *testing.T) {
+	db, err := sql.Open("sqlite3", path.Join(t.TempDir(), "migrate-test.db")+"?_foreign_keys=true")
+	assert.Nil(t, err)
+	defer func(db *sql.DB) {
+		_ = db.Close()
+	}(db)
+
+	// Schema before hierarchical categories
+	_, err = db.Exec(`
+		CREATE TABLE categories (id INTEGER PRIMARY KEY, name TEXT NOT NULL UNIQUE, description TEXT);
+		INSERT INTO categories (id, name) VALUES (0, 'no category'), (1, 'Fuel');
+		CREATE TABLE transactions (
+			id INTEGER PRIMARY KEY,
+			category_id INTEGER NOT NULL DEFAULT 0,
+			payee_id INTEGER NOT NULL,
+			account_id INTEGER NOT NULL,
+			amount_in_cents INTEGER NOT NULL,
+			transaction_date_unix INTEGER NOT NULL,
+			update_date_unix INTEGER,
+			delete_date_unix INTEGER,
+			notes TEXT,
+			FOREIGN KEY (category_id) REFERENCES categories ON DELETE SET DEFAULT
+		);
+		INSERT INTO transactions (id, category_id, payee_id, account_id, amount_in_cents, transaction_date_unix)
+		VALUES (1, 1, 1, 1, 100, 0);
+	`)
+	assert.Nil(t, err)
+
+	assert.Nil(t, MigrateDB(db))
+
+	var categoryID int
+	assert.Nil(t, db.QueryRow(`SELECT category_id FROM transactions WHERE id = 1`).Scan(&categoryID))
+	assert.Equal(t, 1, categoryID)
+
+	fuel := GetSubcategories(db, 0)
+	assert.Len(t, fuel, 1)
+	assert.Equal(t, Category{ID: 1, Name: "Fuel", Path: "Fuel"}, fuel[0])
+
+	// Already migrated DBs are left as they are
+	assert.Nil(t, MigrateDB(db))
+}
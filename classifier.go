@@ -0,0 +1,206 @@
+package ezex
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// CategoryClassifier suggests the category of new transactions, it's a naive Bayes classifier trained on the payee
+// name tokens, notes words and amount bucket (see amountBucket) of the categorized transactions
+type CategoryClassifier struct {
+	// documents is the number of training transactions
+	documents int
+	// categoryDocuments is the number of training transactions by category
+	categoryDocuments map[int]int
+	// featureCounts is the number of occurrences of each feature by category
+	featureCounts map[int]map[string]int
+	// featureTotals is the number of feature occurrences by category
+	featureTotals map[int]int
+	vocabulary    map[string]struct{}
+}
+
+// CategorySuggestion is a suggested category, Confidence is its probability between 0 and 1
+type CategorySuggestion struct {
+	CategoryID int
+	Confidence float64
+}
+
+// TrainCategoryClassifier trains a classifier on the categorized, non-deleted and non-split transactions
+func TrainCategoryClassifier(db *sql.DB) *CategoryClassifier {
+	return trainCategoryClassifier(db)
+}
+
+func TrainCategoryClassifierContext(ctx context.Context, db *sql.DB) *CategoryClassifier {
+	return trainCategoryClassifier(withContext(ctx, db))
+}
+
+func trainCategoryClassifier(db dbExecutor) *CategoryClassifier {
+	c := &CategoryClassifier{
+		categoryDocuments: make(map[int]int),
+		featureCounts:     make(map[int]map[string]int),
+		featureTotals:     make(map[int]int),
+		vocabulary:        make(map[string]struct{}),
+	}
+
+	type trainingRow struct {
+		CategoryID    int
+		PayeeName     string
+		AmountInCents int64
+		Notes         sql.NullString
+	}
+	_ = dbEach[trainingRow](db, func(row trainingRow) error {
+		c.train(row.CategoryID, transactionFeatures(row.PayeeName, row.AmountInCents, row.Notes.String))
+		return nil
+	}, `
+		SELECT	t.category_id,
+				p.name,
+				t.amount_in_cents,
+				t.notes
+		FROM	transactions t
+		JOIN	payees p
+		ON		p.id = t.payee_id
+		WHERE	t.category_id != 0
+			AND t.delete_date_unix IS NULL
+			AND NOT EXISTS (SELECT 1 FROM transaction_splits s WHERE s.transaction_id = t.id)
+		`,
+	)
+
+	return c
+}
+
+// SuggestCategory trains a classifier (see TrainCategoryClassifier) and suggests the category of a draft transaction,
+// ok is false if there's no categorized transaction to learn from
+func SuggestCategory(db *sql.DB, draft Transaction) (suggestion CategorySuggestion, ok bool) {
+	return suggestCategory(db, draft)
+}
+
+func SuggestCategoryContext(ctx context.Context, db *sql.DB, draft Transaction) (suggestion CategorySuggestion, ok bool) {
+	return suggestCategory(withContext(ctx, db), draft)
+}
+
+func suggestCategory(db dbExecutor, draft Transaction) (suggestion CategorySuggestion, ok bool) {
+	payeeName := ""
+	if payees := dbGet[Payee](db, `SELECT id, name, description FROM payees WHERE id = $id`, draft.PayeeID); len(payees) > 0 {
+		payeeName = payees[0].Name
+	}
+
+	return trainCategoryClassifier(db).Suggest(payeeName, draft)
+}
+
+// Suggest returns the most likely category of a draft transaction paid to payeeName (draft.PayeeID is ignored),
+// ok is false if the classifier wasn't trained on any transaction
+func (c *CategoryClassifier) Suggest(payeeName string, draft Transaction) (suggestion CategorySuggestion, ok bool) {
+	if c == nil || c.documents == 0 {
+		return CategorySuggestion{}, false
+	}
+
+	features := transactionFeatures(payeeName, draft.AmountInCents, draft.Notes.String)
+
+	// Log-probabilities avoid underflows, unknown features are ignored
+	scores := make(map[int]float64, len(c.categoryDocuments))
+	best := CategorySuggestion{CategoryID: -1}
+	bestScore := math.Inf(-1)
+	for categoryID, documents := range c.categoryDocuments {
+		score := math.Log(float64(documents) / float64(c.documents))
+		denominator := float64(c.featureTotals[categoryID] + len(c.vocabulary))
+		for _, feature := range features {
+			if _, known := c.vocabulary[feature]; known {
+				score += math.Log(float64(c.featureCounts[categoryID][feature]+1) / denominator)
+			}
+		}
+
+		scores[categoryID] = score
+		// Ties are broken by the lowest ID, so that suggestions are stable
+		if score > bestScore || (score == bestScore && categoryID < best.CategoryID) {
+			best.CategoryID = categoryID
+			bestScore = score
+		}
+	}
+
+	// Confidence is the normalized probability (softmax) of the best category
+	sum := 0.0
+	for _, score := range scores {
+		sum += math.Exp(score - bestScore)
+	}
+	best.Confidence = 1 / sum
+
+	return best, true
+}
+
+// Learn trains the classifier on one more transaction, so that it's kept up to date without retraining it on every
+// transaction. Uncategorized, deleted and split transactions are ignored, like in TrainCategoryClassifier.
+// It's not safe for concurrent use
+func (c *CategoryClassifier) Learn(transaction TransactionView) {
+	if c == nil || transaction.CategoryID == 0 || transaction.DeleteDateUnix.Valid || transaction.SplitCount > 0 {
+		return
+	}
+
+	c.train(
+		transaction.CategoryID,
+		transactionFeatures(transaction.PayeeName, transaction.AmountInCents, transaction.Notes.String),
+	)
+}
+
+func (c *CategoryClassifier) train(categoryID int, features []string) {
+	c.documents++
+	c.categoryDocuments[categoryID]++
+	if c.featureCounts[categoryID] == nil {
+		c.featureCounts[categoryID] = make(map[string]int)
+	}
+
+	for _, feature := range features {
+		c.featureCounts[categoryID][feature]++
+		c.featureTotals[categoryID]++
+		c.vocabulary[feature] = struct{}{}
+	}
+}
+
+// transactionFeatures returns the classifier features of a transaction: its payee tokens, notes words and amount bucket
+func transactionFeatures(payeeName string, amountInCents int64, notes string) []string {
+	var features []string
+	for _, token := range classifierTokens(payeeName) {
+		features = append(features, "payee:"+token)
+	}
+	for _, word := range classifierTokens(notes) {
+		features = append(features, "notes:"+word)
+	}
+
+	return append(features, "amount:"+amountBucket(amountInCents))
+}
+
+// classifierTokens splits a text into lowercase words, ignoring numbers and single characters
+func classifierTokens(text string) []string {
+	var tokens []string
+	for _, field := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len([]rune(field)) > 1 && strings.ContainsFunc(field, unicode.IsLetter) {
+			tokens = append(tokens, field)
+		}
+	}
+
+	return tokens
+}
+
+// amountBucket groups amounts by sign and order of magnitude: the sign followed by the number of digits of the
+// integer part (e.g. -12.50 = "-2", 150.00 = "+3", -0.99 = "-0", 0.99 = "+0")
+func amountBucket(amountInCents int64) string {
+	units := amountInCents / 100
+	if units < 0 {
+		units = -units
+	}
+
+	digits := 0
+	for ; units > 0; units /= 10 {
+		digits++
+	}
+
+	if amountInCents < 0 {
+		return fmt.Sprintf("-%d", digits)
+	}
+	return fmt.Sprintf("+%d", digits)
+}
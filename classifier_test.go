@@ -0,0 +1,95 @@
+package ezex
+
+import (
+	"database/sql"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func newTestClassifier() *CategoryClassifier {
+	c := &CategoryClassifier{
+		categoryDocuments: make(map[int]int),
+		featureCounts:     make(map[int]map[string]int),
+		featureTotals:     make(map[int]int),
+		vocabulary:        make(map[string]struct{}),
+	}
+	c.train(1, transactionFeatures("Shell Station", -5000, "fuel"))
+	c.train(1, transactionFeatures("Shell", -4500, ""))
+	c.train(2, transactionFeatures("Grocer", -2500, "weekly groceries"))
+	c.train(2, transactionFeatures("Grocer", -3000, ""))
+	c.train(3, transactionFeatures("ACME Inc", 250000, "salary"))
+
+	return c
+}
+
+func TestCategoryClassifier_Suggest(t *testing.T) {
+	c := newTestClassifier()
+
+	suggestion, ok := c.Suggest("SHELL 1234", Transaction{AmountInCents: -4000})
+	assert.True(t, ok)
+	assert.Equal(t, 1, suggestion.CategoryID)
+	assert.Greater(t, suggestion.Confidence, 0.5)
+	assert.LessOrEqual(t, suggestion.Confidence, 1.0)
+
+	// Notes and amounts are features too
+	suggestion, _ = c.Suggest("New shop", Transaction{AmountInCents: -2000, Notes: sql.NullString{String: "groceries"}})
+	assert.Equal(t, 2, suggestion.CategoryID)
+	suggestion, _ = c.Suggest("", Transaction{AmountInCents: 300000})
+	assert.Equal(t, 3, suggestion.CategoryID)
+
+	_, ok = (&CategoryClassifier{}).Suggest("Shell", Transaction{})
+	assert.False(t, ok)
+	_, ok = (*CategoryClassifier)(nil).Suggest("Shell", Transaction{})
+	assert.False(t, ok)
+}
+
+func TestCategoryClassifier_Learn(t *testing.T) {
+	c := newTestClassifier()
+	documents := c.documents
+
+	c.Learn(TransactionView{CategoryID: 4, PayeeName: "Vet Clinic", AmountInCents: -8000, Notes: sql.NullString{String: "checkup"}})
+	suggestion, ok := c.Suggest("Vet Clinic", Transaction{AmountInCents: -7000})
+	assert.True(t, ok)
+	assert.Equal(t, 4, suggestion.CategoryID)
+	assert.Equal(t, documents+1, c.documents)
+
+	// Uncategorized, deleted and split transactions are ignored
+	c.Learn(TransactionView{PayeeName: "Vet Clinic"})
+	c.Learn(TransactionView{CategoryID: 4, PayeeName: "Vet Clinic", DeleteDateUnix: sql.NullInt64{Int64: 1, Valid: true}})
+	c.Learn(TransactionView{CategoryID: 4, PayeeName: "Vet Clinic", SplitCount: 2})
+	assert.Equal(t, documents+1, c.documents)
+
+	(*CategoryClassifier)(nil).Learn(TransactionView{CategoryID: 4})
+}
+
+func TestSuggestCategory(t *testing.T) {
+	payeeID, _ := AddPayee(testDB, Payee{Name: "TestSuggestCategory Bakery"})
+	accountID, _ := AddAccount(testDB, Account{Name: "TestSuggestCategory"})
+	categoryID, _ := AddCategory(testDB, Category{Name: "TestSuggestCategory"})
+	for i := 0; i < 3; i++ {
+		_, _ = AddTransaction(testDB, Transaction{
+			PayeeID:       payeeID,
+			AccountID:     accountID,
+			CategoryID:    categoryID,
+			AmountInCents: -450,
+			Notes:         sql.NullString{String: "TestSuggestCategory croissants", Valid: true},
+		})
+	}
+
+	suggestion, ok := SuggestCategory(testDB, Transaction{PayeeID: payeeID, AccountID: accountID, AmountInCents: -500})
+	assert.True(t, ok)
+	assert.Equal(t, categoryID, suggestion.CategoryID)
+}
+
+func TestAmountBucket(t *testing.T) {
+	assert.Equal(t, "-2", amountBucket(-1250))
+	assert.Equal(t, "+3", amountBucket(15000))
+	assert.Equal(t, "+0", amountBucket(99))
+	assert.Equal(t, "-0", amountBucket(-99))
+	assert.Equal(t, "+0", amountBucket(0))
+}
+
+func TestClassifierTokens(t *testing.T) {
+	assert.Equal(t, []string{"amzn", "mktp", "b2b"}, classifierTokens("AMZN*Mktp 1234 B2B x"))
+	assert.Empty(t, classifierTokens(""))
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	ezex "github.com/armanimichael/ez-ex"
@@ -9,15 +10,18 @@ import (
 	"github.com/charmbracelet/bubbles/table"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 	"strconv"
 	"time"
 )
 
 type accountModel struct {
+	ctx            context.Context
 	db             *sql.DB
 	stage          int
 	accounts       []ezex.Account
 	accountCreator accountCreatorModel
+	size           tea.WindowSizeMsg
 	err            struct {
 		id  int64
 		msg string
@@ -26,22 +30,52 @@ type accountModel struct {
 		model      table.Model
 		selectedID int
 	}
+	// preview lists the transactions of the selected account this month, beside the accounts in wide terminals
+	preview struct {
+		model     table.Model
+		accountID int
+	}
 }
 
+// wideLayoutWidth is the least terminal width showing the preview of the selected account
+const wideLayoutWidth = 160
+
 const (
 	accountSelectionStage = iota
 	accountCreationStage
 )
 
+var accountTableColumns = []tableColumn{
+	fixedColumn("ID", 5),
+	flexColumn("Account name", 20),
+	fixedColumn("Balance", 10),
+	flexColumn("Description", 50),
+}
+
+var accountPreviewTableColumns = []tableColumn{
+	fixedColumn("ID", 5),
+	fixedColumn("Date", 10),
+	fixedColumn("St.", 3),
+	fixedColumn("Amount", 10),
+	flexColumn("Payee", 20),
+	flexColumn("Category", 20),
+}
+
 var accountTableKeySuggestions = formatKeySuggestions([][]string{
 	{"^C", "quit"},
+	{"{esc}", "dashboard"},
 	{"{enter}", "select account"},
 	{"d", "delete account"},
 	{"n", "create account"},
+	{"p", "switch profile"},
+	{"u", "undo"},
+	{"^R", "redo"},
 })
 
-func initAccountModel(db *sql.DB) (m accountModel) {
+func initAccountModel(ctx context.Context, db *sql.DB) (m accountModel) {
+	m.ctx = ctx
 	m.db = db
+	m.preview.model = createStandardTable(accountPreviewTableColumns, nil)
 	m = m.createAccountsTable(ezex.GetAccounts(db))
 
 	if len(m.accounts) > 0 {
@@ -64,6 +98,22 @@ func (m accountModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 
 	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.size = msg
+		m = m.resize()
+
+		return m, m.loadPreview()
+	case command.AccountTransactionsMsg:
+		// A late reply for a previously selected account
+		if msg.AccountID != m.table.selectedID {
+			return m, nil
+		}
+
+		m.preview.accountID = msg.AccountID
+		m.preview.model.SetRows(accountTransactionsToTableRows(msg.Transactions...))
+		m.preview.model.SetCursor(0)
+
+		return m, nil
 	case command.HideErrorMessageMsg:
 		if msg.Message == m.err.msg && msg.ID == m.err.id {
 			m.err.msg = ""
@@ -71,7 +121,7 @@ func (m accountModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case command.DeleteAccountMsg:
 		if msg.Err != nil {
 			logger.Err(fmt.Sprintf("Error deleting account: %v", msg.Err))
-			m.err.msg = msg.Err.Error()
+			m.err.msg = formatError(msg.Err)
 			m.err.id = time.Now().UnixMicro()
 
 			return m, command.HideErrorMessageCmd(m.err.id, m.err.msg)
@@ -100,6 +150,11 @@ func (m accountModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.table.model.SetRows(accountsToTableRows(m.accounts...))
 		}
 		m.table.model.GotoTop()
+
+		return m, tea.Batch(
+			command.OperationDoneCmd(command.UndoableOperation{Kind: command.DeleteAccountOperation, ID: msg.DeletedID}),
+			m.loadPreview(),
+		)
 	case command.CreateNewAccountMsg:
 		if msg.Err != nil {
 			logger.Fatal(fmt.Sprintf("Error creating account: %v", msg.Err))
@@ -113,6 +168,27 @@ func (m accountModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.table.selectedID = msg.NewAccount.ID
 		m.stage = accountSelectionStage
 		m.table.model.GotoTop()
+
+		return m, tea.Batch(
+			command.OperationDoneCmd(command.UndoableOperation{Kind: command.CreateAccountOperation, ID: msg.NewAccount.ID}),
+			m.loadPreview(),
+		)
+	case command.UndoMsg:
+		if msg.Err != nil {
+			return m, nil
+		}
+
+		// Accounts may have been created, deleted or had their balance changed
+		m = m.createAccountsTable(ezex.GetAccounts(m.db))
+		m.accountCreator.reset(m.accounts)
+		if len(m.accounts) > 0 {
+			m.table.selectedID = m.accounts[0].ID
+			m.stage = accountSelectionStage
+		} else {
+			m.stage = accountCreationStage
+		}
+
+		return m, m.loadPreview()
 	}
 
 	if m.stage == accountCreationStage {
@@ -131,27 +207,73 @@ func (m accountModel) View() string {
 			msg = errorMessageStyle.Render("Error: "+m.err.msg) + "\n"
 		}
 
+		if m.isWide() {
+			return m.wideView() + "\n" + accountTableKeySuggestions + "\n" + msg
+		}
+
 		return baseStyle.Render(m.table.model.View()) + "\n" + accountTableKeySuggestions + "\n" + msg
 	}
 
 	return m.accountCreator.View()
 }
 
-func (m accountModel) createAccountsTable(accounts []ezex.Account) accountModel {
-	m.accounts = accounts
-	m.table.model = createStandardTable(
-		[]table.Column{
-			{Title: "ID", Width: 5},
-			{Title: "Account name", Width: 20},
-			{Title: "Balance", Width: 10},
-			{Title: "Description", Width: 50},
-		},
-		accountsToTableRows(accounts...),
+// wideView shows the accounts and the transactions of the selected account side by side
+func (m accountModel) wideView() string {
+	title := "Transactions this month"
+	if account, ok := findAccountByID(m.accounts, m.preview.accountID); ok {
+		title = fmt.Sprintf("Transactions of %s in %s", account.Name, time.Now().Format("January 2006"))
+	}
+	previewWidth := m.size.Width - m.size.Width/2
+	title = lipgloss.NewStyle().MaxWidth(previewWidth).Render(title)
+
+	return lipgloss.JoinHorizontal(
+		lipgloss.Top,
+		"Accounts\n"+baseStyle.Render(m.table.model.View()),
+		title+"\n"+baseStyle.Render(m.preview.model.View()),
 	)
+}
+
+func (m accountModel) isWide() bool {
+	return m.size.Width >= wideLayoutWidth
+}
+
+// resize fits the accounts table in the terminal, sharing it with the preview in wide terminals
+func (m accountModel) resize() accountModel {
+	// Both tables are as tall, the rest of the screen is measured once for both
+	m.preview.model.SetHeight(m.table.model.Height())
+	selection := m
+	selection.stage = accountSelectionStage
+	view := selection.View()
+
+	if !m.isWide() {
+		m.table.model = fitTable(m.table.model, accountTableColumns, view, m.size)
+		return m
+	}
+
+	accountsSize := tea.WindowSizeMsg{Width: m.size.Width / 2, Height: m.size.Height}
+	previewSize := tea.WindowSizeMsg{Width: m.size.Width - accountsSize.Width, Height: m.size.Height}
+	m.table.model = fitTable(m.table.model, accountTableColumns, view, accountsSize)
+	m.preview.model = fitTable(m.preview.model, accountPreviewTableColumns, view, previewSize)
 
 	return m
 }
 
+// loadPreview loads the transactions of the selected account when they're shown (see isWide)
+func (m accountModel) loadPreview() tea.Cmd {
+	if !m.isWide() || m.table.selectedID == 0 {
+		return nil
+	}
+
+	return command.AccountTransactionsCmd(m.ctx, m.db, m.table.selectedID)
+}
+
+func (m accountModel) createAccountsTable(accounts []ezex.Account) accountModel {
+	m.accounts = accounts
+	m.table.model = createStandardTable(accountTableColumns, accountsToTableRows(accounts...))
+
+	return m.resize()
+}
+
 func (m accountModel) handleAccountSelectionCommands(msg tea.Msg) (accountModel, tea.Cmd) {
 	var cmd tea.Cmd
 	m.table.model, cmd = m.table.model.Update(msg)
@@ -159,6 +281,9 @@ func (m accountModel) handleAccountSelectionCommands(msg tea.Msg) (accountModel,
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
+		case "esc":
+			logger.Debug("Go back to dashboard")
+			return m, command.SwitchModelCmd(dashboardModelID, 0)
 		case "enter":
 			logger.Debug(fmt.Sprintf("Select account ID %v", m.table.selectedID))
 			return m, command.SwitchModelCmd(transactionModelID, m.table.selectedID)
@@ -174,11 +299,18 @@ func (m accountModel) handleAccountSelectionCommands(msg tea.Msg) (accountModel,
 		case "n":
 			m.stage = accountCreationStage
 			return m, textinput.Blink
+		case "p":
+			logger.Debug("Switch profile")
+			return m, command.SwitchModelCmd(profileModelID, 0)
+		case "u", "ctrl+r":
+			return m, tea.Batch(command.RequestUndoCmd(msg.String() == "ctrl+r"), cmd)
 		case "down", "up":
 			r := m.table.model.SelectedRow()
 			selectedID, _ := strconv.ParseInt(r[0], 10, 32)
 			m.table.selectedID = int(selectedID)
 			m.err.msg = ""
+
+			return m, tea.Batch(cmd, m.loadPreview())
 		}
 
 	}
@@ -120,8 +120,8 @@ func (m accountCreatorModel) validateInput(stage int) string {
 			return fmt.Sprintf("there's already an account named: %v", value)
 		}
 	case accountNewInitialBalanceStage:
-		if !moneyFormatRegex.MatchString(value) {
-			return "invalid balance format, should look like `0.00` or `-0.00`"
+		if err := validateAmount(value); err != nil {
+			return err.Error()
 		}
 	}
 
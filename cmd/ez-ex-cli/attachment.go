@@ -0,0 +1,120 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	ezex "github.com/armanimichael/ez-ex"
+	"io"
+	"os"
+	"strconv"
+	"time"
+)
+
+const attachmentUsage = `usage:
+  attachment add <transaction ID> <file>...
+  attachment list <transaction ID>
+  attachment remove <attachment ID>
+  attachment export [-output file] <attachment ID>
+  attachment check`
+
+// runAttachment handles `ez-ex attachment`, managing the files attached to transactions
+func runAttachment(db *sql.DB, dir string, args []string) error {
+	if len(args) == 0 {
+		return errors.New(attachmentUsage)
+	}
+
+	switch args[0] {
+	case "add":
+		if len(args) < 3 {
+			return errors.New(attachmentUsage)
+		}
+
+		transactionID, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid transaction ID: %s", args[1])
+		}
+		for _, file := range args[2:] {
+			attachment, err := ezex.AttachFile(db, dir, transactionID, file)
+			if err != nil {
+				return fmt.Errorf("%s: %w", file, err)
+			}
+			fmt.Printf("%d\t%s\n", attachment.ID, attachment.FileName)
+		}
+	case "list":
+		if len(args) != 2 {
+			return errors.New(attachmentUsage)
+		}
+
+		transactionID, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid transaction ID: %s", args[1])
+		}
+		for _, attachment := range ezex.GetAttachments(db, transactionID) {
+			fmt.Printf(
+				"%d\t%s\t%d bytes\t%s\n",
+				attachment.ID,
+				attachment.FileName,
+				attachment.SizeInBytes,
+				time.Unix(attachment.CreateDateUnix, 0).Format(time.DateTime),
+			)
+		}
+	case "remove":
+		if len(args) != 2 {
+			return errors.New(attachmentUsage)
+		}
+
+		id, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid attachment ID: %s", args[1])
+		}
+		return ezex.DetachFile(db, dir, id)
+	case "export":
+		flags := flag.NewFlagSet("attachment export", flag.ExitOnError)
+		output := flags.String("output", "", "Output file (defaults to stdout)")
+		_ = flags.Parse(args[1:])
+		if flags.NArg() != 1 {
+			return errors.New(attachmentUsage)
+		}
+
+		id, err := strconv.Atoi(flags.Arg(0))
+		if err != nil {
+			return fmt.Errorf("invalid attachment ID: %s", flags.Arg(0))
+		}
+
+		var w io.Writer = os.Stdout
+		if *output != "" {
+			file, err := os.OpenFile(*output, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+			if err != nil {
+				return err
+			}
+			defer func(file *os.File) {
+				_ = file.Close()
+			}(file)
+
+			w = file
+		}
+		return ezex.ExportAttachment(db, dir, id, w)
+	case "check":
+		broken, err := ezex.CheckAttachments(db, dir)
+		if err != nil {
+			return err
+		}
+
+		for _, b := range broken {
+			problem := "content changed"
+			if b.Missing {
+				problem = "missing file"
+			}
+			fmt.Printf("%d\t%s\t%s\n", b.Attachment.ID, b.Attachment.FileName, problem)
+		}
+		if len(broken) > 0 {
+			return fmt.Errorf("%d broken attachments", len(broken))
+		}
+	default:
+		return errors.New(attachmentUsage)
+	}
+
+	return nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	ezex "github.com/armanimichael/ez-ex"
+	"github.com/armanimichael/ez-ex/archive"
+	"os"
+	"time"
+)
+
+const backupUsage = `usage:
+  backup [-encrypt] [-keep-daily N] [-keep-weekly N] [-keep-monthly N]
+  backup list`
+
+const restoreUsage = `usage:
+  restore [-no-backup] <file>`
+
+// runBackup handles `ez-ex backup`, backing up the DB into dir then removing the backups not kept by the
+// retention policy (defaults to the configured one). Backups are encrypted with a passphrase (see readPassphrase) when -encrypt is set
+func runBackup(db *sql.DB, c config, dir string, name string, args []string) error {
+	if len(args) > 0 && args[0] == "list" {
+		backups, err := ezex.GetBackups(dir, name)
+		if err != nil {
+			return err
+		}
+		for _, backup := range backups {
+			fmt.Printf("%s\t%s\n", backup.Time.Format(time.DateTime), backup.Path)
+		}
+
+		return nil
+	}
+
+	flags := flag.NewFlagSet("backup", flag.ExitOnError)
+	encrypt := flags.Bool("encrypt", false, "Encrypt the backup with a passphrase (read from "+passphraseEnv+" or prompted)")
+	daily := flags.Int("keep-daily", c.Backup.KeepDaily, "Daily backups to keep")
+	weekly := flags.Int("keep-weekly", c.Backup.KeepWeekly, "Weekly backups to keep")
+	monthly := flags.Int("keep-monthly", c.Backup.KeepMonthly, "Monthly backups to keep")
+	_ = flags.Parse(args)
+	if flags.NArg() != 0 {
+		return errors.New(backupUsage)
+	}
+
+	var passphrase string
+	if *encrypt {
+		var err error
+		if passphrase, err = readPassphrase(true); err != nil {
+			return err
+		}
+	}
+
+	backupPath, err := createBackup(db, dir, name, passphrase)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Created %s\n", backupPath)
+
+	removed, err := ezex.PruneBackups(dir, name, ezex.RetentionPolicy{Daily: *daily, Weekly: *weekly, Monthly: *monthly})
+	for _, backup := range removed {
+		fmt.Printf("Removed %s\n", backup.Path)
+	}
+
+	return err
+}
+
+// runRestore handles `ez-ex restore`, replacing the DB with a backup once validated, encrypted backups are
+// decrypted first. The current DB is backed up first (encrypted like the restored backup), unless -no-backup is set
+func runRestore(db *sql.DB, dir string, name string, args []string) error {
+	flags := flag.NewFlagSet("restore", flag.ExitOnError)
+	noBackup := flags.Bool("no-backup", false, "Don't back up the current DB before restoring")
+	_ = flags.Parse(args)
+	if flags.NArg() != 1 {
+		return errors.New(restoreUsage)
+	}
+
+	src := flags.Arg(0)
+	encrypted, err := archive.IsEncryptedFile(src)
+	if err != nil {
+		return err
+	}
+
+	var passphrase string
+	if encrypted {
+		if passphrase, err = readPassphrase(false); err != nil {
+			return err
+		}
+
+		decrypted, err := tempFile("ez-ex-restore-*.db")
+		if err != nil {
+			return err
+		}
+		defer func(name string) {
+			_ = os.Remove(name)
+		}(decrypted)
+
+		if err = archive.DecryptFile(src, decrypted, passphrase); err != nil {
+			return err
+		}
+		src = decrypted
+	}
+
+	if err := ezex.ValidateBackup(src); err != nil {
+		return err
+	}
+	if !*noBackup {
+		backupPath, err := createBackup(db, dir, name, passphrase)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Current DB backed up to %s\n", backupPath)
+	}
+
+	if err := ezex.Restore(db, src); err != nil {
+		return err
+	}
+	if err := ezex.MigrateDB(db); err != nil {
+		return err
+	}
+	fmt.Printf("Restored %s\n", flags.Arg(0))
+
+	return nil
+}
+
+// createBackup backs up the DB into dir (see ezex.CreateBackup), encrypting it when passphrase isn't empty.
+// Encrypted backups are created from a temporary plain copy, so no plain copy is written in dir
+func createBackup(db *sql.DB, dir string, name string, passphrase string) (string, error) {
+	if passphrase == "" {
+		return ezex.CreateBackup(db, dir, name)
+	}
+
+	plain, err := tempFile("ez-ex-backup-*.db")
+	if err != nil {
+		return "", err
+	}
+	defer func(name string) {
+		_ = os.Remove(name)
+	}(plain)
+
+	if err = ezex.Backup(db, plain); err != nil {
+		return "", err
+	}
+	if err = os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+
+	dest := ezex.BackupPath(dir, name) + ".enc"
+	return dest, archive.EncryptFile(plain, dest, passphrase)
+}
+
+// tempFile returns the name of a new empty temporary file
+func tempFile(pattern string) (string, error) {
+	file, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return "", err
+	}
+
+	return file.Name(), file.Close()
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	ezex "github.com/armanimichael/ez-ex"
+	"github.com/armanimichael/ez-ex/cmd/ez-ex-cli/command"
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"strings"
+	"time"
+)
+
+// categoryModel shows the category tree, creating (by path), moving and deleting categories
+type categoryModel struct {
+	db         *sql.DB
+	stage      int
+	categories []ezex.Category
+	input      standardTextInput
+	suggestion struct {
+		autocompleteSuggestion string
+		category               ezex.Category
+	}
+	err struct {
+		id  int64
+		msg string
+	}
+	size  tea.WindowSizeMsg
+	table struct {
+		model table.Model
+	}
+}
+
+const (
+	categorySelectionStage = iota
+	categoryCreationStage
+	categoryMoveStage
+)
+
+var categoryTableColumns = []tableColumn{
+	fixedColumn("ID", 5),
+	flexColumn("Category", 40),
+	flexColumn("Description", 40),
+}
+
+var categoryTableKeySuggestions = formatKeySuggestions([][]string{
+	{"^C", "quit"},
+	{"{esc}", "dashboard"},
+	{"n", "create category"},
+	{"m", "move category"},
+	{"d", "delete category"},
+	{"u", "undo"},
+	{"^R", "redo"},
+})
+
+var categoryInputKeySuggestions = formatKeySuggestions([][]string{
+	{"{enter}", "confirm"},
+	{"{tab}", "autocomplete"},
+	{"{esc}", "cancel"},
+})
+
+func initCategoryModel(db *sql.DB) (m categoryModel) {
+	m.db = db
+	m.categories = ezex.GetCategoryTree(db)
+	m.table.model = createStandardTable(categoryTableColumns, categoriesToTableRows(m.categories...))
+
+	return m
+}
+
+func (m categoryModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m categoryModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.size = msg
+		// The table is measured along with the rest of the selection screen, even while typing
+		selection := m
+		selection.stage = categorySelectionStage
+		m.table.model = fitTable(m.table.model, categoryTableColumns, selection.View(), m.size)
+
+		return m, nil
+	case command.HideErrorMessageMsg:
+		if msg.Message == m.err.msg && msg.ID == m.err.id {
+			m.err.msg = ""
+		}
+	case command.UpdateCategoriesMsg:
+		moved := m.stage == categoryMoveStage
+		m.stage = categorySelectionStage
+		if msg.Err != nil {
+			logger.Err(fmt.Sprintf("Error updating categories: %v", msg.Err))
+			m.err.msg = formatError(msg.Err)
+			m.err.id = time.Now().UnixMicro()
+
+			return m, command.HideErrorMessageCmd(m.err.id, m.err.msg)
+		}
+
+		operation := command.UndoableOperation{Kind: command.MoveCategoryOperation, ID: msg.SelectedID}
+		if category, ok := findCategoryByID(m.categories, msg.SelectedID); ok {
+			operation.From = category.ParentID
+		}
+		m = m.setCategories(msg.Categories, msg.SelectedID)
+		if category, ok := findCategoryByID(m.categories, msg.SelectedID); ok {
+			operation.To = category.ParentID
+		}
+
+		if moved && operation.From != operation.To {
+			return m, command.OperationDoneCmd(operation)
+		}
+		return m, nil
+	case command.UndoMsg:
+		if msg.Err != nil {
+			return m, nil
+		}
+
+		selectedID := 0
+		if len(m.categories) > 0 {
+			selectedID = m.selected().ID
+		}
+		m = m.setCategories(ezex.GetCategoryTree(m.db), selectedID)
+
+		return m, nil
+	}
+
+	if m.stage == categorySelectionStage {
+		return m.handleCategorySelectionCommands(msg)
+	}
+
+	return m.handleCategoryInputCommands(msg)
+}
+
+func (m categoryModel) View() string {
+	if m.stage != categorySelectionStage {
+		str := strings.Builder{}
+		if m.stage == categoryMoveStage {
+			str.WriteString(fmt.Sprintf("Move %s\n\n", m.selected().Path))
+		}
+		str.WriteString(standardTextInputView(0, []standardTextInput{m.input}, m.suggestion.autocompleteSuggestion))
+		str.WriteString("\n\n" + categoryInputKeySuggestions)
+
+		return str.String()
+	}
+
+	msg := ""
+	if m.err.msg != "" {
+		msg = errorMessageStyle.Render("Error: "+m.err.msg) + "\n"
+	}
+
+	return baseStyle.Render(m.table.model.View()) + "\n" + categoryTableKeySuggestions + "\n" + msg
+}
+
+// setCategories shows the categories, selecting the one with selectedID (the first one if missing)
+func (m categoryModel) setCategories(categories []ezex.Category, selectedID int) categoryModel {
+	m.categories = categories
+	m.table.model.SetRows(categoriesToTableRows(m.categories...))
+	m.table.model.SetCursor(0)
+	for i, category := range m.categories {
+		if category.ID == selectedID {
+			m.table.model.SetCursor(i)
+			break
+		}
+	}
+
+	return m
+}
+
+// selected returns the category under the cursor
+func (m categoryModel) selected() ezex.Category {
+	return m.categories[m.table.model.Cursor()]
+}
+
+func (m categoryModel) handleCategorySelectionCommands(msg tea.Msg) (categoryModel, tea.Cmd) {
+	var cmd tea.Cmd
+	m.table.model, cmd = m.table.model.Update(msg)
+
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "esc":
+			logger.Debug("Go back to dashboard")
+			return m, command.SwitchModelCmd(dashboardModelID, 0)
+		case "n":
+			m.stage = categoryCreationStage
+			m.input = createCategoryInput(m.stage)
+			return m, textinput.Blink
+		case "m", "d":
+			if m.selected().ID == 0 {
+				m.err.msg = ezex.ErrProtectedCategory.Error()
+				m.err.id = time.Now().UnixMicro()
+				return m, command.HideErrorMessageCmd(m.err.id, m.err.msg)
+			}
+
+			if msg.String() == "d" {
+				logger.Debug(fmt.Sprintf("Delete category (ID: %v)", m.selected().ID))
+				return m, command.DeleteCategoryCmd(m.db, m.selected().ID)
+			}
+
+			m.stage = categoryMoveStage
+			m.input = createCategoryInput(m.stage)
+			return m, textinput.Blink
+		case "u", "ctrl+r":
+			return m, tea.Batch(command.RequestUndoCmd(msg.String() == "ctrl+r"), cmd)
+		case "up", "down":
+			m.err.msg = ""
+		}
+	}
+
+	return m, cmd
+}
+
+func (m categoryModel) handleCategoryInputCommands(msg tea.Msg) (categoryModel, tea.Cmd) {
+	var cmd tea.Cmd
+
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "esc":
+			m.stage = categorySelectionStage
+			return m, nil
+		case "enter":
+			if m.input.errorMsg != "" {
+				break
+			}
+
+			path := strings.TrimSpace(m.input.model.Value())
+			if m.stage == categoryCreationStage {
+				return m, command.CreateCategoryCmd(m.db, path)
+			}
+
+			return m, command.MoveCategoryCmd(m.db, m.selected().ID, path)
+		case "tab":
+			if m.suggestion.autocompleteSuggestion != "" {
+				m.input.model.SetValue(m.suggestion.category.Path)
+				m.input.model.SetCursor(len(m.suggestion.category.Path))
+				m.suggestion.autocompleteSuggestion = ""
+			}
+			return m, nil
+		}
+	}
+
+	m.input.model, cmd = m.input.model.Update(msg)
+	m.input.errorMsg = m.validateInput()
+
+	m.suggestion.autocompleteSuggestion = ""
+	if val := m.input.model.Value(); val != "" {
+		if match, ok := autocomplete(categoryPaths(m.categories), val); ok {
+			m.suggestion.autocompleteSuggestion = match.Path[len(val):]
+			m.suggestion.category = match.Category
+		}
+	}
+
+	return m, cmd
+}
+
+func (m categoryModel) validateInput() string {
+	value := strings.TrimSpace(m.input.model.Value())
+	if value == "" {
+		if m.stage == categoryCreationStage {
+			return "category path is required"
+		}
+
+		// Moving to the top-level
+		return ""
+	}
+
+	if err := validateCategoryPath(value); err != nil {
+		return err.Error()
+	}
+
+	if _, exists := findCategory(m.categories, value); exists && m.stage == categoryCreationStage {
+		return fmt.Sprintf("there's already a category named: %v", value)
+	}
+
+	return ""
+}
+
+func createCategoryInput(stage int) standardTextInput {
+	ti := textinput.New()
+	ti.Prompt = ""
+	ti.Focus()
+
+	switch stage {
+	case categoryCreationStage:
+		ti.Placeholder = "Parent:Child"
+
+		return standardTextInput{
+			model:    ti,
+			errorMsg: "category path is required",
+			label:    "Category path*",
+		}
+	case categoryMoveStage:
+		ti.Placeholder = "<TOP-LEVEL>"
+
+		return standardTextInput{
+			model: ti,
+			label: "New parent",
+		}
+	}
+
+	panic("unsupported category input stage")
+}
@@ -1,9 +1,11 @@
 package command
 
 import (
+	"context"
 	"database/sql"
 	ezex "github.com/armanimichael/ez-ex"
 	tea "github.com/charmbracelet/bubbletea"
+	"time"
 )
 
 type CreateNewAccountMsg = struct {
@@ -11,6 +13,12 @@ type CreateNewAccountMsg = struct {
 	Err        error
 }
 
+// AccountTransactionsMsg carries the transactions of an account in the current month
+type AccountTransactionsMsg = struct {
+	AccountID    int
+	Transactions []ezex.TransactionView
+}
+
 type DeleteAccountMsg = struct {
 	DeletedID    int
 	DeletedIndex int
@@ -19,7 +27,7 @@ type DeleteAccountMsg = struct {
 
 func CreateNewAccountCmd(db *sql.DB, account ezex.Account) tea.Cmd {
 	return func() tea.Msg {
-		id, err := ezex.AddAccount(db, account)
+		id, err := ezex.AddAccountContext(tuiAudit, db, account)
 		account.ID = id
 
 		return CreateNewAccountMsg{
@@ -30,7 +38,7 @@ func CreateNewAccountCmd(db *sql.DB, account ezex.Account) tea.Cmd {
 }
 func DeleteAccountCmd(db *sql.DB, id int, index int) tea.Cmd {
 	return func() tea.Msg {
-		if _, err := ezex.DeleteAccount(db, id); err != nil {
+		if _, err := ezex.DeleteAccountContext(tuiAudit, db, id); err != nil {
 			return DeleteTransactionMsg{
 				Err: err,
 			}
@@ -43,3 +51,24 @@ func DeleteAccountCmd(db *sql.DB, id int, index int) tea.Cmd {
 		}
 	}
 }
+
+// AccountTransactionsCmd loads the transactions of an account in the current month, no message is sent when ctx
+// is canceled
+func AccountTransactionsCmd(ctx context.Context, db *sql.DB, accountID int) tea.Cmd {
+	return func() tea.Msg {
+		now := time.Now()
+		transactions := ezex.FilterTransactionsContext(ctx, db, ezex.TransactionFilter{
+			AccountIDs: []int{accountID},
+			MinDate:    time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local),
+			MaxDate:    time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.Local),
+		})
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		return AccountTransactionsMsg{
+			AccountID:    accountID,
+			Transactions: transactions,
+		}
+	}
+}
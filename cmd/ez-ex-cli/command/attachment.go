@@ -0,0 +1,45 @@
+package command
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	ezex "github.com/armanimichael/ez-ex"
+	tea "github.com/charmbracelet/bubbletea"
+	"os"
+	"os/exec"
+)
+
+type OpenAttachmentsMsg = struct {
+	Err error
+}
+
+// OpenAttachmentsCmd opens every attachment of a transaction with the default application (`xdg-open`), nothing is
+// opened when ctx is canceled
+func OpenAttachmentsCmd(ctx context.Context, db *sql.DB, dir string, transactionID int) tea.Cmd {
+	return func() tea.Msg {
+		attachments := ezex.GetAttachmentsContext(ctx, db, transactionID)
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		for _, attachment := range attachments {
+			filePath := attachment.Path(dir)
+			if _, err := os.Stat(filePath); errors.Is(err, os.ErrNotExist) {
+				return OpenAttachmentsMsg{Err: fmt.Errorf("attachment file of %s is missing", attachment.FileName)}
+			}
+
+			open := exec.Command("xdg-open", filePath)
+			if err := open.Start(); err != nil {
+				return OpenAttachmentsMsg{Err: err}
+			}
+			// The application may outlive the command, only reap it
+			go func() {
+				_ = open.Wait()
+			}()
+		}
+
+		return OpenAttachmentsMsg{}
+	}
+}
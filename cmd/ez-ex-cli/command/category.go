@@ -0,0 +1,64 @@
+package command
+
+import (
+	"database/sql"
+	ezex "github.com/armanimichael/ez-ex"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// UpdateCategoriesMsg notifies that the category tree changed, SelectedID is the category to select
+type UpdateCategoriesMsg = struct {
+	Categories []ezex.Category
+	SelectedID int
+	Err        error
+}
+
+// CreateCategoryCmd creates the category at path (e.g. `Car:Fuel`), along with its missing parents
+func CreateCategoryCmd(db *sql.DB, path string) tea.Cmd {
+	return func() tea.Msg {
+		id, err := ezex.AddCategoryPathContext(tuiAudit, db, path)
+		if err != nil {
+			return UpdateCategoriesMsg{Err: err}
+		}
+
+		return UpdateCategoriesMsg{
+			Categories: ezex.GetCategoryTree(db),
+			SelectedID: id,
+		}
+	}
+}
+
+// MoveCategoryCmd moves a category under the category at parentPath (created if missing), empty makes it top-level
+func MoveCategoryCmd(db *sql.DB, id int, parentPath string) tea.Cmd {
+	return func() tea.Msg {
+		parentID := 0
+		if parentPath != "" {
+			var err error
+			if parentID, err = ezex.AddCategoryPathContext(tuiAudit, db, parentPath); err != nil {
+				return UpdateCategoriesMsg{Err: err}
+			}
+		}
+
+		if err := ezex.MoveCategoryContext(tuiAudit, db, id, parentID); err != nil {
+			return UpdateCategoriesMsg{Err: err}
+		}
+
+		return UpdateCategoriesMsg{
+			Categories: ezex.GetCategoryTree(db),
+			SelectedID: id,
+		}
+	}
+}
+
+// DeleteCategoryCmd deletes a category, its subcategories are moved to its parent
+func DeleteCategoryCmd(db *sql.DB, id int) tea.Cmd {
+	return func() tea.Msg {
+		if _, err := ezex.DeleteCategoryContext(tuiAudit, db, id); err != nil {
+			return UpdateCategoriesMsg{Err: err}
+		}
+
+		return UpdateCategoriesMsg{
+			Categories: ezex.GetCategoryTree(db),
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package command
+
+import (
+	"context"
+	"database/sql"
+	ezex "github.com/armanimichael/ez-ex"
+	tea "github.com/charmbracelet/bubbletea"
+	"time"
+)
+
+// DashboardMsg carries the dashboard data of the current month
+type DashboardMsg = struct {
+	TotalBalance       int64
+	CashFlow           ezex.CashFlow
+	CategoryTotals     []ezex.CategoryTotal
+	Upcoming           []ezex.TransactionView
+	RecentTransactions []ezex.TransactionView
+}
+
+// LoadDashboardCmd loads the totals of the current month, the next upcomingCount and the last recentCount
+// transactions, no message is sent when ctx is canceled
+func LoadDashboardCmd(ctx context.Context, db *sql.DB, upcomingCount int, recentCount int) tea.Cmd {
+	return func() tea.Msg {
+		now := time.Now()
+		monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+		monthEnd := time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.Local)
+
+		msg := DashboardMsg{
+			TotalBalance:       ezex.GetTotalBalanceContext(ctx, db),
+			CashFlow:           ezex.GetCashFlowContext(ctx, db, monthStart, monthEnd),
+			CategoryTotals:     ezex.GetCategoryTotalsContext(ctx, db, monthStart, monthEnd),
+			Upcoming:           ezex.GetUpcomingTransactionsContext(ctx, db, now, upcomingCount),
+			RecentTransactions: ezex.GetRecentTransactionsContext(ctx, db, now, recentCount),
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		return msg
+	}
+}
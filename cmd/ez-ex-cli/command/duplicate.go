@@ -0,0 +1,35 @@
+package command
+
+import (
+	"database/sql"
+	ezex "github.com/armanimichael/ez-ex"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// UpdateDuplicatesMsg notifies that the likely duplicates changed (see ezex.FindDuplicates)
+type UpdateDuplicatesMsg = struct {
+	Pairs []ezex.DuplicatePair
+	Err   error
+}
+
+// MergeDuplicateCmd merges the duplicate into the transaction to keep, removing its amount from the account balance
+func MergeDuplicateCmd(db *sql.DB, keep ezex.TransactionView, duplicate ezex.TransactionView) tea.Cmd {
+	return func() tea.Msg {
+		if err := ezex.MergeDuplicateContext(tuiAudit, db, keep.ID, duplicate.ID); err != nil {
+			return UpdateDuplicatesMsg{Err: err}
+		}
+
+		return UpdateDuplicatesMsg{Pairs: ezex.FindDuplicates(db, ezex.DuplicateMaxDays)}
+	}
+}
+
+// DismissDuplicateCmd marks a pair of transactions as not duplicates
+func DismissDuplicateCmd(db *sql.DB, pair ezex.DuplicatePair) tea.Cmd {
+	return func() tea.Msg {
+		if err := ezex.DismissDuplicateContext(tuiAudit, db, pair.Transaction.ID, pair.Duplicate.ID); err != nil {
+			return UpdateDuplicatesMsg{Err: err}
+		}
+
+		return UpdateDuplicatesMsg{Pairs: ezex.FindDuplicates(db, ezex.DuplicateMaxDays)}
+	}
+}
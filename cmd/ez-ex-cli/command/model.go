@@ -1,10 +1,15 @@
 package command
 
 import (
+	"context"
+	ezex "github.com/armanimichael/ez-ex"
 	tea "github.com/charmbracelet/bubbletea"
 	"time"
 )
 
+// tuiAudit records the changes made by the commands as coming from the TUI (see ezex.GetHistory)
+var tuiAudit = ezex.WithAuditSource(context.Background(), ezex.AuditSourceTUI)
+
 type SwitchModelMsg = struct {
 	ModelID   int
 	AccountID int
@@ -32,3 +37,15 @@ func HideErrorMessageCmd(id int64, message string) tea.Cmd {
 		}
 	})
 }
+
+// SwitchProfileMsg asks to open the DB of another profile, Err is set when it couldn't be opened
+type SwitchProfileMsg = struct {
+	Name string
+	Err  error
+}
+
+func SwitchProfileCmd(name string) tea.Cmd {
+	return func() tea.Msg {
+		return SwitchProfileMsg{Name: name}
+	}
+}
@@ -0,0 +1,69 @@
+package command
+
+import (
+	"context"
+	"database/sql"
+	ezex "github.com/armanimichael/ez-ex"
+	tea "github.com/charmbracelet/bubbletea"
+	"time"
+)
+
+// SetTransactionStatusMsg notifies that a transaction was marked as cleared or uncommitted,
+// Balance is the updated account balance
+type SetTransactionStatusMsg = struct {
+	ID      int
+	Status  ezex.TransactionStatus
+	Balance ezex.AccountBalance
+	Err     error
+}
+
+// ReconcileCandidatesMsg carries the transactions that can be reconciled against a statement
+type ReconcileCandidatesMsg = struct {
+	Transactions []ezex.TransactionView
+}
+
+// ReconcileMsg notifies that the ticked transactions were reconciled, Balance is the updated account balance
+type ReconcileMsg = struct {
+	Balance ezex.AccountBalance
+	Err     error
+}
+
+func SetTransactionStatusCmd(db *sql.DB, accountID int, id int, status ezex.TransactionStatus) tea.Cmd {
+	return func() tea.Msg {
+		if err := ezex.SetTransactionStatusContext(tuiAudit, db, id, status); err != nil {
+			return SetTransactionStatusMsg{Err: err}
+		}
+
+		balance, err := ezex.GetAccountBalance(db, accountID)
+		return SetTransactionStatusMsg{ID: id, Status: status, Balance: balance, Err: err}
+	}
+}
+
+// ReconcileCandidatesCmd loads the transactions of the account not reconciled yet, up to the statement end date (included).
+// No message is sent when ctx is canceled
+func ReconcileCandidatesCmd(ctx context.Context, db *sql.DB, accountID int, endDate time.Time) tea.Cmd {
+	return func() tea.Msg {
+		transactions := ezex.FilterTransactionsContext(ctx, db, ezex.TransactionFilter{
+			AccountIDs: []int{accountID},
+			MaxDate:    endDate.AddDate(0, 0, 1),
+			Statuses:   []ezex.TransactionStatus{ezex.StatusUncommitted, ezex.StatusCleared},
+		})
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		return ReconcileCandidatesMsg{Transactions: transactions}
+	}
+}
+
+// ReconcileCmd locks the transactions as reconciled (see ezex.Reconcile)
+func ReconcileCmd(db *sql.DB, accountID int, ids []int) tea.Cmd {
+	return func() tea.Msg {
+		if err := ezex.ReconcileContext(tuiAudit, db, accountID, ids); err != nil {
+			return ReconcileMsg{Err: err}
+		}
+
+		balance, err := ezex.GetAccountBalance(db, accountID)
+		return ReconcileMsg{Balance: balance, Err: err}
+	}
+}
@@ -0,0 +1,77 @@
+package command
+
+import (
+	"database/sql"
+	ezex "github.com/armanimichael/ez-ex"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// UpdateRulesMsg notifies that the rules changed, SelectedID is the rule to select
+type UpdateRulesMsg = struct {
+	Rules []ezex.Rule
+	// Categories is the updated category tree, including the categories created with the rule
+	Categories []ezex.Category
+	SelectedID int
+	Err        error
+}
+
+// CreateRuleCmd creates a rule setting the category at categoryPath (created if missing), empty sets no category
+func CreateRuleCmd(db *sql.DB, rule ezex.Rule, categoryPath string) tea.Cmd {
+	return func() tea.Msg {
+		// Validate before creating the category, so that invalid rules don't leave new categories behind
+		check := rule
+		if categoryPath != "" {
+			check.CategoryID = -1
+		}
+		if err := check.Validate(); err != nil {
+			return UpdateRulesMsg{Err: err}
+		}
+
+		if categoryPath != "" {
+			var err error
+			if rule.CategoryID, err = ezex.AddCategoryPathContext(tuiAudit, db, categoryPath); err != nil {
+				return UpdateRulesMsg{Err: err}
+			}
+		}
+
+		id, err := ezex.AddRuleContext(tuiAudit, db, rule)
+		if err != nil {
+			return UpdateRulesMsg{Err: err}
+		}
+
+		return UpdateRulesMsg{
+			Rules:      ezex.GetRules(db),
+			Categories: ezex.GetCategoryTree(db),
+			SelectedID: id,
+		}
+	}
+}
+
+// MoveRuleCmd moves a rule by offset positions in the evaluation order (negative = earlier)
+func MoveRuleCmd(db *sql.DB, id int, offset int) tea.Cmd {
+	return func() tea.Msg {
+		if err := ezex.MoveRuleContext(tuiAudit, db, id, offset); err != nil {
+			return UpdateRulesMsg{Err: err}
+		}
+
+		return UpdateRulesMsg{
+			Rules:      ezex.GetRules(db),
+			Categories: ezex.GetCategoryTree(db),
+			SelectedID: id,
+		}
+	}
+}
+
+// DeleteRuleCmd deletes a rule
+func DeleteRuleCmd(db *sql.DB, id int) tea.Cmd {
+	return func() tea.Msg {
+		if _, err := ezex.DeleteRuleContext(tuiAudit, db, id); err != nil {
+			return UpdateRulesMsg{Err: err}
+		}
+
+		return UpdateRulesMsg{
+			Rules:      ezex.GetRules(db),
+			Categories: ezex.GetCategoryTree(db),
+		}
+	}
+}
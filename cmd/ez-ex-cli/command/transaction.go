@@ -1,6 +1,7 @@
 package command
 
 import (
+	"context"
 	"database/sql"
 	ezex "github.com/armanimichael/ez-ex"
 	tea "github.com/charmbracelet/bubbletea"
@@ -11,14 +12,61 @@ type SwitchTransactionsMonthMsg = struct {
 	Month        time.Month
 	Year         int
 	Transactions []ezex.TransactionView
+	// SelectedID is the transaction to select once the month is loaded, 0 selects the first one
+	SelectedID int
+}
+
+// TransactionFilterMsg notifies that a new transactions filter has been applied,
+// Values are the raw filter inputs so that it can be restored later
+type TransactionFilterMsg = struct {
+	Values []string
+}
+
+type SearchTransactionsMsg = struct {
+	Query   string
+	Results []ezex.TransactionView
 }
 
 type CreateNewTransactionMsg = struct {
+	// ID is the created transaction
+	ID            int
 	Transactions  []ezex.TransactionView
 	NewPayee      ezex.Payee
 	NewCategory   ezex.Category
 	AmountInCents int64
-	Err           error
+	// Payees, Categories and Tags are the updated lists, including the ones created with the transaction
+	Payees     []ezex.Payee
+	Categories []ezex.Category
+	Tags       []ezex.Tag
+	// Transaction is the created transaction as saved (e.g. with the rules applied), to update the classifier
+	Transaction ezex.TransactionView
+	Err         error
+}
+
+// NewTransactionSplit is a split of a transaction being created,
+// categories with no ID and a path are created along with their missing parents
+type NewTransactionSplit = struct {
+	Category      ezex.Category
+	AmountInCents int64
+	Notes         sql.NullString
+}
+
+// TransactionHistoryMsg carries the changes of a transaction recorded in the audit log, oldest first
+type TransactionHistoryMsg = struct {
+	TransactionID int
+	History       []ezex.AuditEntry
+}
+
+// EditTransactionMsg notifies that a transaction was edited, AmountInCents is the balance adjustment made
+// (see ezex.UpdateAccountBalance)
+type EditTransactionMsg = struct {
+	Before        ezex.Transaction
+	After         ezex.Transaction
+	AmountInCents int64
+	// Payees and Categories are the updated lists, including the ones created with the edit
+	Payees     []ezex.Payee
+	Categories []ezex.Category
+	Err        error
 }
 
 type DeleteTransactionMsg = struct {
@@ -27,30 +75,59 @@ type DeleteTransactionMsg = struct {
 	Err          error
 }
 
-func CreateNewTransactionCmd(db *sql.DB, transaction ezex.Transaction, payee ezex.Payee, category ezex.Category) tea.Cmd {
+// CreateNewTransactionCmd creates a transaction, along with its payee, category, splits (if any) and tags when new,
+// then applies the rules (see ezex.Rule) and updates the account balance, all or nothing (see ezex.CreateTransaction)
+func CreateNewTransactionCmd(
+	db *sql.DB,
+	transaction ezex.Transaction,
+	payee ezex.Payee,
+	category ezex.Category,
+	splits []NewTransactionSplit,
+	tags []string,
+) tea.Cmd {
 	return func() tea.Msg {
-		if payee.ID == 0 {
-			id, err := ezex.AddPayee(db, payee)
-			if err != nil {
-				return CreateNewTransactionMsg{Err: err}
-			}
-			payee.ID = id
-			transaction.PayeeID = id
+		newTransaction := ezex.NewTransaction{
+			Transaction:  transaction,
+			PayeeName:    payee.Name,
+			CategoryPath: category.Path,
+			Tags:         tags,
 		}
-		if category.ID == 0 && category.Name != "" {
-			id, err := ezex.AddCategory(db, category)
-			if err != nil {
-				return CreateNewTransactionMsg{Err: err}
-			}
-			category.ID = id
-			transaction.CategoryID = id
+		newTransaction.Transaction.PayeeID = payee.ID
+		newTransaction.Transaction.CategoryID = category.ID
+		for _, split := range splits {
+			newTransaction.Splits = append(newTransaction.Splits, ezex.NewTransactionSplit{
+				TransactionSplit: ezex.TransactionSplit{
+					CategoryID:    split.Category.ID,
+					AmountInCents: split.AmountInCents,
+					Notes:         split.Notes,
+				},
+				CategoryPath: split.Category.Path,
+			})
 		}
 
-		if _, err := ezex.AddTransaction(db, transaction); err != nil {
+		id, err := ezex.CreateTransactionContext(tuiAudit, db, newTransaction)
+		if err != nil {
 			return CreateNewTransactionMsg{Err: err}
 		}
-		if _, err := ezex.UpdateAccountBalance(db, transaction.AccountID, transaction.AmountInCents); err != nil {
-			return CreateNewTransactionMsg{Err: err}
+
+		// The created transaction is looked up among the ones of its account on the same date (in seconds)
+		date := time.Unix(transaction.TransactionDateUnix, 0)
+		var created ezex.TransactionView
+		for _, t := range ezex.FilterTransactions(db, ezex.TransactionFilter{
+			AccountIDs: []int{transaction.AccountID},
+			MinDate:    date,
+			MaxDate:    date.Add(time.Second),
+		}) {
+			if t.ID == id {
+				created = t
+				break
+			}
+		}
+		if payee.ID == 0 {
+			payee.ID = created.PayeeID
+		}
+		if category.ID == 0 && category.Path != "" {
+			category.ID = created.CategoryID
 		}
 
 		now := time.Now()
@@ -59,44 +136,177 @@ func CreateNewTransactionCmd(db *sql.DB, transaction ezex.Transaction, payee eze
 		transactions := ezex.GetTransactions(db, transaction.AccountID, monthStart, monthEnd)
 
 		return CreateNewTransactionMsg{
+			ID:            id,
 			Transactions:  transactions,
 			NewPayee:      payee,
 			NewCategory:   category,
 			AmountInCents: transaction.AmountInCents,
+			Payees:        ezex.GetPayees(db),
+			Categories:    ezex.GetCategories(db),
+			Tags:          ezex.GetTags(db),
+			Transaction:   created,
 			Err:           nil,
 		}
 	}
 }
 
+// DeleteTransactionCmd deletes a transaction and reverts its amount from the account balance, both or neither
 func DeleteTransactionCmd(db *sql.DB, accountID int, id int, amountInCents int64, index int) tea.Cmd {
 	return func() tea.Msg {
-		var err error
-
-		if _, err = ezex.DeleteTransaction(db, id); err != nil {
+		err := ezex.NewSQLiteStoreContext(tuiAudit, db).UnitOfWork(func(s ezex.Store) error {
+			if _, err := s.DeleteTransaction(id); err != nil {
+				return err
+			}
+			_, err := s.UpdateAccountBalance(accountID, amountInCents)
+			return err
+		})
+		if err != nil {
 			return DeleteTransactionMsg{Err: err}
 		}
-		if _, err = ezex.UpdateAccountBalance(db, accountID, amountInCents); err != nil {
-			return CreateNewTransactionMsg{Err: err}
-		}
 
 		return DeleteTransactionMsg{
 			DeletedID:    id,
 			DeletedIndex: index,
-			Err:          err,
+			Err:          nil,
 		}
 	}
 }
 
-func SwitchTransactionsMonthCmd(db *sql.DB, accountID int, year int, month time.Month) tea.Cmd {
+// EditTransactionCmd replaces the before transaction with after, creating its payee and category when new,
+// the account balance is adjusted by the amount difference in the same DB transaction
+func EditTransactionCmd(
+	db *sql.DB,
+	before ezex.Transaction,
+	after ezex.Transaction,
+	payee ezex.Payee,
+	category ezex.Category,
+) tea.Cmd {
 	return func() tea.Msg {
-		monthStart := time.Date(year, month, 1, 0, 0, 0, 0, time.Local)
-		monthEnd := time.Date(year, month+1, 1, 0, 0, 0, 0, time.Local)
-		transactions := ezex.GetTransactions(db, accountID, monthStart, monthEnd)
+		if payee.ID == 0 {
+			id, err := ezex.AddPayeeContext(tuiAudit, db, payee)
+			if err != nil {
+				return EditTransactionMsg{Err: err}
+			}
+			after.PayeeID = id
+		}
+		if category.ID == 0 && category.Path != "" {
+			id, err := ezex.AddCategoryPathContext(tuiAudit, db, category.Path)
+			if err != nil {
+				return EditTransactionMsg{Err: err}
+			}
+			after.CategoryID = id
+		}
+
+		// The amount is subtracted from the balance (see ezex.UpdateAccountBalance)
+		amountInCents := before.AmountInCents - after.AmountInCents
+		err := ezex.NewSQLiteStoreContext(tuiAudit, db).UnitOfWork(func(s ezex.Store) error {
+			if _, err := s.UpdateTransaction(after); err != nil {
+				return err
+			}
+			if amountInCents == 0 {
+				return nil
+			}
+			_, err := s.UpdateAccountBalance(after.AccountID, amountInCents)
+			return err
+		})
+		if err != nil {
+			return EditTransactionMsg{Err: err}
+		}
+
+		return EditTransactionMsg{
+			Before:        before,
+			After:         after,
+			AmountInCents: amountInCents,
+			Payees:        ezex.GetPayees(db),
+			Categories:    ezex.GetCategories(db),
+			Err:           nil,
+		}
+	}
+}
+
+// SwitchTransactionsMonthCmd loads the transactions matching the filter in the given month, no message is sent
+// when ctx is canceled
+func SwitchTransactionsMonthCmd(
+	ctx context.Context,
+	db *sql.DB,
+	filter ezex.TransactionFilter,
+	year int,
+	month time.Month,
+) tea.Cmd {
+	return func() tea.Msg {
+		filter.MinDate = time.Date(year, month, 1, 0, 0, 0, 0, time.Local)
+		filter.MaxDate = time.Date(year, month+1, 1, 0, 0, 0, 0, time.Local)
+		transactions := ezex.FilterTransactionsContext(ctx, db, filter)
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		return SwitchTransactionsMonthMsg{
+			Month:        filter.MinDate.Month(),
+			Year:         filter.MinDate.Year(),
+			Transactions: transactions,
+		}
+	}
+}
+
+// JumpToTransactionCmd loads the transactions matching the filter in the month containing the transaction, selecting it.
+// No message is sent when ctx is canceled
+func JumpToTransactionCmd(
+	ctx context.Context,
+	db *sql.DB,
+	filter ezex.TransactionFilter,
+	transaction ezex.TransactionView,
+) tea.Cmd {
+	return func() tea.Msg {
+		date := time.Unix(transaction.TransactionDateUnix, 0)
+		filter.AccountIDs = []int{transaction.AccountID}
+		filter.MinDate = time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.Local)
+		filter.MaxDate = time.Date(date.Year(), date.Month()+1, 1, 0, 0, 0, 0, time.Local)
+		transactions := ezex.FilterTransactionsContext(ctx, db, filter)
+		if ctx.Err() != nil {
+			return nil
+		}
 
 		return SwitchTransactionsMonthMsg{
-			Month:        monthStart.Month(),
-			Year:         monthStart.Year(),
+			Month:        filter.MinDate.Month(),
+			Year:         filter.MinDate.Year(),
 			Transactions: transactions,
+			SelectedID:   transaction.ID,
 		}
 	}
 }
+
+func SearchTransactionsCmd(ctx context.Context, db *sql.DB, query string) tea.Cmd {
+	return func() tea.Msg {
+		results := ezex.SearchTransactionsContext(ctx, db, query, ezex.TransactionFilter{})
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		return SearchTransactionsMsg{
+			Query:   query,
+			Results: results,
+		}
+	}
+}
+
+// TransactionHistoryCmd loads the changes of a transaction (see ezex.GetHistory), no message is sent when ctx is canceled
+func TransactionHistoryCmd(ctx context.Context, db *sql.DB, transactionID int) tea.Cmd {
+	return func() tea.Msg {
+		history := ezex.GetHistoryContext(ctx, db, ezex.AuditTransaction, transactionID)
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		return TransactionHistoryMsg{
+			TransactionID: transactionID,
+			History:       history,
+		}
+	}
+}
+
+func TransactionFilterCmd(values []string) tea.Cmd {
+	return func() tea.Msg {
+		return TransactionFilterMsg{Values: values}
+	}
+}
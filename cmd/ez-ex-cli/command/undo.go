@@ -0,0 +1,163 @@
+package command
+
+import (
+	"database/sql"
+	"fmt"
+	ezex "github.com/armanimichael/ez-ex"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	CreateTransactionOperation = iota
+	DeleteTransactionOperation
+	SetTransactionStatusOperation
+	CreateAccountOperation
+	DeleteAccountOperation
+	MoveCategoryOperation
+	EditTransactionOperation
+	RecategorizeTransactionOperation
+)
+
+// UndoableOperation is a change made from the TUI that can be reverted and applied again (see UndoCmd)
+type UndoableOperation struct {
+	Kind int
+	// ID is the changed transaction, account or category
+	ID        int
+	AccountID int
+	// AmountInCents is the balance adjustment made along with the change (see ezex.UpdateAccountBalance)
+	AmountInCents int64
+	// From and To are the transaction status or the category parent ID before and after the change
+	From int
+	To   int
+	// Before and After are the edited or recategorized transaction before and after the change
+	Before ezex.Transaction
+	After  ezex.Transaction
+}
+
+// OperationDoneMsg notifies that an operation can be undone
+type OperationDoneMsg = struct {
+	Operation UndoableOperation
+}
+
+// RequestUndoMsg asks to undo the last operation, or to redo the last undone one
+type RequestUndoMsg = struct {
+	Redo bool
+}
+
+// UndoMsg notifies that an operation was undone or, when Redo is set, applied again
+type UndoMsg = struct {
+	Operation UndoableOperation
+	Redo      bool
+	Err       error
+}
+
+func (o UndoableOperation) String() string {
+	switch o.Kind {
+	case CreateTransactionOperation:
+		return fmt.Sprintf("Created transaction %d", o.ID)
+	case DeleteTransactionOperation:
+		return fmt.Sprintf("Deleted transaction %d", o.ID)
+	case SetTransactionStatusOperation:
+		return fmt.Sprintf("Marked transaction %d as %s", o.ID, ezex.TransactionStatus(o.To))
+	case CreateAccountOperation:
+		return fmt.Sprintf("Created account %d", o.ID)
+	case DeleteAccountOperation:
+		return fmt.Sprintf("Deleted account %d", o.ID)
+	case MoveCategoryOperation:
+		return fmt.Sprintf("Moved category %d", o.ID)
+	case EditTransactionOperation:
+		return fmt.Sprintf("Edited transaction %d", o.ID)
+	case RecategorizeTransactionOperation:
+		return fmt.Sprintf("Recategorized transaction %d", o.ID)
+	}
+
+	return "Unknown operation"
+}
+
+func OperationDoneCmd(operation UndoableOperation) tea.Cmd {
+	return func() tea.Msg {
+		return OperationDoneMsg{Operation: operation}
+	}
+}
+
+func RequestUndoCmd(redo bool) tea.Cmd {
+	return func() tea.Msg {
+		return RequestUndoMsg{Redo: redo}
+	}
+}
+
+// UndoCmd reverts an operation, or applies it again when redo is set, restoring the account balance it adjusted.
+// Every step of the operation is saved in a single DB transaction
+func UndoCmd(db *sql.DB, operation UndoableOperation, redo bool) tea.Cmd {
+	return func() tea.Msg {
+		return UndoMsg{
+			Operation: operation,
+			Redo:      redo,
+			Err:       undo(db, operation, redo),
+		}
+	}
+}
+
+func undo(db *sql.DB, o UndoableOperation, redo bool) error {
+	// Creating is deleting backwards, and the other way around
+	deleted := o.Kind == DeleteTransactionOperation || o.Kind == DeleteAccountOperation
+	if !redo {
+		deleted = !deleted
+	}
+	// Redoing repeats the balance adjustment, undoing reverts it
+	amountInCents := o.AmountInCents
+	if !redo {
+		amountInCents = -amountInCents
+	}
+
+	var err error
+	switch o.Kind {
+	case CreateTransactionOperation, DeleteTransactionOperation:
+		err = ezex.NewSQLiteStoreContext(tuiAudit, db).UnitOfWork(func(s ezex.Store) error {
+			var err error
+			if deleted {
+				_, err = s.DeleteTransaction(o.ID)
+			} else {
+				_, err = s.UndeleteTransaction(o.ID)
+			}
+			if err == nil && amountInCents != 0 {
+				_, err = s.UpdateAccountBalance(o.AccountID, amountInCents)
+			}
+			return err
+		})
+	case EditTransactionOperation, RecategorizeTransactionOperation:
+		transaction := o.Before
+		if redo {
+			transaction = o.After
+		}
+		err = ezex.NewSQLiteStoreContext(tuiAudit, db).UnitOfWork(func(s ezex.Store) error {
+			_, err := s.UpdateTransaction(transaction)
+			if err == nil && amountInCents != 0 {
+				_, err = s.UpdateAccountBalance(o.AccountID, amountInCents)
+			}
+			return err
+		})
+	case CreateAccountOperation, DeleteAccountOperation:
+		if deleted {
+			_, err = ezex.DeleteAccountContext(tuiAudit, db, o.ID)
+		} else {
+			_, err = ezex.UndeleteAccountContext(tuiAudit, db, o.ID)
+		}
+	case SetTransactionStatusOperation:
+		status := o.From
+		if redo {
+			status = o.To
+		}
+		err = ezex.SetTransactionStatusContext(tuiAudit, db, o.ID, ezex.TransactionStatus(status))
+	case MoveCategoryOperation:
+		parentID := o.From
+		if redo {
+			parentID = o.To
+		}
+		err = ezex.MoveCategoryContext(tuiAudit, db, o.ID, parentID)
+	default:
+		err = fmt.Errorf("unknown operation kind %d", o.Kind)
+	}
+
+	return err
+}
@@ -0,0 +1,428 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	ezex "github.com/armanimichael/ez-ex"
+	tea "github.com/charmbracelet/bubbletea"
+	"golang.org/x/text/language"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// configFileName is the config file inside the data directory
+const configFileName = "config.json"
+
+const configUsage = `usage:
+  config path
+  config get [key]
+  config set <key> <value>`
+
+// config is the app configuration, values are resolved as flags > env > file > defaults (see loadConfig)
+type config struct {
+	// DB is the DB file, empty means the default one inside the data directory
+	DB       string `json:"db"`
+	LogLevel int    `json:"log_level"`
+	// Locale formats the amounts (e.g. `en`, `it`)
+	Locale string `json:"locale"`
+	// Currency is the ISO 4217 code shown next to the balances, empty shows none
+	Currency string `json:"currency"`
+	Theme    string `json:"theme"`
+	// KeyBindings maps a key to the app key it acts as (e.g. `"ctrl+n": "n"`)
+	KeyBindings map[string]string `json:"key_bindings"`
+	// DateRange is the default period of the reports, from the start of the current month, quarter or year
+	DateRange string       `json:"date_range"`
+	Backup    backupConfig `json:"backup"`
+	// Profiles maps a profile name to its DB file, the default profile uses DB (see `ez-ex profile`)
+	Profiles map[string]string `json:"profiles"`
+}
+
+type backupConfig struct {
+	KeepDaily   int `json:"keep_daily"`
+	KeepWeekly  int `json:"keep_weekly"`
+	KeepMonthly int `json:"keep_monthly"`
+}
+
+// configField is a config value that can be read and written as text (see `ez-ex config`)
+type configField struct {
+	key string
+	// env overrides the config file, empty if the field has no env var
+	env string
+	get func(c config) string
+	set func(c *config, value string) error
+}
+
+var themes = []string{"dark", "light"}
+
+var dateRanges = []string{"month", "quarter", "year"}
+
+var currencyRegex = regexp.MustCompile(`^[A-Z]{3}$`)
+
+var configFields = []configField{
+	{
+		key: "db",
+		env: "EZEX_DB",
+		get: func(c config) string { return c.DB },
+		set: func(c *config, value string) error {
+			c.DB = value
+			return nil
+		},
+	},
+	{
+		key: "log_level",
+		env: "EZEX_LOG_LEVEL",
+		get: func(c config) string { return strconv.Itoa(c.LogLevel) },
+		set: func(c *config, value string) (err error) {
+			c.LogLevel, err = strconv.Atoi(value)
+			return err
+		},
+	},
+	{
+		key: "locale",
+		env: "EZEX_LOCALE",
+		get: func(c config) string { return c.Locale },
+		set: func(c *config, value string) error {
+			c.Locale = value
+			return nil
+		},
+	},
+	{
+		key: "currency",
+		env: "EZEX_CURRENCY",
+		get: func(c config) string { return c.Currency },
+		set: func(c *config, value string) error {
+			c.Currency = strings.ToUpper(value)
+			return nil
+		},
+	},
+	{
+		key: "theme",
+		env: "EZEX_THEME",
+		get: func(c config) string { return c.Theme },
+		set: func(c *config, value string) error {
+			c.Theme = value
+			return nil
+		},
+	},
+	{
+		key: "date_range",
+		env: "EZEX_DATE_RANGE",
+		get: func(c config) string { return c.DateRange },
+		set: func(c *config, value string) error {
+			c.DateRange = value
+			return nil
+		},
+	},
+	{
+		key: "backup.keep_daily",
+		get: func(c config) string { return strconv.Itoa(c.Backup.KeepDaily) },
+		set: func(c *config, value string) (err error) {
+			c.Backup.KeepDaily, err = strconv.Atoi(value)
+			return err
+		},
+	},
+	{
+		key: "backup.keep_weekly",
+		get: func(c config) string { return strconv.Itoa(c.Backup.KeepWeekly) },
+		set: func(c *config, value string) (err error) {
+			c.Backup.KeepWeekly, err = strconv.Atoi(value)
+			return err
+		},
+	},
+	{
+		key: "backup.keep_monthly",
+		get: func(c config) string { return strconv.Itoa(c.Backup.KeepMonthly) },
+		set: func(c *config, value string) (err error) {
+			c.Backup.KeepMonthly, err = strconv.Atoi(value)
+			return err
+		},
+	},
+}
+
+// keyBindingPrefix is the prefix of the key bindings fields, followed by the bound key (e.g. `key_bindings.ctrl+n`)
+const keyBindingPrefix = "key_bindings."
+
+// namedKeys are the keys with a name (e.g. `enter`), others are typed as they are (e.g. `n`)
+var namedKeys = func() map[string]tea.KeyType {
+	keys := map[string]tea.KeyType{"space": tea.KeySpace}
+	for key := tea.KeyType(-100); key <= tea.KeyCtrlQuestionMark; key++ {
+		if name := key.String(); name != "" && key != tea.KeyRunes {
+			keys[name] = key
+		}
+	}
+
+	return keys
+}()
+
+func defaultConfig() config {
+	return config{
+		LogLevel:    5,
+		Locale:      "en",
+		Theme:       "dark",
+		KeyBindings: map[string]string{},
+		DateRange:   "month",
+		Profiles:    map[string]string{},
+		Backup: backupConfig{
+			KeepDaily:   ezex.DefaultRetentionPolicy.Daily,
+			KeepWeekly:  ezex.DefaultRetentionPolicy.Weekly,
+			KeepMonthly: ezex.DefaultRetentionPolicy.Monthly,
+		},
+	}
+}
+
+func configPath(dataDir string) string {
+	return filepath.Join(dataDir, configFileName)
+}
+
+// readConfigFile returns the defaults overridden by the config file, if any
+func readConfigFile(dataDir string) (config, error) {
+	c := defaultConfig()
+
+	content, err := os.ReadFile(configPath(dataDir))
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return c, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+	if err = decoder.Decode(&c); err != nil {
+		return c, fmt.Errorf("%s: %w", configPath(dataDir), err)
+	}
+	if c.KeyBindings == nil {
+		c.KeyBindings = map[string]string{}
+	}
+	if c.Profiles == nil {
+		c.Profiles = map[string]string{}
+	}
+
+	return c, nil
+}
+
+// loadConfig returns the config file values overridden by the env vars, flags are applied by the caller
+func loadConfig(dataDir string) (config, error) {
+	c, err := readConfigFile(dataDir)
+	if err != nil {
+		return c, err
+	}
+
+	for _, field := range configFields {
+		if value := os.Getenv(field.env); field.env != "" && value != "" {
+			if err = field.set(&c, value); err != nil {
+				return c, fmt.Errorf("%s: %w", field.env, err)
+			}
+		}
+	}
+
+	return c, c.validate()
+}
+
+func (c config) write(dataDir string) error {
+	content, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(configPath(dataDir), append(content, '\n'), 0600)
+}
+
+func (c config) validate() error {
+	if c.LogLevel < 0 || c.LogLevel > 6 {
+		return errors.New("log_level must be between 0 (trace) and 6 (none)")
+	}
+	if _, err := language.Parse(c.Locale); err != nil {
+		return fmt.Errorf("invalid locale %q: %w", c.Locale, err)
+	}
+	if c.Currency != "" && !currencyRegex.MatchString(c.Currency) {
+		return fmt.Errorf("invalid currency %q, should be an ISO 4217 code (e.g. EUR)", c.Currency)
+	}
+	if !slices.Contains(themes, c.Theme) {
+		return fmt.Errorf("invalid theme %q, should be one of: %s", c.Theme, strings.Join(themes, ", "))
+	}
+	if !slices.Contains(dateRanges, c.DateRange) {
+		return fmt.Errorf("invalid date_range %q, should be one of: %s", c.DateRange, strings.Join(dateRanges, ", "))
+	}
+	if c.Backup.KeepDaily < 0 || c.Backup.KeepWeekly < 0 || c.Backup.KeepMonthly < 0 {
+		return errors.New("backups to keep must not be negative")
+	}
+	for key, target := range c.KeyBindings {
+		if key == "" {
+			return errors.New("empty key binding")
+		}
+		if _, ok := keyMsg(target); !ok {
+			return fmt.Errorf("invalid key binding %s: unknown key %q", key, target)
+		}
+	}
+	for name, db := range c.Profiles {
+		if err := validateProfileName(name); err != nil {
+			return err
+		}
+		if db == "" {
+			return fmt.Errorf("profile %s has no DB file", name)
+		}
+	}
+
+	return nil
+}
+
+func (c config) get(key string) (string, error) {
+	if boundKey, ok := strings.CutPrefix(key, keyBindingPrefix); ok {
+		return c.KeyBindings[boundKey], nil
+	}
+
+	field, ok := findConfigField(key)
+	if !ok {
+		return "", fmt.Errorf("unknown config key: %s", key)
+	}
+
+	return field.get(c), nil
+}
+
+// set changes a config value, an empty value removes a key binding
+func (c *config) set(key string, value string) error {
+	if boundKey, ok := strings.CutPrefix(key, keyBindingPrefix); ok {
+		if value == "" {
+			delete(c.KeyBindings, boundKey)
+		} else {
+			c.KeyBindings[boundKey] = value
+		}
+
+		return nil
+	}
+
+	field, ok := findConfigField(key)
+	if !ok {
+		return fmt.Errorf("unknown config key: %s", key)
+	}
+
+	return field.set(c, value)
+}
+
+// keys returns every config key, including the key bindings
+func (c config) keys() []string {
+	keys := make([]string, 0, len(configFields)+len(c.KeyBindings))
+	for _, field := range configFields {
+		keys = append(keys, field.key)
+	}
+
+	var bindings []string
+	for key := range c.KeyBindings {
+		bindings = append(bindings, keyBindingPrefix+key)
+	}
+	sort.Strings(bindings)
+
+	return append(keys, bindings...)
+}
+
+// dateRangeStart returns the first day of the configured date range containing now
+func (c config) dateRangeStart(now time.Time) time.Time {
+	switch c.DateRange {
+	case "quarter":
+		return time.Date(now.Year(), now.Month()-(now.Month()-1)%3, 1, 0, 0, 0, 0, time.Local)
+	case "year":
+		return time.Date(now.Year(), time.January, 1, 0, 0, 0, 0, time.Local)
+	}
+
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+}
+
+// apply sets the amounts locale, the currency and the theme, it must be called before creating any model
+func (c config) apply() {
+	amountLocale = language.Make(c.Locale)
+	currencyCode = c.Currency
+	applyTheme(c.Theme)
+}
+
+// runConfig handles `ez-ex config`: c is the resolved config shown by get, set only changes the config file
+func runConfig(c config, dataDir string, args []string) error {
+	if len(args) == 0 {
+		return errors.New(configUsage)
+	}
+
+	switch args[0] {
+	case "path":
+		fmt.Println(configPath(dataDir))
+	case "get":
+		if len(args) > 2 {
+			return errors.New(configUsage)
+		}
+
+		keys := c.keys()
+		if len(args) == 2 {
+			keys = []string{args[1]}
+		}
+		for _, key := range keys {
+			value, err := c.get(key)
+			if err != nil {
+				return err
+			}
+
+			if len(args) == 2 {
+				fmt.Println(value)
+			} else {
+				fmt.Printf("%s=%s\n", key, value)
+			}
+		}
+	case "set":
+		if len(args) != 3 {
+			return errors.New(configUsage)
+		}
+
+		fileConfig, err := readConfigFile(dataDir)
+		if err != nil {
+			return err
+		}
+		if err = fileConfig.set(args[1], args[2]); err != nil {
+			return fmt.Errorf("%s: %w", args[1], err)
+		}
+		if err = fileConfig.validate(); err != nil {
+			return err
+		}
+
+		return fileConfig.write(dataDir)
+	default:
+		return errors.New(configUsage)
+	}
+
+	return nil
+}
+
+// keyMsg returns the key message of a key name (see tea.Key.String)
+func keyMsg(name string) (tea.KeyMsg, bool) {
+	if key, ok := namedKeys[name]; ok {
+		msg := tea.KeyMsg{Type: key}
+		if key == tea.KeySpace {
+			// Text inputs insert the space rune
+			msg.Runes = []rune{' '}
+		}
+
+		return msg, true
+	}
+
+	runes := []rune(name)
+	if len(runes) != 1 {
+		return tea.KeyMsg{}, false
+	}
+
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: runes}, true
+}
+
+func findConfigField(key string) (configField, bool) {
+	for _, field := range configFields {
+		if field.key == key {
+			return field, true
+		}
+	}
+
+	return configField{}, false
+}
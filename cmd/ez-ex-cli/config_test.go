@@ -0,0 +1,100 @@
+package main
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("EZEX_CURRENCY", "")
+	t.Setenv("EZEX_THEME", "")
+
+	c, err := loadConfig(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, defaultConfig(), c)
+
+	_ = os.WriteFile(configPath(dir), []byte(`{"currency": "EUR", "theme": "light", "key_bindings": {"ctrl+n": "n"}}`), 0600)
+	c, err = loadConfig(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, "EUR", c.Currency)
+	assert.Equal(t, "light", c.Theme)
+	assert.Equal(t, "month", c.DateRange)
+	assert.Equal(t, map[string]string{"ctrl+n": "n"}, c.KeyBindings)
+
+	// Env vars override the file
+	t.Setenv("EZEX_CURRENCY", "usd")
+	c, err = loadConfig(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, "USD", c.Currency)
+
+	t.Setenv("EZEX_THEME", "blue")
+	_, err = loadConfig(dir)
+	assert.Error(t, err)
+	t.Setenv("EZEX_THEME", "")
+
+	_ = os.WriteFile(configPath(dir), []byte(`{"colour": "blue"}`), 0600)
+	_, err = loadConfig(dir)
+	assert.Error(t, err)
+}
+
+func TestConfig_Validate(t *testing.T) {
+	invalid := []func(c *config){
+		func(c *config) { c.LogLevel = 7 },
+		func(c *config) { c.Locale = "not a locale" },
+		func(c *config) { c.Currency = "EURO" },
+		func(c *config) { c.Theme = "blue" },
+		func(c *config) { c.DateRange = "week" },
+		func(c *config) { c.Backup.KeepDaily = -1 },
+		func(c *config) { c.KeyBindings["ctrl+n"] = "nn" },
+	}
+	for _, change := range invalid {
+		c := defaultConfig()
+		change(&c)
+		assert.Error(t, c.validate())
+	}
+
+	assert.Nil(t, defaultConfig().validate())
+}
+
+func TestRunConfig_Set(t *testing.T) {
+	dir := t.TempDir()
+
+	assert.Nil(t, runConfig(defaultConfig(), dir, []string{"set", "backup.keep_daily", "3"}))
+	assert.Nil(t, runConfig(defaultConfig(), dir, []string{"set", "key_bindings.ctrl+n", "enter"}))
+	assert.Error(t, runConfig(defaultConfig(), dir, []string{"set", "date_range", "week"}))
+	assert.Error(t, runConfig(defaultConfig(), dir, []string{"set", "unknown", "value"}))
+
+	c, err := readConfigFile(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, c.Backup.KeepDaily)
+	assert.Equal(t, "month", c.DateRange)
+	value, _ := c.get("key_bindings.ctrl+n")
+	assert.Equal(t, "enter", value)
+
+	assert.Nil(t, runConfig(defaultConfig(), dir, []string{"set", "key_bindings.ctrl+n", ""}))
+	c, _ = readConfigFile(dir)
+	assert.Empty(t, c.KeyBindings)
+}
+
+func TestConfig_DateRangeStart(t *testing.T) {
+	now := time.Date(2026, time.August, 14, 10, 0, 0, 0, time.Local)
+	c := defaultConfig()
+
+	assert.Equal(t, time.Date(2026, time.August, 1, 0, 0, 0, 0, time.Local), c.dateRangeStart(now))
+	c.DateRange = "quarter"
+	assert.Equal(t, time.Date(2026, time.July, 1, 0, 0, 0, 0, time.Local), c.dateRangeStart(now))
+	c.DateRange = "year"
+	assert.Equal(t, time.Date(2026, time.January, 1, 0, 0, 0, 0, time.Local), c.dateRangeStart(now))
+}
+
+func TestModel_BindKey(t *testing.T) {
+	m := model{keyBindings: map[string]string{"ctrl+n": "n", "x": "space"}}
+
+	assert.Equal(t, "n", m.bindKey(tea.KeyMsg{Type: tea.KeyCtrlN}).String())
+	assert.Equal(t, " ", m.bindKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'x'}}).String())
+	assert.Equal(t, "enter", m.bindKey(tea.KeyMsg{Type: tea.KeyEnter}).String())
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	ezex "github.com/armanimichael/ez-ex"
+	"github.com/armanimichael/ez-ex/cmd/ez-ex-cli/command"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"strings"
+	"time"
+)
+
+type dashboardModel struct {
+	// ctx is canceled when leaving the screen
+	ctx                context.Context
+	db                 *sql.DB
+	month              time.Time
+	totalBalance       int64
+	cashFlow           ezex.CashFlow
+	categoryTotals     []ezex.CategoryTotal
+	upcoming           []ezex.TransactionView
+	recentTransactions []ezex.TransactionView
+	size               tea.WindowSizeMsg
+	table              struct {
+		model table.Model
+	}
+}
+
+const (
+	dashboardTopCategories = 5
+	dashboardUpcomingCount = 3
+	dashboardRecentCount   = 10
+	dashboardBarWidth      = 30
+)
+
+var dashboardTableColumns = []tableColumn{
+	fixedColumn("ID", 5),
+	fixedColumn("Date", 10),
+	flexColumn("Account", 20),
+	fixedColumn("Amount", 10),
+	flexColumn("Payee", 20),
+	flexColumn("Category", 20),
+}
+
+var dashboardKeySuggestions = formatKeySuggestions([][]string{
+	{"^C", "quit"},
+	{"a", "accounts list"},
+	{"c", "categories"},
+	{"r", "rules"},
+	{"D", "review duplicates"},
+	{"{enter}", "open transaction account"},
+})
+
+// initDashboardModel creates an empty dashboard, its data is loaded by Init
+func initDashboardModel(ctx context.Context, db *sql.DB) (m dashboardModel) {
+	now := time.Now()
+
+	m.ctx = ctx
+	m.db = db
+	m.month = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+	m.table.model = createStandardTable(dashboardTableColumns, nil)
+
+	return m
+}
+
+func (m dashboardModel) Init() tea.Cmd {
+	return command.LoadDashboardCmd(m.ctx, m.db, dashboardUpcomingCount, dashboardRecentCount)
+}
+
+func (m dashboardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	m.table.model, cmd = m.table.model.Update(msg)
+
+	switch msg := msg.(type) {
+	case command.DashboardMsg:
+		m.totalBalance = msg.TotalBalance
+		m.cashFlow = msg.CashFlow
+		m.categoryTotals = msg.CategoryTotals
+		m.upcoming = msg.Upcoming
+		m.recentTransactions = msg.RecentTransactions
+		m.table.model.SetRows(recentTransactionsToTableRows(m.recentTransactions...))
+		m.table.model.SetCursor(0)
+		// The top expenses and upcoming transactions change the room left for the table
+		m.table.model = fitTable(m.table.model, dashboardTableColumns, m.View(), m.size)
+	case tea.WindowSizeMsg:
+		m.size = msg
+		m.table.model = fitTable(m.table.model, dashboardTableColumns, m.View(), m.size)
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "a":
+			logger.Debug("Go to account list")
+			return m, command.SwitchModelCmd(accountModelID, 0)
+		case "c":
+			logger.Debug("Go to category tree")
+			return m, command.SwitchModelCmd(categoryModelID, 0)
+		case "r":
+			logger.Debug("Go to rule list")
+			return m, command.SwitchModelCmd(ruleModelID, 0)
+		case "D":
+			logger.Debug("Go to duplicates review")
+			return m, command.SwitchModelCmd(duplicateModelID, 0)
+		case "enter":
+			if len(m.recentTransactions) == 0 {
+				break
+			}
+
+			accountID := m.recentTransactions[m.table.model.Cursor()].AccountID
+			logger.Debug(fmt.Sprintf("Select account ID %v", accountID))
+			return m, command.SwitchModelCmd(transactionModelID, accountID)
+		}
+	}
+
+	return m, cmd
+}
+
+func (m dashboardModel) View() string {
+	str := strings.Builder{}
+	str.WriteString(fmt.Sprintf("Total balance:\t%s\n\n", encodeBalance(m.totalBalance)))
+
+	str.WriteString(fmt.Sprintf("%s\n", m.month.Format("January 2006")))
+	str.WriteString(fmt.Sprintf("Income:\t\t%s\n", encodeCents(m.cashFlow.IncomeInCents, true)))
+	str.WriteString(fmt.Sprintf("Expenses:\t%s\n", encodeCents(m.cashFlow.ExpenseInCents, true)))
+	str.WriteString(fmt.Sprintf("Net:\t\t%s\n\n", encodeCents(m.cashFlow.IncomeInCents+m.cashFlow.ExpenseInCents, true)))
+
+	str.WriteString(m.topExpensesView())
+
+	if len(m.upcoming) > 0 {
+		str.WriteString("Upcoming\n")
+		for _, transaction := range m.upcoming {
+			str.WriteString(fmt.Sprintf(
+				"%s  %-20s %-20s %s\n",
+				encodeUnixDate(transaction.TransactionDateUnix),
+				transaction.AccountName,
+				transaction.PayeeName,
+				encodeCents(transaction.AmountInCents, true),
+			))
+		}
+		str.WriteString("\n")
+	}
+
+	str.WriteString("Recent transactions\n")
+	str.WriteString(baseStyle.Render(m.table.model.View()) + "\n")
+	str.WriteString(dashboardKeySuggestions)
+
+	return str.String()
+}
+
+// topExpensesView renders a bar for each of the top-level categories (subcategories included)
+// with the highest expenses in the month
+func (m dashboardModel) topExpensesView() string {
+	var expenses []ezex.CategoryTotal
+	for _, total := range m.categoryTotals {
+		// Totals are sorted from the biggest expense, stop at the first non-expense
+		if total.AmountInCents >= 0 || len(expenses) == dashboardTopCategories {
+			break
+		}
+		if total.ParentID == 0 {
+			expenses = append(expenses, total)
+		}
+	}
+
+	if len(expenses) == 0 {
+		return ""
+	}
+
+	str := strings.Builder{}
+	str.WriteString("Top expenses\n")
+	for _, expense := range expenses {
+		str.WriteString(fmt.Sprintf(
+			"%-20s %s %s\n",
+			expense.CategoryName,
+			renderBar(-expense.AmountInCents, -expenses[0].AmountInCents, dashboardBarWidth),
+			encodeCents(expense.AmountInCents, true),
+		))
+	}
+	str.WriteString("\n")
+
+	return str.String()
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"github.com/armanimichael/ez-ex/archive"
+	"io"
+	"os"
+)
+
+const decryptUsage = `usage:
+  decrypt [-output file] <file>`
+
+// runDecrypt handles `ez-ex decrypt`, writing the content of an encrypted backup or export to a file or stdout
+func runDecrypt(args []string) error {
+	flags := flag.NewFlagSet("decrypt", flag.ExitOnError)
+	output := flags.String("output", "", "Output file (defaults to stdout)")
+	_ = flags.Parse(args)
+	if flags.NArg() != 1 {
+		return errors.New(decryptUsage)
+	}
+
+	passphrase, err := readPassphrase(false)
+	if err != nil {
+		return err
+	}
+	if *output != "" {
+		return archive.DecryptFile(flags.Arg(0), *output, passphrase)
+	}
+
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer func(file *os.File) {
+		_ = file.Close()
+	}(file)
+
+	r, err := archive.NewReader(file, passphrase)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(os.Stdout, r)
+
+	return err
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	ezex "github.com/armanimichael/ez-ex"
+	"github.com/armanimichael/ez-ex/cmd/ez-ex-cli/command"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"strings"
+	"time"
+)
+
+// duplicateModel reviews the likely duplicate transactions, merging or dismissing each pair
+type duplicateModel struct {
+	db    *sql.DB
+	pairs []ezex.DuplicatePair
+	err   struct {
+		id  int64
+		msg string
+	}
+	size  tea.WindowSizeMsg
+	table struct {
+		model table.Model
+	}
+}
+
+var duplicateTableColumns = []tableColumn{
+	flexColumn("Account", 15),
+	flexColumn("Payee", 20),
+	fixedColumn("Amount", 10),
+	fixedColumn("First", 10),
+	flexColumn("First notes", 20),
+	fixedColumn("Second", 10),
+	flexColumn("Second notes", 20),
+	fixedColumn("Notes sim.", 10),
+}
+
+var duplicateTableKeySuggestions = formatKeySuggestions([][]string{
+	{"^C", "quit"},
+	{"{esc}", "dashboard"},
+	{"m", "merge into first"},
+	{"M", "merge into second"},
+	{"x", "not a duplicate"},
+})
+
+func initDuplicateModel(db *sql.DB) (m duplicateModel) {
+	m.db = db
+	m.pairs = ezex.FindDuplicates(db, ezex.DuplicateMaxDays)
+	m.table.model = createStandardTable(duplicateTableColumns, duplicatesToTableRows(m.pairs...))
+
+	return m
+}
+
+func (m duplicateModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m duplicateModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.size = msg
+		m.table.model = fitTable(m.table.model, duplicateTableColumns, m.View(), m.size)
+
+		return m, nil
+	case command.HideErrorMessageMsg:
+		if msg.Message == m.err.msg && msg.ID == m.err.id {
+			m.err.msg = ""
+		}
+	case command.UpdateDuplicatesMsg:
+		if msg.Err != nil {
+			logger.Err(fmt.Sprintf("Error reviewing duplicates: %v", msg.Err))
+			m.err.msg = formatError(msg.Err)
+			m.err.id = time.Now().UnixMicro()
+
+			return m, command.HideErrorMessageCmd(m.err.id, m.err.msg)
+		}
+
+		cursor := m.table.model.Cursor()
+		m.pairs = msg.Pairs
+		m.table.model.SetRows(duplicatesToTableRows(m.pairs...))
+		m.table.model.SetCursor(min(cursor, max(len(m.pairs)-1, 0)))
+
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.table.model, cmd = m.table.model.Update(msg)
+
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "esc":
+			logger.Debug("Go back to dashboard")
+			return m, command.SwitchModelCmd(dashboardModelID, 0)
+		case "m", "M", "x":
+			if len(m.pairs) == 0 {
+				break
+			}
+
+			pair := m.pairs[m.table.model.Cursor()]
+			switch msg.String() {
+			case "m":
+				logger.Debug(fmt.Sprintf("Merge transaction %v into %v", pair.Duplicate.ID, pair.Transaction.ID))
+				return m, command.MergeDuplicateCmd(m.db, pair.Transaction, pair.Duplicate)
+			case "M":
+				logger.Debug(fmt.Sprintf("Merge transaction %v into %v", pair.Transaction.ID, pair.Duplicate.ID))
+				return m, command.MergeDuplicateCmd(m.db, pair.Duplicate, pair.Transaction)
+			case "x":
+				logger.Debug(fmt.Sprintf("Dismiss duplicates %v and %v", pair.Transaction.ID, pair.Duplicate.ID))
+				return m, command.DismissDuplicateCmd(m.db, pair)
+			}
+		case "up", "down":
+			m.err.msg = ""
+		}
+	}
+
+	return m, cmd
+}
+
+func (m duplicateModel) View() string {
+	str := strings.Builder{}
+	if len(m.pairs) == 0 {
+		str.WriteString(successMessageStyle.Render("No likely duplicates found") + "\n")
+	} else {
+		str.WriteString(fmt.Sprintf("%d likely duplicates\n", len(m.pairs)))
+	}
+	str.WriteString(baseStyle.Render(m.table.model.View()) + "\n" + duplicateTableKeySuggestions + "\n")
+
+	if m.err.msg != "" {
+		str.WriteString(errorMessageStyle.Render("Error: "+m.err.msg) + "\n")
+	}
+
+	return str.String()
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	ezex "github.com/armanimichael/ez-ex"
+	"github.com/armanimichael/ez-ex/archive"
+	"github.com/armanimichael/ez-ex/export"
+	"io"
+	"os"
+	"time"
+)
+
+// runExport handles `ez-ex export`, writing the requested data to a file or stdout,
+// encrypted with a passphrase (see readPassphrase) when -encrypt is set
+func runExport(db *sql.DB, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	data := flags.String("data", "transactions", "Data to export (transactions, accounts, payees, categories)")
+	formatName := flags.String("format", "csv", "Output format (csv, json)")
+	output := flags.String("output", "", "Output file (defaults to stdout)")
+	accountID := flags.Int("account", 0, "Only export the transactions of this account ID (0 = all accounts)")
+	from := flags.String("from", "", "Only export transactions from this date, YYYY-MM-DD (included)")
+	to := flags.String("to", "", "Only export transactions up to this date, YYYY-MM-DD (included)")
+	encrypt := flags.Bool("encrypt", false, "Encrypt the export with a passphrase (read from "+passphraseEnv+" or prompted)")
+	_ = flags.Parse(args)
+
+	format, err := export.ParseFormat(*formatName)
+	if err != nil {
+		return err
+	}
+
+	filter := ezex.TransactionFilter{}
+	if *accountID != 0 {
+		filter.AccountIDs = []int{*accountID}
+	}
+	if *from != "" {
+		if err = validateDateString(*from); err != nil {
+			return fmt.Errorf("-from: %w", err)
+		}
+		filter.MinDate = time.Unix(decodeUnixDate(*from), 0)
+	}
+	if *to != "" {
+		if err = validateDateString(*to); err != nil {
+			return fmt.Errorf("-to: %w", err)
+		}
+		filter.MaxDate = time.Unix(decodeUnixDate(*to), 0).AddDate(0, 0, 1)
+	}
+
+	var passphrase string
+	if *encrypt {
+		if passphrase, err = readPassphrase(true); err != nil {
+			return err
+		}
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.OpenFile(*output, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+		if err != nil {
+			return err
+		}
+		defer func(file *os.File) {
+			_ = file.Close()
+		}(file)
+
+		w = file
+	}
+
+	if *encrypt {
+		aw, err := archive.NewWriter(w, passphrase)
+		if err != nil {
+			return err
+		}
+		if err = exportData(db, aw, *data, format, filter); err != nil {
+			return err
+		}
+
+		return aw.Close()
+	}
+
+	return exportData(db, w, *data, format, filter)
+}
+
+func exportData(db *sql.DB, w io.Writer, data string, format export.Format, filter ezex.TransactionFilter) error {
+	switch data {
+	case "transactions":
+		return export.Transactions(db, w, format, filter)
+	case "accounts":
+		return export.Accounts(db, w, format)
+	case "payees":
+		return export.Payees(db, w, format)
+	case "categories":
+		return export.Categories(db, w, format)
+	}
+
+	return errors.New("unsupported export data: " + data)
+}
@@ -1,27 +1,40 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	ezex "github.com/armanimichael/ez-ex"
 	"golang.org/x/text/language"
-	"golang.org/x/text/message"
 	"strconv"
 	"strings"
 	"time"
 )
 
+// amountLocale formats the amounts (see config.apply)
+var amountLocale = language.English
+
+// currencyCode is shown next to the balances (see encodeBalance), empty shows none
+var currencyCode string
+
+// encodeCents formats an amount with the configured locale, pad right-aligns it for tables
 func encodeCents(cents int64, pad bool) string {
+	str := ezex.Money{Cents: cents}.Format(amountLocale)
 	if pad {
-		return message.NewPrinter(language.English).Sprintf("%10.2f", float64(cents)/100.0)
+		return fmt.Sprintf("%10s", str)
 	}
 
-	return message.NewPrinter(language.English).Sprintf("%.2f", float64(cents)/100.0)
+	return str
 }
 
-func decodeCents(cents string) int64 {
-	str := strings.Replace(cents, ".", "", 1)
-	c, _ := strconv.ParseInt(str, 10, 64)
+// encodeBalance formats a balance followed by the configured currency
+func encodeBalance(cents int64) string {
+	return ezex.Money{Cents: cents, Currency: currencyCode}.Format(amountLocale)
+}
 
-	return c
+// decodeCents parses an amount (see ezex.ParseMoney), 0 if invalid
+func decodeCents(amount string) int64 {
+	money, _ := ezex.ParseMoney(amount)
+	return money.Cents
 }
 
 func encodeUnixDate(unix int64) string {
@@ -50,3 +63,31 @@ func formatKeySuggestions(commands [][]string) string {
 
 	return str.String()
 }
+
+// renderBar renders a horizontal bar of at most `width` cells proportional to value / max
+func renderBar(value int64, max int64, width int) string {
+	if max <= 0 || value <= 0 {
+		return strings.Repeat(" ", width)
+	}
+
+	filled := int(value * int64(width) / max)
+	if filled == 0 {
+		filled = 1
+	}
+
+	return barStyle.Render(strings.Repeat("█", filled)) + strings.Repeat(" ", width-filled)
+}
+
+// formatError returns the message shown for err, friendlier than the DB error for the ezex sentinel errors
+func formatError(err error) string {
+	switch {
+	case errors.Is(err, ezex.ErrDuplicateName):
+		return "the name is already taken"
+	case errors.Is(err, ezex.ErrInUse):
+		return "it's still in use by some transactions"
+	case errors.Is(err, ezex.ErrNotFound):
+		return "it doesn't exist anymore"
+	}
+
+	return err.Error()
+}
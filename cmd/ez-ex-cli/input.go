@@ -45,7 +45,12 @@ func standardTextInputView(stage int, inputs []standardTextInput, autocompleteSu
 		}
 		label := mark + fmt.Sprintf("%-19s", input.label+": ")
 
-		inputListStr.WriteString(render(label) + input.model.View())
+		model := input.model
+		if autocompleteSuggestion != "" && i == stage && model.Value() == "" {
+			// Suggestions for empty inputs (e.g. learned categories) replace the placeholder
+			model.Placeholder = autocompleteSuggestion
+		}
+		inputListStr.WriteString(render(label) + model.View())
 
 		if autocompleteSuggestion != "" && i == stage && input.model.Value() != "" {
 			inputListStr.WriteString(lowOpacityForegroundStyle.Render(autocompleteSuggestion))
@@ -1,13 +1,12 @@
 package logger
 
 import (
-	ezex "github.com/armanimichael/ez-ex"
 	"log"
 	"os"
 	"path"
 )
 
-// FileLogger logs to ezex.UserDataDir/logs
+// FileLogger logs to the `logs` file inside the data directory (see ezex.DataDir)
 type FileLogger struct {
 	logger *log.Logger
 	level  int
@@ -25,7 +24,7 @@ type FileLogger struct {
 //	4 = err
 //	5 = fatal
 //	6 = none
-func NewFileLogger(level int) Logger {
+func NewFileLogger(level int, dataDir string) Logger {
 	if level > 6 || level < 0 {
 		panic("log level must be between 0 (trace) and 6 (none)")
 	}
@@ -34,8 +33,7 @@ func NewFileLogger(level int) Logger {
 		return emptyLogger{}
 	}
 
-	home, _ := os.UserHomeDir()
-	logsFile, err := os.OpenFile(path.Join(home, ezex.UserDataDir, "logs"), os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	logsFile, err := os.OpenFile(path.Join(dataDir, "logs"), os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
 	if err != nil {
 		log.Fatalf("Error opening log file: %s", err)
 	}
@@ -9,50 +9,178 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"log"
 	"os"
+	"path/filepath"
 )
 
 var logger customLogger.Logger
 
 func main() {
-	dbName := flag.String(
-		"db-name",
-		ezex.DefaultDBName,
-		"App DB filename (if not present, one will be created)",
+	dbPath := flag.String(
+		"db",
+		"",
+		"App DB file (if not present, one will be created), :memory: for a temporary DB (default: config db, <data-dir>/"+ezex.DefaultDBName+")",
+	)
+	dataDir := flag.String(
+		"data-dir",
+		"",
+		"Directory of the DB, attachments, backups and logs (default $"+ezex.DataDirEnv+", ~/.ez-ex or $XDG_DATA_HOME/ez-ex)",
+	)
+	profileName := flag.String(
+		"profile",
+		"",
+		"Profile to open (see `profile list`), without it the app asks which one when there are several",
 	)
 	logLevel := flag.Int(
 		"log-level",
 		5,
-		"Application log level (trace = 0, debug = 1, info = 2, warn = 3, error = 4, fatal = 5, none = 6)",
+		"Application log level (trace = 0, debug = 1, info = 2, warn = 3, error = 4, fatal = 5, none = 6), overrides the config log_level",
 	)
+	flag.Usage = func() {
+		out := flag.CommandLine.Output()
+		_, _ = fmt.Fprintf(out, "Usage: %s [flags] [command]\n\n", os.Args[0])
+		_, _ = fmt.Fprintln(out, "Commands:")
+		_, _ = fmt.Fprintln(out, "  export\texport data as CSV or JSON (see `export -h`)")
+		_, _ = fmt.Fprintln(out, "  report\trender a self-contained HTML report (see `report html -h`)")
+		_, _ = fmt.Fprintln(out, "  attachment\tadd, list, remove, export or check transaction attachments")
+		_, _ = fmt.Fprintln(out, "  rules\tlist the categorization rules or apply them to existing transactions")
+		_, _ = fmt.Fprintln(out, "  backup\tback up the DB into <data-dir>/backups, removing the old backups (see `backup -h`)")
+		_, _ = fmt.Fprintln(out, "  restore\treplace the DB with a backup, encrypted backups are decrypted")
+		_, _ = fmt.Fprintln(out, "  decrypt\tdecrypt an encrypted backup or export (see `backup -encrypt` and `export -encrypt`)")
+		_, _ = fmt.Fprintln(out, "  profile\tlist, create, rename or delete the profiles, each one with its own DB")
+		_, _ = fmt.Fprintln(out, "  config\tshow or change the settings of <data-dir>/"+configFileName+" (config path|get|set)")
+		_, _ = fmt.Fprintln(out, "\nWithout a command the interactive app is started.\n\nFlags:")
+		flag.PrintDefaults()
+	}
 	flag.Parse()
 
-	logger = customLogger.NewFileLogger(*logLevel)
+	if *dataDir == "" {
+		*dataDir = ezex.DataDir()
+	}
+	if err := os.MkdirAll(*dataDir, 0700); err != nil {
+		log.Fatalf("Error creating the data directory: %s", err)
+	}
+
+	// Flags take precedence over env vars and the config file
+	c, err := loadConfig(*dataDir)
+	if err == nil {
+		flag.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "db":
+				c.DB = *dbPath
+			case "log-level":
+				c.LogLevel = *logLevel
+			}
+		})
+		err = c.validate()
+	}
+
+	if flag.Arg(0) == "config" {
+		// An invalid config can still be fixed with `config set`
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Invalid config: %s\n", err)
+		}
+		if err = runConfig(c, *dataDir, flag.Args()[1:]); err != nil {
+			log.Fatalf("Error managing the config: %s", err)
+		}
+		return
+	}
+	if err != nil {
+		log.Fatalf("Error loading the config: %s", err)
+	}
+	c.apply()
+
+	logger = customLogger.NewFileLogger(c.LogLevel, *dataDir)
 	defer func(logger customLogger.Logger) {
 		_ = logger.Close()
 	}(logger)
 
-	var opts []ezex.OptionsBuilder
-	if *dbName != "" {
-		opts = append(opts, ezex.WithDBName(*dbName))
+	if flag.Arg(0) == "profile" {
+		if err = runProfile(c, *dataDir, flag.Args()[1:]); err != nil {
+			log.Fatalf("Error managing the profiles: %s", err)
+		}
+		return
 	}
 
-	db, err := ezex.OpenDB(opts...)
-	if err != nil {
-		log.Fatalf("Error opening the DB: %s", err)
+	profiles := c.profiles()
+	current, ok := findProfile(profiles, defaultProfileName)
+	if *profileName != "" && *dbPath != "" {
+		log.Fatalf("-profile and -db can't be used together")
 	}
-	defer func(db *sql.DB) {
+	if *profileName != "" {
+		if current, ok = findProfile(profiles, *profileName); !ok {
+			log.Fatalf("Unknown profile %s (see `profile list`)", *profileName)
+		}
+	}
+
+	// The profile picker opens the DB when no profile (or DB) is chosen and there are several
+	var db *sql.DB
+	pickProfile := flag.Arg(0) == "" && *profileName == "" && *dbPath == "" && len(profiles) > 1
+	if !pickProfile {
+		if db, err = openProfile(*dataDir, current); err != nil {
+			log.Fatalf("Error opening the DB: %s", err)
+		}
+	}
+	defer func() {
+		if db == nil {
+			return
+		}
 		if err = db.Close(); err != nil {
 			log.Fatalf("Error closing the DB: %s", err)
 		}
-	}(db)
+	}()
 
-	if err = ezex.MigrateDB(db); err != nil {
-		log.Fatalf("Error migrating the DB: %s", err)
+	backupsDir := filepath.Join(*dataDir, ezex.BackupsDirName)
+	backupName := current.backupName(*dataDir)
+	attachmentsDir := current.attachmentsDir(*dataDir)
+
+	switch flag.Arg(0) {
+	case "":
+	case "export":
+		if err = runExport(db, flag.Args()[1:]); err != nil {
+			log.Fatalf("Error exporting data: %s", err)
+		}
+		return
+	case "report":
+		if err = runReport(db, c, flag.Args()[1:]); err != nil {
+			log.Fatalf("Error creating the report: %s", err)
+		}
+		return
+	case "attachment":
+		if err = runAttachment(db, attachmentsDir, flag.Args()[1:]); err != nil {
+			log.Fatalf("Error managing attachments: %s", err)
+		}
+		return
+	case "rules":
+		if err = runRules(db, flag.Args()[1:]); err != nil {
+			log.Fatalf("Error applying rules: %s", err)
+		}
+		return
+	case "backup":
+		if err = runBackup(db, c, backupsDir, backupName, flag.Args()[1:]); err != nil {
+			log.Fatalf("Error backing up the DB: %s", err)
+		}
+		return
+	case "restore":
+		if err = runRestore(db, backupsDir, backupName, flag.Args()[1:]); err != nil {
+			log.Fatalf("Error restoring the DB: %s", err)
+		}
+		return
+	case "decrypt":
+		if err = runDecrypt(flag.Args()[1:]); err != nil {
+			log.Fatalf("Error decrypting: %s", err)
+		}
+		return
+	default:
+		flag.Usage()
+		os.Exit(2)
 	}
 
-	p := tea.NewProgram(initialModel(db))
-	if _, err := p.Run(); err != nil {
+	p := tea.NewProgram(initialModel(db, *dataDir, profiles, current.name, c.KeyBindings))
+	final, err := p.Run()
+	if err != nil {
 		fmt.Printf("error running program: %v", err)
 		os.Exit(1)
 	}
+	// Switching profile replaces the DB
+	db = final.(model).db
 }
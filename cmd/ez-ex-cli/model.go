@@ -1,35 +1,80 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"github.com/armanimichael/ez-ex/cmd/ez-ex-cli/command"
 	tea "github.com/charmbracelet/bubbletea"
+	"time"
 )
 
 const (
-	accountModelID = iota
+	dashboardModelID = iota
+	accountModelID
 	transactionModelID
+	categoryModelID
+	ruleModelID
+	duplicateModelID
+	profileModelID
 )
 
 type model struct {
 	db             *sql.DB
+	dataDir        string
+	attachmentsDir string
+	profiles       []profile
+	// profile is the name of the open profile, empty until one is picked
+	profile        string
 	currentModelID int
 	accountID      int
 	currentModel   tea.Model
+	// transactionFilter is the last transactions filter applied, restored when switching account
+	transactionFilter []string
+	// keyBindings maps a key to the app key it acts as (see config.KeyBindings)
+	keyBindings map[string]string
+	// ctx is canceled when leaving the current screen, stopping the loads it started
+	ctx    context.Context
+	cancel context.CancelFunc
+	// size is the last known terminal size, given to every new screen
+	size tea.WindowSizeMsg
+	// undo keeps the operations of the session, across screens (see undoStack)
+	undo undoStack
+	// notice is a transient message shown below every screen, such as the last undoable operation
+	notice struct {
+		id    int64
+		msg   string
+		isErr bool
+	}
 }
 
-func initialModel(db *sql.DB) model {
-	return model{
-		db:             db,
-		currentModelID: accountModelID,
-		currentModel:   initAccountModel(db),
+// initialModel starts on the dashboard of the current profile, or on the profile picker when db is nil
+func initialModel(db *sql.DB, dataDir string, profiles []profile, current string, keyBindings map[string]string) model {
+	m := model{
+		db:          db,
+		dataDir:     dataDir,
+		profiles:    profiles,
+		keyBindings: keyBindings,
+	}
+	m.resetContext()
+
+	if db == nil {
+		m.currentModelID = profileModelID
+		m.currentModel = initProfileModel(profiles, "", dataDir)
+	} else {
+		m.profile = current
+		p, _ := findProfile(profiles, current)
+		m.attachmentsDir = p.attachmentsDir(dataDir)
+		m.currentModelID = dashboardModelID
+		m.currentModel = initDashboardModel(m.ctx, db)
 	}
+
+	return m
 }
 
 func (m model) Init() tea.Cmd {
 	switch m.currentModelID {
-	case accountModelID:
+	case dashboardModelID, accountModelID:
 		return m.currentModel.Init()
 	}
 
@@ -39,29 +84,95 @@ func (m model) Init() tea.Cmd {
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		msg = m.bindKey(keyMsg)
+	}
+
 	switch msg := msg.(type) {
 	case command.SwitchModelMsg:
-		if m.currentModelID != msg.ModelID {
-			logger.Debug(fmt.Sprintf("Switch to transaction model ID %v", msg.ModelID))
+		// Switching to the same model is allowed when it shows a different account
+		accountChanged := msg.AccountID != 0 && msg.AccountID != m.accountID
+		if m.currentModelID != msg.ModelID || accountChanged {
+			logger.Debug(fmt.Sprintf("Switch to model ID %v", msg.ModelID))
 
 			m.currentModelID = msg.ModelID
+			m.resetContext()
 			if msg.AccountID != 0 {
 				m.accountID = msg.AccountID
 			}
 
 			switch msg.ModelID {
+			case dashboardModelID:
+				m.currentModel = initDashboardModel(m.ctx, m.db)
 			case accountModelID:
-				m.currentModel = initAccountModel(m.db)
+				m.currentModel = initAccountModel(m.ctx, m.db)
+			case categoryModelID:
+				m.currentModel = initCategoryModel(m.db)
+			case ruleModelID:
+				m.currentModel = initRuleModel(m.db)
+			case duplicateModelID:
+				m.currentModel = initDuplicateModel(m.db)
+			case profileModelID:
+				m.currentModel = initProfileModel(m.profiles, m.profile, m.dataDir)
 			case transactionModelID:
 				var err error
-				m.currentModel, err = initTransactionModel(m.db, m.accountID)
+				m.currentModel, err = initTransactionModel(m.ctx, m.db, m.attachmentsDir, m.accountID, m.transactionFilter)
 				if err != nil {
 					return m, tea.Quit
 				}
 			}
 
+			m, cmd = m.resizeCurrentModel()
+			// The dashboard loads its data in the background
+			if msg.ModelID == dashboardModelID {
+				cmd = tea.Batch(m.currentModel.Init(), cmd)
+			}
+
 			return m, cmd
 		}
+	case tea.WindowSizeMsg:
+		m.size = msg
+	case command.TransactionFilterMsg:
+		m.transactionFilter = msg.Values
+	case command.OperationDoneMsg:
+		m.undo = m.undo.push(msg.Operation)
+		return m, m.showNotice(fmt.Sprintf("%s — press u to undo", msg.Operation), false)
+	case command.RequestUndoMsg:
+		var operation command.UndoableOperation
+		var ok bool
+		if m.undo, operation, ok = m.undo.pop(msg.Redo); !ok {
+			if msg.Redo {
+				return m, m.showNotice("nothing to redo", true)
+			}
+			return m, m.showNotice("nothing to undo", true)
+		}
+
+		logger.Debug(fmt.Sprintf("Undo (redo: %v) %v", msg.Redo, operation))
+		return m, command.UndoCmd(m.db, operation, msg.Redo)
+	case command.UndoMsg:
+		m.undo = m.undo.finish(msg.Operation, msg.Redo, msg.Err != nil)
+		var noticeCmd tea.Cmd
+		switch {
+		case msg.Err != nil:
+			logger.Err(fmt.Sprintf("Error undoing (redo: %v) %v: %v", msg.Redo, msg.Operation, msg.Err))
+			noticeCmd = m.showNotice(formatError(msg.Err), true)
+		case msg.Redo:
+			noticeCmd = m.showNotice(fmt.Sprintf("Redone: %s — press u to undo", msg.Operation), false)
+		default:
+			noticeCmd = m.showNotice(fmt.Sprintf("Undone: %s — press ctrl+r to redo", msg.Operation), false)
+		}
+
+		// The current screen reloads what changed
+		m.currentModel, cmd = m.currentModel.Update(msg)
+		return m, tea.Batch(noticeCmd, cmd)
+	case command.HideErrorMessageMsg:
+		if msg.ID == m.notice.id && msg.Message == m.notice.msg {
+			m.notice.msg = ""
+		}
+	case command.SwitchProfileMsg:
+		if msg.Err == nil {
+			return m.switchProfile(msg.Name)
+		}
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "ctrl+c":
@@ -74,5 +185,88 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m model) View() string {
-	return m.currentModel.View()
+	switch {
+	case m.notice.msg == "":
+		return m.currentModel.View()
+	case m.notice.isErr:
+		return m.currentModel.View() + errorMessageStyle.Render("Error: "+m.notice.msg) + "\n"
+	}
+
+	return m.currentModel.View() + successMessageStyle.Render(m.notice.msg) + "\n"
+}
+
+// showNotice shows msg below the current screen until HideErrorMessageCmd hides it
+func (m *model) showNotice(msg string, isErr bool) tea.Cmd {
+	m.notice.msg = msg
+	m.notice.isErr = isErr
+	m.notice.id = time.Now().UnixMicro()
+
+	return command.HideErrorMessageCmd(m.notice.id, m.notice.msg)
+}
+
+// bindKey returns the key msg acts as according to the configured key bindings
+func (m model) bindKey(msg tea.KeyMsg) tea.KeyMsg {
+	target, ok := m.keyBindings[msg.String()]
+	if !ok {
+		return msg
+	}
+
+	bound, _ := keyMsg(target)
+	return bound
+}
+
+// switchProfile opens the DB of a profile then closes the current one, errors are shown by the profile picker
+func (m model) switchProfile(name string) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	if name == m.profile {
+		return m, command.SwitchModelCmd(dashboardModelID, 0)
+	}
+
+	p, _ := findProfile(m.profiles, name)
+	db, err := openProfile(m.dataDir, p)
+	if err != nil {
+		m.currentModel, cmd = m.currentModel.Update(command.SwitchProfileMsg{Name: name, Err: err})
+		return m, cmd
+	}
+
+	if m.db != nil {
+		if err = m.db.Close(); err != nil {
+			logger.Err(fmt.Sprintf("Error closing profile %s: %v", m.profile, err))
+		}
+	}
+	logger.Debug(fmt.Sprintf("Switch to profile %s", name))
+
+	m.db = db
+	m.profile = name
+	m.attachmentsDir = p.attachmentsDir(m.dataDir)
+	m.accountID = 0
+	m.transactionFilter = nil
+	// Operations can only be undone in the profile they were made in
+	m.undo = undoStack{}
+	m.currentModelID = dashboardModelID
+	m.resetContext()
+	m.currentModel = initDashboardModel(m.ctx, db)
+	m, cmd = m.resizeCurrentModel()
+
+	return m, tea.Batch(m.currentModel.Init(), cmd)
+}
+
+// resizeCurrentModel fits a new screen in the terminal, once its size is known
+func (m model) resizeCurrentModel() (model, tea.Cmd) {
+	if m.size.Width == 0 {
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.currentModel, cmd = m.currentModel.Update(m.size)
+
+	return m, cmd
+}
+
+// resetContext cancels the loads of the current screen, giving the next one a new context
+func (m *model) resetContext() {
+	if m.cancel != nil {
+		m.cancel()
+	}
+	m.ctx, m.cancel = context.WithCancel(context.Background())
 }
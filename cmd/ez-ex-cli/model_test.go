@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	ezex "github.com/armanimichael/ez-ex"
+	"github.com/armanimichael/ez-ex/cmd/ez-ex-cli/command"
+	customLogger "github.com/armanimichael/ez-ex/cmd/ez-ex-cli/logger"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestModel_SwitchModelCancelsLoads(t *testing.T) {
+	logger = customLogger.NewFileLogger(6, "")
+	db, err := ezex.OpenDB(ezex.WithInMemory())
+	assert.Nil(t, err)
+	defer db.Close()
+	assert.Nil(t, ezex.MigrateDB(db))
+
+	m := initialModel(db, t.TempDir(), []profile{{name: defaultProfileName}}, defaultProfileName, nil)
+	ctx := m.ctx
+	load := command.SwitchTransactionsMonthCmd(ctx, db, ezex.TransactionFilter{}, 2110, time.January)
+
+	updated, _ := m.Update(command.SwitchModelMsg{ModelID: accountModelID})
+	m = updated.(model)
+	assert.ErrorIs(t, ctx.Err(), context.Canceled)
+	assert.Nil(t, m.ctx.Err())
+	// Loads finishing after the screen is left don't reach the next one
+	assert.Nil(t, load())
+}
+
+func TestModel_LoadDashboard(t *testing.T) {
+	logger = customLogger.NewFileLogger(6, "")
+	db, err := ezex.OpenDB(ezex.WithInMemory())
+	assert.Nil(t, err)
+	defer db.Close()
+	assert.Nil(t, ezex.MigrateDB(db))
+	accountID, _ := ezex.AddAccount(db, ezex.Account{Name: "Checking"})
+	payeeID, _ := ezex.AddPayee(db, ezex.Payee{Name: "Grocer"})
+	_, err = ezex.AddTransaction(db, ezex.Transaction{
+		AccountID:           accountID,
+		PayeeID:             payeeID,
+		AmountInCents:       -500,
+		TransactionDateUnix: time.Now().Add(-time.Minute).Unix(),
+	})
+	assert.Nil(t, err)
+
+	m := initialModel(db, t.TempDir(), []profile{{name: defaultProfileName}}, defaultProfileName, nil)
+	msgs := runCmd(m.Init())
+	assert.Len(t, msgs, 1)
+	for _, msg := range msgs {
+		updated, _ := m.Update(msg)
+		m = updated.(model)
+	}
+	dashboard := m.currentModel.(dashboardModel)
+	assert.Len(t, dashboard.recentTransactions, 1)
+	assert.Equal(t, int64(-500), dashboard.cashFlow.ExpenseInCents)
+
+	// The dashboard isn't loaded once left
+	load := m.currentModel.Init()
+	_, _ = m.Update(command.SwitchModelMsg{ModelID: accountModelID})
+	assert.Nil(t, load())
+}
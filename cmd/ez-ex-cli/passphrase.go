@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+)
+
+// passphraseEnv provides the archives passphrase to scripts, instead of prompting for it
+const passphraseEnv = "EZEX_PASSPHRASE"
+
+// readPassphrase returns the passphrase of encrypted archives from EZEX_PASSPHRASE or prompts for it,
+// twice when confirm is set (e.g. when encrypting)
+func readPassphrase(confirm bool) (string, error) {
+	if passphrase := os.Getenv(passphraseEnv); passphrase != "" {
+		return passphrase, nil
+	}
+
+	if info, err := os.Stdin.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return "", fmt.Errorf("no terminal to ask for the passphrase, set %s", passphraseEnv)
+	}
+
+	// The typed passphrase is hidden where stty is available (not on Windows)
+	if err := stty("-echo"); err == nil {
+		defer restoreEchoOnSignal()()
+	} else {
+		_, _ = fmt.Fprintln(os.Stderr, "Warning: the passphrase will be shown while typing")
+	}
+
+	stdin := bufio.NewReader(os.Stdin)
+	passphrase, err := promptLine(stdin, "Passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if passphrase == "" {
+		return "", errors.New("empty passphrase")
+	}
+
+	if confirm {
+		confirmation, err := promptLine(stdin, "Confirm passphrase: ")
+		if err != nil {
+			return "", err
+		}
+		if confirmation != passphrase {
+			return "", errors.New("the passphrases don't match")
+		}
+	}
+
+	return passphrase, nil
+}
+
+// restoreEchoOnSignal restores the terminal echo and exits if the prompt is interrupted, since deferred calls don't run
+// then. The returned function stops watching the signals and restores the echo
+func restoreEchoOnSignal() func() {
+	signals := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-signals:
+			_ = stty("echo")
+			_, _ = fmt.Fprintln(os.Stderr)
+			os.Exit(1)
+		case <-done:
+		}
+	}()
+
+	return func() {
+		signal.Stop(signals)
+		close(done)
+		_ = stty("echo")
+	}
+}
+
+// promptLine writes prompt to stderr and reads a line from r, without the line ending
+func promptLine(r *bufio.Reader, prompt string) (string, error) {
+	_, _ = fmt.Fprint(os.Stderr, prompt)
+	line, err := r.ReadString('\n')
+	_, _ = fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// stty changes the settings of the terminal attached to stdin
+func stty(args ...string) error {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+
+	return cmd.Run()
+}
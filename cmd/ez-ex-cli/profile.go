@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"github.com/armanimichael/ez-ex/cmd/ez-ex-cli/command"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"strings"
+	"time"
+)
+
+// profileModel picks the profile to open, at startup or when switching from the account screen
+type profileModel struct {
+	profiles []profile
+	// current is the profile currently open, empty at startup
+	current string
+	err     struct {
+		id  int64
+		msg string
+	}
+	size  tea.WindowSizeMsg
+	table struct {
+		model table.Model
+	}
+}
+
+var profileTableColumns = []tableColumn{
+	flexColumn("Profile", 20),
+	flexColumn("DB", 60),
+}
+
+var profileTableKeySuggestions = formatKeySuggestions([][]string{
+	{"^C", "quit"},
+	{"{esc}", "accounts"},
+	{"{enter}", "open profile"},
+})
+
+var startupProfileTableKeySuggestions = formatKeySuggestions([][]string{
+	{"^C", "quit"},
+	{"{enter}", "open profile"},
+})
+
+func initProfileModel(profiles []profile, current string, dataDir string) (m profileModel) {
+	m.profiles = profiles
+	m.current = current
+
+	var rows []table.Row
+	for _, p := range profiles {
+		name := p.name
+		if name == current {
+			name = "* " + name
+		}
+
+		dbPath := p.dbPath(dataDir)
+		if dbPath == "" {
+			dbPath = p.db
+		}
+		rows = append(rows, table.Row{name, dbPath})
+	}
+	m.table.model = createStandardTable(profileTableColumns, rows)
+
+	return m
+}
+
+func (m profileModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m profileModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.size = msg
+		m.table.model = fitTable(m.table.model, profileTableColumns, m.View(), m.size)
+
+		return m, nil
+	case command.HideErrorMessageMsg:
+		if msg.Message == m.err.msg && msg.ID == m.err.id {
+			m.err.msg = ""
+		}
+	case command.SwitchProfileMsg:
+		if msg.Err != nil {
+			logger.Err(fmt.Sprintf("Error opening profile %s: %v", msg.Name, msg.Err))
+			m.err.msg = formatError(msg.Err)
+			m.err.id = time.Now().UnixMicro()
+
+			return m, command.HideErrorMessageCmd(m.err.id, m.err.msg)
+		}
+	}
+
+	var cmd tea.Cmd
+	m.table.model, cmd = m.table.model.Update(msg)
+
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "esc":
+			if m.current != "" {
+				logger.Debug("Go back to accounts")
+				return m, command.SwitchModelCmd(accountModelID, 0)
+			}
+		case "enter":
+			if len(m.profiles) > 0 {
+				name := m.profiles[m.table.model.Cursor()].name
+				logger.Debug(fmt.Sprintf("Open profile %s", name))
+				return m, command.SwitchProfileCmd(name)
+			}
+		case "up", "down":
+			m.err.msg = ""
+		}
+	}
+
+	return m, cmd
+}
+
+func (m profileModel) View() string {
+	keySuggestions := profileTableKeySuggestions
+	if m.current == "" {
+		keySuggestions = startupProfileTableKeySuggestions
+	}
+
+	str := strings.Builder{}
+	str.WriteString(baseStyle.Render(m.table.model.View()) + "\n" + keySuggestions + "\n")
+	if m.err.msg != "" {
+		str.WriteString(errorMessageStyle.Render("Error: "+m.err.msg) + "\n")
+	}
+
+	return str.String()
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	ezex "github.com/armanimichael/ez-ex"
+	"github.com/armanimichael/ez-ex/cmd/ez-ex-cli/command"
+	customLogger "github.com/armanimichael/ez-ex/cmd/ez-ex-cli/logger"
+	"github.com/stretchr/testify/assert"
+	"path"
+	"testing"
+)
+
+func TestRunProfile(t *testing.T) {
+	dir := t.TempDir()
+	run := func(args ...string) error {
+		c, err := readConfigFile(dir)
+		assert.Nil(t, err)
+		return runProfile(c, dir, args)
+	}
+
+	assert.Nil(t, run("create", "side"))
+	assert.Nil(t, run("create", "house", path.Join(dir, "house.db")))
+	assert.Error(t, run("create", "side"))
+	assert.Error(t, run("create", "default"))
+	assert.Error(t, run("create", "bad name"))
+	assert.Error(t, run("create", "copy", path.Join(dir, "house.db")))
+
+	assert.Nil(t, run("rename", "side", "business"))
+	assert.Error(t, run("rename", "default", "main"))
+	assert.Error(t, run("rename", "missing", "other"))
+	assert.Error(t, run("rename", "business", "house"))
+
+	c, _ := readConfigFile(dir)
+	profiles := c.profiles()
+	assert.Equal(t, []profile{
+		{name: defaultProfileName},
+		{name: "business", db: path.Join(dir, "side.db")},
+		{name: "house", db: path.Join(dir, "house.db")},
+	}, profiles)
+	assert.Equal(t, path.Join(dir, ezex.DefaultDBName), profiles[0].dbPath(dir))
+	assert.Equal(t, "side", profiles[1].backupName(dir))
+	// Attachments are removed once unused by a DB, so DBs don't share them
+	assert.Equal(t, path.Join(dir, ezex.AttachmentsDirName), profiles[0].attachmentsDir(dir))
+	assert.Equal(t, path.Join(dir, ezex.AttachmentsDirName, "side"), profiles[1].attachmentsDir(dir))
+	assert.Equal(t, path.Join(dir, ezex.AttachmentsDirName, "memory-temp"), profile{name: "temp", db: ":memory:"}.attachmentsDir(dir))
+
+	assert.Nil(t, run("delete", "business"))
+	assert.Error(t, run("delete", "default"))
+	c, _ = readConfigFile(dir)
+	assert.Len(t, c.profiles(), 2)
+}
+
+func TestModel_SwitchProfile(t *testing.T) {
+	logger = customLogger.NewFileLogger(6, "")
+	dir := t.TempDir()
+	profiles := []profile{{name: defaultProfileName}, {name: "side", db: path.Join(dir, "side.db")}}
+
+	m := initialModel(nil, dir, profiles, "", nil)
+	assert.Equal(t, profileModelID, m.currentModelID)
+
+	updated, _ := m.Update(command.SwitchProfileMsg{Name: "side"})
+	m = updated.(model)
+	assert.Equal(t, dashboardModelID, m.currentModelID)
+	assert.Equal(t, "side", m.profile)
+	_, err := ezex.AddAccount(m.db, ezex.Account{Name: "Side business"})
+	assert.Nil(t, err)
+	side := m.db
+
+	updated, _ = m.Update(command.SwitchProfileMsg{Name: defaultProfileName})
+	m = updated.(model)
+	assert.Equal(t, defaultProfileName, m.profile)
+	assert.Len(t, ezex.GetAccounts(m.db), 0)
+	// The previous DB is closed
+	assert.Error(t, side.Ping())
+	assert.Nil(t, m.db.Close())
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	ezex "github.com/armanimichael/ez-ex"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// defaultProfileName is the profile of the configured DB, it always exists
+const defaultProfileName = "default"
+
+const profileUsage = `usage:
+  profile list
+  profile create <name> [file]
+  profile rename <name> <new name>
+  profile delete <name>`
+
+var profileNameRegex = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// profile is a named ledger, each one has its own DB and backups
+type profile struct {
+	name string
+	// db is the DB file, empty for the default DB inside the data directory, `:memory:` for a temporary DB
+	db string
+}
+
+// profiles returns the default profile followed by the configured ones sorted by name
+func (c config) profiles() []profile {
+	profiles := []profile{{name: defaultProfileName, db: c.DB}}
+
+	var names []string
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		profiles = append(profiles, profile{name: name, db: c.Profiles[name]})
+	}
+
+	return profiles
+}
+
+func findProfile(profiles []profile, name string) (profile, bool) {
+	for _, p := range profiles {
+		if p.name == name {
+			return p, true
+		}
+	}
+
+	return profile{}, false
+}
+
+func (p profile) openOptions(dataDir string) []ezex.OptionsBuilder {
+	opts := []ezex.OptionsBuilder{ezex.WithDataDir(dataDir)}
+	switch p.db {
+	case "":
+	case ":memory:":
+		opts = append(opts, ezex.WithInMemory())
+	default:
+		opts = append(opts, ezex.WithDBPath(p.db))
+	}
+
+	return opts
+}
+
+// dbPath returns the DB file of the profile, empty for a temporary DB
+func (p profile) dbPath(dataDir string) string {
+	return ezex.DBPath(p.openOptions(dataDir)...)
+}
+
+// backupName returns the name of the profile backups, named after the DB so that each DB keeps its own
+func (p profile) backupName(dataDir string) string {
+	dbFileName := filepath.Base(p.dbPath(dataDir))
+	return strings.TrimSuffix(dbFileName, filepath.Ext(dbFileName))
+}
+
+// attachmentsDir returns the directory of the profile attachments. Files are shared by the transactions of a DB
+// and removed once none refers to them (see ezex.DetachFile), so each DB has its own directory. The default profile
+// keeps the attachments directory, the others use a subdirectory named after their DB
+func (p profile) attachmentsDir(dataDir string) string {
+	dir := filepath.Join(dataDir, ezex.AttachmentsDirName)
+	switch {
+	case p.name == defaultProfileName:
+		return dir
+	case p.dbPath(dataDir) == "":
+		// Temporary DBs have no file to be named after
+		return filepath.Join(dir, "memory-"+p.name)
+	}
+
+	return filepath.Join(dir, p.backupName(dataDir))
+}
+
+// openProfile opens and migrates the profile DB, backing it up before a migration
+func openProfile(dataDir string, p profile) (*sql.DB, error) {
+	db, err := ezex.OpenDB(p.openOptions(dataDir)...)
+	if err != nil {
+		return nil, err
+	}
+
+	var migrateOpts []ezex.MigrateOptionsBuilder
+	if p.db != ":memory:" {
+		migrateOpts = append(migrateOpts, ezex.WithMigrationBackup(filepath.Join(dataDir, ezex.BackupsDirName), p.backupName(dataDir)))
+	}
+	if err = ezex.MigrateDB(db, migrateOpts...); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("migrating %s: %w", p.name, err)
+	}
+
+	return db, nil
+}
+
+func validateProfileName(name string) error {
+	if !profileNameRegex.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q, use only letters, digits, - and _", name)
+	}
+
+	return nil
+}
+
+// runProfile handles `ez-ex profile`, changing the profiles of the config file. Deleting a profile keeps its DB file
+func runProfile(c config, dataDir string, args []string) error {
+	if len(args) == 0 {
+		return errors.New(profileUsage)
+	}
+
+	if args[0] == "list" {
+		if len(args) != 1 {
+			return errors.New(profileUsage)
+		}
+
+		for _, p := range c.profiles() {
+			dbPath := p.dbPath(dataDir)
+			if dbPath == "" {
+				dbPath = p.db
+			}
+			fmt.Printf("%s\t%s\n", p.name, dbPath)
+		}
+
+		return nil
+	}
+
+	fileConfig, err := readConfigFile(dataDir)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "create":
+		if len(args) != 2 && len(args) != 3 {
+			return errors.New(profileUsage)
+		}
+
+		name := args[1]
+		if err = validateNewProfileName(fileConfig, name); err != nil {
+			return err
+		}
+
+		dbPath := filepath.Join(dataDir, name+".db")
+		if len(args) == 3 {
+			if dbPath, err = filepath.Abs(args[2]); err != nil {
+				return err
+			}
+		}
+		for _, p := range c.profiles() {
+			if p.dbPath(dataDir) == dbPath {
+				return fmt.Errorf("%s is already the DB of profile %s", dbPath, p.name)
+			}
+		}
+
+		fileConfig.Profiles[name] = dbPath
+		fmt.Printf("Created profile %s (%s)\n", name, dbPath)
+	case "rename":
+		if len(args) != 3 {
+			return errors.New(profileUsage)
+		}
+
+		name, newName := args[1], args[2]
+		if name == defaultProfileName {
+			return errors.New("the default profile can't be renamed, its DB is set by the config db")
+		}
+		dbPath, ok := fileConfig.Profiles[name]
+		if !ok {
+			return fmt.Errorf("unknown profile %s", name)
+		}
+		if err = validateNewProfileName(fileConfig, newName); err != nil {
+			return err
+		}
+
+		delete(fileConfig.Profiles, name)
+		fileConfig.Profiles[newName] = dbPath
+	case "delete":
+		if len(args) != 2 {
+			return errors.New(profileUsage)
+		}
+
+		if args[1] == defaultProfileName {
+			return errors.New("the default profile can't be deleted")
+		}
+		dbPath, ok := fileConfig.Profiles[args[1]]
+		if !ok {
+			return fmt.Errorf("unknown profile %s", args[1])
+		}
+
+		delete(fileConfig.Profiles, args[1])
+		if _, err = os.Stat(dbPath); err == nil {
+			fmt.Printf("DB file kept: %s\n", dbPath)
+		}
+	default:
+		return errors.New(profileUsage)
+	}
+
+	return fileConfig.write(dataDir)
+}
+
+func validateNewProfileName(c config, name string) error {
+	if err := validateProfileName(name); err != nil {
+		return err
+	}
+	if _, ok := c.Profiles[name]; ok || name == defaultProfileName {
+		return fmt.Errorf("profile %s already exists", name)
+	}
+
+	return nil
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	ezex "github.com/armanimichael/ez-ex"
+	"github.com/armanimichael/ez-ex/cmd/ez-ex-cli/command"
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// reconcileModel is the wizard matching an account against a bank statement: once the statement end date and
+// balance are set, transactions are ticked until the difference is zero, then they're locked as reconciled
+type reconcileModel struct {
+	ctx       context.Context
+	db        *sql.DB
+	accountID int
+	// reconciledInCents is the account balance before this reconciliation (see ezex.AccountBalance)
+	reconciledInCents int64
+	stage             int
+	inputs            []standardTextInput
+	transactions      []ezex.TransactionView
+	ticked            map[int]bool
+	table             table.Model
+}
+
+const (
+	reconcileEndDateStage = iota
+	reconcileStatementBalanceStage
+	reconcileSelectionStage
+)
+
+var reconcileTableColumns = []tableColumn{
+	fixedColumn("", 3),
+	fixedColumn("ID", 5),
+	fixedColumn("Date", 10),
+	fixedColumn("Amount", 10),
+	flexColumn("Payee", 20),
+	flexColumn("Notes", 30),
+}
+
+var reconcileInputKeySuggestions = formatKeySuggestions([][]string{
+	{"{enter}", "select transactions"},
+	{"{esc}", "cancel"},
+	{"{up}/{down}", "switch field"},
+})
+
+var reconcileSelectionKeySuggestions = formatKeySuggestions([][]string{
+	{"{space}", "tick transaction"},
+	{"{enter}", "reconcile (difference must be zero)"},
+	{"{esc}", "cancel"},
+})
+
+func initReconcile(ctx context.Context, db *sql.DB, accountID int, balance ezex.AccountBalance) reconcileModel {
+	m := reconcileModel{
+		ctx:               ctx,
+		db:                db,
+		accountID:         accountID,
+		reconciledInCents: balance.ReconciledInCents,
+		stage:             reconcileEndDateStage,
+		inputs:            []standardTextInput{createReconcileInput(reconcileEndDateStage), createReconcileInput(reconcileStatementBalanceStage)},
+		ticked:            map[int]bool{},
+	}
+	m.inputs[m.stage].model.Focus()
+	for i := range m.inputs {
+		m.inputs[i].errorMsg = m.validateInput(i)
+	}
+	m.table = createStandardTable(reconcileTableColumns, nil)
+
+	return m
+}
+
+func (m reconcileModel) Update(msg tea.Msg) (reconcileModel, tea.Cmd) {
+	var cmd tea.Cmd
+
+	if msg, ok := msg.(command.ReconcileCandidatesMsg); ok {
+		m.transactions = msg.Transactions
+		m.ticked = map[int]bool{}
+		for _, transaction := range m.transactions {
+			// The bank already confirmed cleared transactions, they're likely on the statement
+			m.ticked[transaction.ID] = transaction.Status == ezex.StatusCleared
+		}
+		m.table.SetRows(m.rows())
+		m.table.SetCursor(0)
+
+		return m, nil
+	}
+
+	if m.stage == reconcileSelectionStage {
+		m.table, cmd = m.table.Update(msg)
+
+		if msg, ok := msg.(tea.KeyMsg); ok {
+			switch msg.String() {
+			case " ":
+				if len(m.transactions) == 0 {
+					break
+				}
+
+				id := m.transactions[m.table.Cursor()].ID
+				m.ticked[id] = !m.ticked[id]
+				m.table.SetRows(m.rows())
+			case "enter":
+				if m.differenceInCents() != 0 {
+					break
+				}
+
+				logger.Debug(fmt.Sprintf("Reconcile %d transactions of account ID %d", len(m.tickedIDs()), m.accountID))
+				return m, command.ReconcileCmd(m.db, m.accountID, m.tickedIDs())
+			}
+		}
+
+		return m, cmd
+	}
+
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "enter":
+			if !m.isValid() {
+				break
+			}
+
+			m.inputs[m.stage].model.Blur()
+			m.stage = reconcileSelectionStage
+			return m, command.ReconcileCandidatesCmd(m.ctx, m.db, m.accountID, m.endDate())
+		case "up", "down":
+			m.inputs[m.stage].model.Blur()
+			m.stage = handleSwitchInputStage(msg.String() == "up", m.stage, reconcileEndDateStage, reconcileStatementBalanceStage)
+			m.inputs[m.stage].model.Focus()
+
+			return m, textinput.Blink
+		}
+	}
+
+	currentInput := &m.inputs[m.stage]
+	currentInput.model, cmd = currentInput.model.Update(msg)
+	for i := range m.inputs {
+		m.inputs[i].errorMsg = m.validateInput(i)
+		m.inputs[i].previousInput = m.inputs[i].model.Value()
+	}
+
+	return m, cmd
+}
+
+func (m reconcileModel) View() string {
+	if m.stage != reconcileSelectionStage {
+		return standardTextInputView(m.stage, m.inputs, "") + "\n\n" + reconcileInputKeySuggestions
+	}
+
+	str := strings.Builder{}
+	str.WriteString(fmt.Sprintf("Statement:\t%s on %s\n", encodeCents(m.statementInCents(), false), encodeUnixDate(m.endDate().Unix())))
+	str.WriteString(fmt.Sprintf("Reconciled:\t%s\n", encodeCents(m.reconciledInCents, false)))
+	str.WriteString(fmt.Sprintf("Ticked:\t\t%s\n", encodeCents(m.tickedInCents(), false)))
+
+	difference := fmt.Sprintf("Difference:\t%s", encodeCents(m.differenceInCents(), false))
+	if m.differenceInCents() == 0 {
+		str.WriteString(successMessageStyle.Render(difference) + "\n")
+	} else {
+		str.WriteString(warningMessageStyle.Render(difference) + "\n")
+	}
+	str.WriteString(baseStyle.Render(m.table.View()) + "\n" + reconcileSelectionKeySuggestions)
+
+	return str.String()
+}
+
+// resize fits the transactions table in the terminal, measured as shown once the statement is set
+func (m reconcileModel) resize(size tea.WindowSizeMsg) reconcileModel {
+	selection := m
+	selection.stage = reconcileSelectionStage
+	// The inputs being typed may not be valid yet
+	selection.inputs = []standardTextInput{createReconcileInput(reconcileEndDateStage), createReconcileInput(reconcileStatementBalanceStage)}
+	m.table = fitTable(m.table, reconcileTableColumns, selection.View(), size)
+
+	return m
+}
+
+func (m reconcileModel) isValid() bool {
+	for i := range m.inputs {
+		if m.validateInput(i) != "" {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (m reconcileModel) validateInput(stage int) string {
+	value := strings.TrimSpace(m.inputs[stage].model.Value())
+
+	switch stage {
+	case reconcileEndDateStage:
+		if err := validateDateString(value); err != nil {
+			return err.Error()
+		}
+	case reconcileStatementBalanceStage:
+		if err := validateAmount(value); err != nil {
+			return err.Error()
+		}
+	}
+
+	return ""
+}
+
+// endDate is the last day of the statement, the inputs must be valid
+func (m reconcileModel) endDate() time.Time {
+	return time.Unix(decodeUnixDate(strings.TrimSpace(m.inputs[reconcileEndDateStage].model.Value())), 0)
+}
+
+// statementInCents is the statement end balance, the inputs must be valid
+func (m reconcileModel) statementInCents() int64 {
+	return decodeCents(strings.TrimSpace(m.inputs[reconcileStatementBalanceStage].model.Value()))
+}
+
+func (m reconcileModel) tickedInCents() int64 {
+	var total int64
+	for _, transaction := range m.transactions {
+		if m.ticked[transaction.ID] {
+			total += transaction.AmountInCents
+		}
+	}
+
+	return total
+}
+
+func (m reconcileModel) tickedIDs() []int {
+	var ids []int
+	for _, transaction := range m.transactions {
+		if m.ticked[transaction.ID] {
+			ids = append(ids, transaction.ID)
+		}
+	}
+
+	return ids
+}
+
+// differenceInCents is what's left to match the statement, zero once every statement transaction is ticked
+func (m reconcileModel) differenceInCents() int64 {
+	return m.statementInCents() - m.reconciledInCents - m.tickedInCents()
+}
+
+func (m reconcileModel) rows() []table.Row {
+	rows := make([]table.Row, len(m.transactions))
+	for i, transaction := range m.transactions {
+		tick := "[ ]"
+		if m.ticked[transaction.ID] {
+			tick = "[x]"
+		}
+
+		rows[i] = table.Row{
+			tick,
+			strconv.Itoa(transaction.ID),
+			encodeUnixDate(transaction.TransactionDateUnix),
+			encodeCents(transaction.AmountInCents, true),
+			transaction.PayeeName,
+			transaction.Notes.String,
+		}
+	}
+
+	return rows
+}
+
+func createReconcileInput(stage int) standardTextInput {
+	ti := textinput.New()
+	ti.Prompt = ""
+
+	switch stage {
+	case reconcileEndDateStage:
+		ti.Placeholder = "YYYY-MM-DD"
+		ti.SetValue(encodeUnixDate(time.Now().Unix()))
+		return standardTextInput{model: ti, label: "End date*"}
+	case reconcileStatementBalanceStage:
+		ti.Placeholder = "0.00"
+		return standardTextInput{model: ti, label: "Statement balance*"}
+	}
+
+	panic("unsupported reconcile stage")
+}
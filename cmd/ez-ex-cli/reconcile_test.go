@@ -0,0 +1,32 @@
+package main
+
+import (
+	"context"
+	ezex "github.com/armanimichael/ez-ex"
+	"github.com/armanimichael/ez-ex/cmd/ez-ex-cli/command"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestReconcileModel_Difference(t *testing.T) {
+	m := initReconcile(context.Background(), nil, 1, ezex.AccountBalance{ReconciledInCents: 10000})
+	assert.False(t, m.isValid())
+
+	m.inputs[reconcileStatementBalanceStage].model.SetValue("70.00")
+	assert.True(t, m.isValid())
+	m.stage = reconcileSelectionStage
+
+	m, _ = m.Update(command.ReconcileCandidatesMsg{Transactions: []ezex.TransactionView{
+		{ID: 1, AmountInCents: -2000, Status: ezex.StatusCleared},
+		{ID: 2, AmountInCents: -1000},
+		{ID: 3, AmountInCents: 500},
+	}})
+	assert.Equal(t, []int{1}, m.tickedIDs())
+	assert.Equal(t, int64(-1000), m.differenceInCents())
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+	assert.Equal(t, []int{1, 2}, m.tickedIDs())
+	assert.Equal(t, int64(0), m.differenceInCents())
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/armanimichael/ez-ex/report"
+	"io"
+	"os"
+	"time"
+)
+
+// runReport handles `ez-ex report html`, rendering the report of the given period to a file or stdout
+func runReport(db *sql.DB, c config, args []string) error {
+	if len(args) == 0 || args[0] != "html" {
+		return errors.New("usage: report html -from YYYY-MM-DD -to YYYY-MM-DD [-output file]")
+	}
+
+	now := time.Now()
+	flags := flag.NewFlagSet("report html", flag.ExitOnError)
+	from := flags.String("from", encodeUnixDate(c.dateRangeStart(now).Unix()), "First day of the report, YYYY-MM-DD (included)")
+	to := flags.String("to", encodeUnixDate(now.Unix()), "Last day of the report, YYYY-MM-DD (included)")
+	output := flags.String("output", "", "Output file (defaults to stdout)")
+	_ = flags.Parse(args[1:])
+
+	if err := validateDateString(*from); err != nil {
+		return fmt.Errorf("-from: %w", err)
+	}
+	if err := validateDateString(*to); err != nil {
+		return fmt.Errorf("-to: %w", err)
+	}
+
+	opts := report.Options{
+		MinDate: time.Unix(decodeUnixDate(*from), 0),
+		MaxDate: time.Unix(decodeUnixDate(*to), 0).AddDate(0, 0, 1),
+	}
+	if !opts.MinDate.Before(opts.MaxDate) {
+		return errors.New("-from must not be after -to")
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.OpenFile(*output, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+		if err != nil {
+			return err
+		}
+		defer func(file *os.File) {
+			_ = file.Close()
+		}(file)
+
+		w = file
+	}
+
+	return report.HTML(db, w, opts)
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	ezex "github.com/armanimichael/ez-ex"
+	"github.com/armanimichael/ez-ex/cmd/ez-ex-cli/command"
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"strings"
+	"time"
+)
+
+// ruleModel lists the rules in evaluation order, creating, reordering and deleting them
+type ruleModel struct {
+	db          *sql.DB
+	stage       int
+	rules       []ezex.Rule
+	accounts    []ezex.Account
+	categories  []ezex.Category
+	ruleCreator ruleCreatorModel
+	err         struct {
+		id  int64
+		msg string
+	}
+	size  tea.WindowSizeMsg
+	table struct {
+		model table.Model
+	}
+}
+
+const (
+	ruleSelectionStage = iota
+	ruleCreationStage
+)
+
+var ruleTableColumns = []tableColumn{
+	fixedColumn("#", 3),
+	flexColumn("Name", 20),
+	flexColumn("Conditions", 45),
+	flexColumn("Actions", 40),
+}
+
+var ruleTableKeySuggestions = formatKeySuggestions([][]string{
+	{"^C", "quit"},
+	{"{esc}", "dashboard"},
+	{"n", "create rule"},
+	{"K/J", "move rule up/down"},
+	{"d", "delete rule"},
+})
+
+func initRuleModel(db *sql.DB) (m ruleModel) {
+	m.db = db
+	m.rules = ezex.GetRules(db)
+	m.accounts = ezex.GetAccounts(db)
+	m.categories = ezex.GetCategoryTree(db)
+	m.ruleCreator = initRuleCreator(db, m.accounts, m.categories)
+	m.table.model = createStandardTable(ruleTableColumns, m.tableRows())
+
+	return m
+}
+
+func (m ruleModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m ruleModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.size = msg
+		// The table is measured along with the rest of the selection screen, even while creating a rule
+		selection := m
+		selection.stage = ruleSelectionStage
+		m.table.model = fitTable(m.table.model, ruleTableColumns, selection.View(), m.size)
+
+		return m, nil
+	case command.HideErrorMessageMsg:
+		if msg.Message == m.err.msg && msg.ID == m.err.id {
+			m.err.msg = ""
+		}
+	case command.UpdateRulesMsg:
+		m.stage = ruleSelectionStage
+		if msg.Err != nil {
+			logger.Err(fmt.Sprintf("Error updating rules: %v", msg.Err))
+			m.err.msg = formatError(msg.Err)
+			m.err.id = time.Now().UnixMicro()
+
+			return m, command.HideErrorMessageCmd(m.err.id, m.err.msg)
+		}
+
+		m.rules = msg.Rules
+		m.categories = msg.Categories
+		m.ruleCreator = m.ruleCreator.reset(m.accounts, m.categories)
+		m.table.model.SetRows(m.tableRows())
+		m.table.model.SetCursor(0)
+		for i, rule := range m.rules {
+			if rule.ID == msg.SelectedID {
+				m.table.model.SetCursor(i)
+				break
+			}
+		}
+
+		return m, nil
+	}
+
+	if m.stage == ruleCreationStage {
+		if msg, ok := msg.(tea.KeyMsg); ok && msg.String() == "esc" {
+			m.stage = ruleSelectionStage
+			return m, nil
+		}
+
+		var cmd tea.Cmd
+		m.ruleCreator, cmd = m.ruleCreator.Update(msg)
+
+		return m, cmd
+	}
+
+	return m.handleRuleSelectionCommands(msg)
+}
+
+func (m ruleModel) View() string {
+	if m.stage == ruleCreationStage {
+		return m.ruleCreator.View()
+	}
+
+	msg := ""
+	if m.err.msg != "" {
+		msg = errorMessageStyle.Render("Error: "+m.err.msg) + "\n"
+	}
+
+	return baseStyle.Render(m.table.model.View()) + "\n" + ruleTableKeySuggestions + "\n" + msg
+}
+
+func (m ruleModel) handleRuleSelectionCommands(msg tea.Msg) (ruleModel, tea.Cmd) {
+	var cmd tea.Cmd
+	m.table.model, cmd = m.table.model.Update(msg)
+
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "esc":
+			logger.Debug("Go back to dashboard")
+			return m, command.SwitchModelCmd(dashboardModelID, 0)
+		case "n":
+			m.stage = ruleCreationStage
+			m.ruleCreator = m.ruleCreator.reset(m.accounts, m.categories)
+			return m, textinput.Blink
+		case "d", "K", "J":
+			if len(m.rules) == 0 {
+				break
+			}
+
+			id := m.rules[m.table.model.Cursor()].ID
+			switch msg.String() {
+			case "d":
+				logger.Debug(fmt.Sprintf("Delete rule (ID: %v)", id))
+				return m, command.DeleteRuleCmd(m.db, id)
+			case "K":
+				return m, command.MoveRuleCmd(m.db, id, -1)
+			case "J":
+				return m, command.MoveRuleCmd(m.db, id, 1)
+			}
+		case "up", "down":
+			m.err.msg = ""
+		}
+	}
+
+	return m, cmd
+}
+
+func (m ruleModel) tableRows() []table.Row {
+	accountNames := make(map[int]string, len(m.accounts))
+	for _, account := range m.accounts {
+		accountNames[account.ID] = account.Name
+	}
+
+	return rulesToTableRows(m.rules, accountNames, categoryPathsByID(m.categories))
+}
+
+// ruleConditions describes the conditions of a rule, accountNames maps the account IDs to their names
+func ruleConditions(rule ezex.Rule, accountNames map[int]string) string {
+	var parts []string
+	if rule.PayeePattern != "" {
+		parts = append(parts, "payee ~ "+rule.PayeePattern)
+	}
+
+	switch minAmount, maxAmount := rule.MinAmountInCents, rule.MaxAmountInCents; {
+	case minAmount.Valid && maxAmount.Valid:
+		parts = append(parts, fmt.Sprintf("amount %s..%s", encodeCents(minAmount.Int64, false), encodeCents(maxAmount.Int64, false)))
+	case minAmount.Valid:
+		parts = append(parts, "amount >= "+encodeCents(minAmount.Int64, false))
+	case maxAmount.Valid:
+		parts = append(parts, "amount <= "+encodeCents(maxAmount.Int64, false))
+	}
+
+	if rule.AccountID != 0 {
+		name, ok := accountNames[rule.AccountID]
+		if !ok {
+			name = fmt.Sprintf("#%d", rule.AccountID)
+		}
+		parts = append(parts, "account "+name)
+	}
+	if rule.NotesPattern != "" {
+		parts = append(parts, "notes ~ "+rule.NotesPattern)
+	}
+
+	if len(parts) == 0 {
+		return "<ANY>"
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// ruleActions describes the actions of a rule, categoryPaths maps the category IDs to their paths
+func ruleActions(rule ezex.Rule, categoryPaths map[int]string) string {
+	var parts []string
+	if rule.CategoryID != 0 {
+		parts = append(parts, "category "+categoryPaths[rule.CategoryID])
+	}
+	if rule.PayeeName != "" {
+		parts = append(parts, "payee "+rule.PayeeName)
+	}
+	if rule.Tags != "" {
+		parts = append(parts, ezex.FormatTags(rule.Tags))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// categoryPathsByID maps the category IDs to their paths
+func categoryPathsByID(categories []ezex.Category) map[int]string {
+	paths := make(map[int]string, len(categories))
+	for _, category := range categories {
+		paths[category.ID] = category.Path
+	}
+
+	return paths
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	ezex "github.com/armanimichael/ez-ex"
+	"github.com/armanimichael/ez-ex/cmd/ez-ex-cli/command"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"regexp"
+	"strings"
+)
+
+// ruleCreatorModel is the form creating a rule, conditions first then actions
+type ruleCreatorModel struct {
+	db         *sql.DB
+	stage      int
+	accounts   []ezex.Account
+	categories []ezex.Category
+	inputs     []standardTextInput
+	suggestion struct {
+		autocompleteSuggestion string
+		category               ezex.Category
+	}
+}
+
+const (
+	ruleNameStage = iota
+	rulePayeePatternStage
+	ruleMinAmountStage
+	ruleMaxAmountStage
+	ruleAccountStage
+	ruleNotesPatternStage
+	ruleCategoryStage
+	rulePayeeNameStage
+	ruleTagsStage
+)
+
+const ruleStagesCount = ruleTagsStage + 1
+
+var ruleCreatorKeySuggestions = formatKeySuggestions([][]string{
+	{"{enter}", "create rule"},
+	{"{tab}", "autocomplete"},
+	{"{esc}", "cancel"},
+	{"{up}/{down}", "switch field"},
+})
+
+func initRuleCreator(db *sql.DB, accounts []ezex.Account, categories []ezex.Category) ruleCreatorModel {
+	m := ruleCreatorModel{
+		db:     db,
+		inputs: make([]standardTextInput, ruleStagesCount),
+	}
+
+	return m.reset(accounts, categories)
+}
+
+func (m ruleCreatorModel) Update(msg tea.Msg) (ruleCreatorModel, tea.Cmd) {
+	var cmd tea.Cmd
+
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "enter":
+			if !m.isValid() {
+				break
+			}
+
+			return m, command.CreateRuleCmd(m.db, m.rule(), strings.TrimSpace(m.inputs[ruleCategoryStage].model.Value()))
+		case "tab":
+			if m.suggestion.autocompleteSuggestion != "" && m.stage == ruleCategoryStage {
+				m.inputs[m.stage].model.SetValue(m.suggestion.category.Path)
+				m.inputs[m.stage].model.SetCursor(len(m.suggestion.category.Path))
+				m.suggestion.autocompleteSuggestion = ""
+			}
+			return m, nil
+		case "up", "down":
+			m.inputs[m.stage].model.Blur()
+			m.stage = handleSwitchInputStage(msg.String() == "up", m.stage, ruleNameStage, ruleTagsStage)
+			m.inputs[m.stage].model.SetCursor(0)
+			m.inputs[m.stage].model.Focus()
+			m.suggestion.autocompleteSuggestion = ""
+
+			return m, textinput.Blink
+		}
+	}
+
+	currentInput := &m.inputs[m.stage]
+	currentInput.model, cmd = currentInput.model.Update(msg)
+
+	for i := range m.inputs {
+		m.inputs[i].errorMsg = m.validateInput(i)
+		m.inputs[i].previousInput = m.inputs[i].model.Value()
+	}
+
+	m.suggestion.autocompleteSuggestion = ""
+	if val := currentInput.model.Value(); val != "" && m.stage == ruleCategoryStage {
+		if match, ok := autocomplete(categoryPaths(m.categories), val); ok {
+			m.suggestion.autocompleteSuggestion = match.Path[len(val):]
+			m.suggestion.category = match.Category
+		}
+	}
+
+	return m, cmd
+}
+
+func (m ruleCreatorModel) View() string {
+	return standardTextInputView(m.stage, m.inputs, m.suggestion.autocompleteSuggestion) + "\n\n" + ruleCreatorKeySuggestions
+}
+
+func (m ruleCreatorModel) reset(accounts []ezex.Account, categories []ezex.Category) ruleCreatorModel {
+	m.stage = ruleNameStage
+	m.accounts = accounts
+	m.categories = categories
+	m.suggestion.autocompleteSuggestion = ""
+	for i := range m.inputs {
+		m.inputs[i] = createRuleInput(i)
+	}
+	m.inputs[m.stage].model.Focus()
+	m.inputs[ruleNameStage].errorMsg = m.validateInput(ruleNameStage)
+
+	return m
+}
+
+func (m ruleCreatorModel) isValid() bool {
+	for i := range m.inputs {
+		if m.validateInput(i) != "" {
+			return false
+		}
+	}
+
+	return true
+}
+
+// rule converts the inputs to a rule, the category is set by path (see command.CreateRuleCmd).
+// The inputs must be valid
+func (m ruleCreatorModel) rule() ezex.Rule {
+	values := make([]string, len(m.inputs))
+	for i, input := range m.inputs {
+		values[i] = strings.TrimSpace(input.model.Value())
+	}
+
+	rule := ezex.Rule{
+		Name:         values[ruleNameStage],
+		PayeePattern: values[rulePayeePatternStage],
+		NotesPattern: values[ruleNotesPatternStage],
+		PayeeName:    values[rulePayeeNameStage],
+		Tags:         strings.Join(ezex.ParseTags(values[ruleTagsStage]), " "),
+	}
+	if value := values[ruleMinAmountStage]; value != "" {
+		rule.MinAmountInCents = sql.NullInt64{Int64: decodeCents(value), Valid: true}
+	}
+	if value := values[ruleMaxAmountStage]; value != "" {
+		rule.MaxAmountInCents = sql.NullInt64{Int64: decodeCents(value), Valid: true}
+	}
+	if account, ok := m.findAccount(values[ruleAccountStage]); ok {
+		rule.AccountID = account.ID
+	}
+
+	return rule
+}
+
+func (m ruleCreatorModel) findAccount(name string) (ezex.Account, bool) {
+	for _, account := range m.accounts {
+		if strings.EqualFold(account.Name, name) {
+			return account, true
+		}
+	}
+
+	return ezex.Account{}, false
+}
+
+func (m ruleCreatorModel) validateInput(stage int) string {
+	value := strings.TrimSpace(m.inputs[stage].model.Value())
+
+	switch stage {
+	case ruleNameStage:
+		if value == "" {
+			return "rule name is required"
+		}
+	case rulePayeePatternStage, ruleNotesPatternStage:
+		if _, err := regexp.Compile("(?i)" + value); err != nil {
+			return fmt.Sprintf("invalid %s: %v", strings.ToLower(m.inputs[stage].label), err)
+		}
+	case ruleMinAmountStage, ruleMaxAmountStage:
+		if err := validateAmount(value); value != "" && err != nil {
+			return err.Error()
+		}
+
+		minAmount, minErr := ezex.ParseMoney(m.inputs[ruleMinAmountStage].model.Value())
+		maxAmount, maxErr := ezex.ParseMoney(m.inputs[ruleMaxAmountStage].model.Value())
+		if stage == ruleMaxAmountStage && minErr == nil && maxErr == nil && minAmount.Cents > maxAmount.Cents {
+			return ezex.ErrInvalidRuleAmountRange.Error()
+		}
+	case ruleAccountStage:
+		if _, ok := m.findAccount(value); value != "" && !ok {
+			return fmt.Sprintf("unknown account: %s", value)
+		}
+	case ruleCategoryStage:
+		if err := validateCategoryPath(value); value != "" && err != nil {
+			return err.Error()
+		}
+
+		payeeName := strings.TrimSpace(m.inputs[rulePayeeNameStage].model.Value())
+		tags := ezex.ParseTags(m.inputs[ruleTagsStage].model.Value())
+		if value == "" && payeeName == "" && len(tags) == 0 {
+			return ezex.ErrRuleWithoutActions.Error()
+		}
+	}
+
+	return ""
+}
+
+func createRuleInput(stage int) standardTextInput {
+	ti := textinput.New()
+	ti.Prompt = ""
+
+	switch stage {
+	case ruleNameStage:
+		ti.Placeholder = "..."
+		return standardTextInput{model: ti, label: "Rule name*"}
+	case rulePayeePatternStage:
+		ti.Placeholder = "<ANY> (e.g. ^amzn)"
+		return standardTextInput{model: ti, label: "Payee pattern"}
+	case ruleMinAmountStage:
+		ti.Placeholder = "<ANY>"
+		return standardTextInput{model: ti, label: "Min amount"}
+	case ruleMaxAmountStage:
+		ti.Placeholder = "<ANY>"
+		return standardTextInput{model: ti, label: "Max amount"}
+	case ruleAccountStage:
+		ti.Placeholder = "<ANY>"
+		return standardTextInput{model: ti, label: "Account"}
+	case ruleNotesPatternStage:
+		ti.Placeholder = "<ANY>"
+		return standardTextInput{model: ti, label: "Notes pattern"}
+	case ruleCategoryStage:
+		ti.Placeholder = "<UNCHANGED>"
+		return standardTextInput{model: ti, label: "Set category"}
+	case rulePayeeNameStage:
+		ti.Placeholder = "<UNCHANGED>"
+		return standardTextInput{model: ti, label: "Rename payee"}
+	case ruleTagsStage:
+		ti.Placeholder = "#tag1 #tag2"
+		return standardTextInput{model: ti, label: "Add tags"}
+	}
+
+	panic("unsupported rule creation stage")
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"database/sql"
+	ezex "github.com/armanimichael/ez-ex"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// ruleCreatorWithValues creates a rule creator with the given input values, in stage order
+func ruleCreatorWithValues(values ...string) ruleCreatorModel {
+	m := initRuleCreator(nil, []ezex.Account{{ID: 2, Name: "Bank"}}, nil)
+	for i, value := range values {
+		m.inputs[i].model.SetValue(value)
+	}
+
+	return m
+}
+
+func TestRuleCreatorModel_Rule(t *testing.T) {
+	m := ruleCreatorWithValues("Amazon", "^amzn", "-100.00", "0.00", "bank", "", "Shopping", "Amazon", "#online, #amazon")
+
+	assert.True(t, m.isValid())
+	assert.Equal(t, ezex.Rule{
+		Name:             "Amazon",
+		PayeePattern:     "^amzn",
+		MinAmountInCents: sql.NullInt64{Int64: -10000, Valid: true},
+		MaxAmountInCents: sql.NullInt64{Int64: 0, Valid: true},
+		AccountID:        2,
+		PayeeName:        "Amazon",
+		Tags:             "online amazon",
+	}, m.rule())
+}
+
+func TestRuleCreatorModel_Validation(t *testing.T) {
+	cases := []struct {
+		name   string
+		values []string
+	}{
+		{"missing name", []string{"", "", "", "", "", "", "Food"}},
+		{"invalid payee pattern", []string{"rule", "(", "", "", "", "", "Food"}},
+		{"invalid amount", []string{"rule", "", "10.000.0", "", "", "", "Food"}},
+		{"invalid amount range", []string{"rule", "", "10.00", "-10.00", "", "", "Food"}},
+		{"unknown account", []string{"rule", "", "", "", "Cash", "", "Food"}},
+		{"invalid notes pattern", []string{"rule", "", "", "", "", "[", "Food"}},
+		{"no actions", []string{"rule", "^shop"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.False(t, ruleCreatorWithValues(c.values...).isValid())
+		})
+	}
+
+	assert.True(t, ruleCreatorWithValues("rule", "", "", "", "", "", "", "", "#tag").isValid())
+}
+
+func TestRuleDescriptions(t *testing.T) {
+	rule := ezex.Rule{
+		PayeePattern:     "^shell",
+		MaxAmountInCents: sql.NullInt64{Valid: true},
+		AccountID:        3,
+		CategoryID:       4,
+		Tags:             "car fuel",
+	}
+
+	assert.Equal(t, "payee ~ ^shell, amount <= 0.00, account #3", ruleConditions(rule, nil))
+	assert.Equal(t, "category Car:Fuel, #car #fuel", ruleActions(rule, map[int]string{4: "Car:Fuel"}))
+	assert.Equal(t, "<ANY>", ruleConditions(ezex.Rule{}, nil))
+}
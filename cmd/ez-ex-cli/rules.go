@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	ezex "github.com/armanimichael/ez-ex"
+	"strings"
+	"time"
+)
+
+const rulesUsage = `usage:
+  rules list
+  rules apply [-dry-run] [-overwrite] [-from YYYY-MM-DD] [-to YYYY-MM-DD]`
+
+// runRules handles `ez-ex rules`, listing the rules or applying them to the existing transactions
+func runRules(db *sql.DB, args []string) error {
+	if len(args) == 0 {
+		return errors.New(rulesUsage)
+	}
+
+	accountNames := make(map[int]string)
+	for _, account := range ezex.GetAccounts(db) {
+		accountNames[account.ID] = account.Name
+	}
+	categoryPaths := categoryPathsByID(ezex.GetCategoryTree(db))
+
+	switch args[0] {
+	case "list":
+		for i, rule := range ezex.GetRules(db) {
+			fmt.Printf(
+				"%d\t%s\tif %s\tthen %s\n",
+				i+1,
+				rule.Name,
+				ruleConditions(rule, accountNames),
+				ruleActions(rule, categoryPaths),
+			)
+		}
+	case "apply":
+		flags := flag.NewFlagSet("rules apply", flag.ExitOnError)
+		dryRun := flags.Bool("dry-run", false, "Print the changes without saving them")
+		overwrite := flags.Bool("overwrite", false, "Replace the category of already categorized transactions")
+		from := flags.String("from", "", "First day of the transactions, YYYY-MM-DD (included)")
+		to := flags.String("to", "", "Last day of the transactions, YYYY-MM-DD (included)")
+		_ = flags.Parse(args[1:])
+
+		filter := ezex.TransactionFilter{}
+		if *from != "" {
+			if err := validateDateString(*from); err != nil {
+				return fmt.Errorf("-from: %w", err)
+			}
+			filter.MinDate = time.Unix(decodeUnixDate(*from), 0)
+		}
+		if *to != "" {
+			if err := validateDateString(*to); err != nil {
+				return fmt.Errorf("-to: %w", err)
+			}
+			filter.MaxDate = time.Unix(decodeUnixDate(*to), 0).AddDate(0, 0, 1)
+		}
+
+		ctx := ezex.WithAuditSource(context.Background(), ezex.AuditSourceCLI)
+		changes, err := ezex.ApplyRulesContext(ctx, db, filter, *overwrite, *dryRun)
+		if err != nil {
+			return err
+		}
+
+		skipped := 0
+		for _, change := range changes {
+			summary := ruleChangeSummary(change)
+			if change.Skipped {
+				skipped++
+				summary = "skipped (reconciled): " + summary
+			}
+
+			fmt.Printf(
+				"%d\t%s\t%s\t%s\t%s\n",
+				change.Transaction.ID,
+				encodeUnixDate(change.Transaction.TransactionDateUnix),
+				change.Transaction.PayeeName,
+				encodeCents(change.Transaction.AmountInCents, false),
+				summary,
+			)
+		}
+
+		if *dryRun {
+			fmt.Printf("%d transactions would be updated, %d skipped (dry run)\n", len(changes)-skipped, skipped)
+		} else {
+			fmt.Printf("%d transactions updated, %d skipped\n", len(changes)-skipped, skipped)
+		}
+	default:
+		return errors.New(rulesUsage)
+	}
+
+	return nil
+}
+
+// ruleChangeSummary describes the changes made by the rules to a transaction
+func ruleChangeSummary(change ezex.RuleChange) string {
+	var parts []string
+	if change.CategoryID != 0 {
+		from := change.Transaction.CategoryName
+		if change.Transaction.CategoryID == 0 {
+			from = "<NO CATEGORY>"
+		}
+		parts = append(parts, fmt.Sprintf("category %s -> %s", from, change.CategoryPath))
+	}
+	if change.PayeeName != "" {
+		parts = append(parts, fmt.Sprintf("payee %s -> %s", change.Transaction.PayeeName, change.PayeeName))
+	}
+	if len(change.AddedTags) > 0 {
+		parts = append(parts, "tags +"+ezex.FormatTags(strings.Join(change.AddedTags, " ")))
+	}
+
+	return strings.Join(parts, ", ")
+}
@@ -4,12 +4,15 @@ import (
 	"github.com/charmbracelet/lipgloss"
 )
 
-const (
+// Colors of the dark theme, the default one (see applyTheme)
+var (
 	foreground         = lipgloss.Color("240")
 	selectedForeground = lipgloss.Color("229")
 	selectedBackground = lipgloss.Color("32")
 	errorForeground    = lipgloss.Color("124")
 	successForeground  = lipgloss.Color("2")
+	warningForeground  = lipgloss.Color("214")
+	keySuggestionColor = lipgloss.Color("248")
 )
 
 var baseStyle = lipgloss.NewStyle().
@@ -22,8 +25,11 @@ var errorMessageStyle = lipgloss.NewStyle().
 var successMessageStyle = lipgloss.NewStyle().
 	Foreground(successForeground)
 
+var warningMessageStyle = lipgloss.NewStyle().
+	Foreground(warningForeground)
+
 var keySuggestionStyle = lipgloss.NewStyle().
-	Foreground(lipgloss.Color("248"))
+	Foreground(keySuggestionColor)
 
 var lowOpacityForegroundStyle = lipgloss.NewStyle().
 	Foreground(lipgloss.Color("240"))
@@ -34,3 +40,30 @@ var inputBoxSelectedStyle = lipgloss.NewStyle().
 
 var inputBoxStyle = lipgloss.NewStyle().
 	PaddingLeft(1)
+
+var barStyle = lipgloss.NewStyle().
+	Foreground(selectedBackground)
+
+// applyTheme sets the colors of a theme (`dark` or `light`), it must be called before creating any model
+func applyTheme(theme string) {
+	if theme != "light" {
+		return
+	}
+
+	foreground = lipgloss.Color("245")
+	selectedForeground = lipgloss.Color("255")
+	selectedBackground = lipgloss.Color("25")
+	errorForeground = lipgloss.Color("160")
+	successForeground = lipgloss.Color("28")
+	warningForeground = lipgloss.Color("166")
+	keySuggestionColor = lipgloss.Color("238")
+
+	baseStyle = baseStyle.BorderForeground(foreground)
+	errorMessageStyle = errorMessageStyle.Foreground(errorForeground)
+	successMessageStyle = successMessageStyle.Foreground(successForeground)
+	warningMessageStyle = warningMessageStyle.Foreground(warningForeground)
+	keySuggestionStyle = keySuggestionStyle.Foreground(keySuggestionColor)
+	lowOpacityForegroundStyle = lowOpacityForegroundStyle.Foreground(foreground)
+	inputBoxSelectedStyle = inputBoxSelectedStyle.BorderForeground(selectedBackground)
+	barStyle = barStyle.Foreground(selectedBackground)
+}
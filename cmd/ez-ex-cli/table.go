@@ -1,19 +1,43 @@
 package main
 
 import (
+	"fmt"
 	ezex "github.com/armanimichael/ez-ex"
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"strconv"
+	"strings"
 )
 
-func createStandardTable(columns []table.Column, rows []table.Row) table.Model {
+// minTableHeight is the number of rows shown by tables until the terminal size is known (see fitTable),
+// and the least they shrink to
+const minTableHeight = 6
+
+// tableColumn is a table column, flex columns grow with the terminal (see resizeTable)
+type tableColumn struct {
+	table.Column
+	flex bool
+}
+
+// fixedColumn is a column always width cells wide, e.g. for IDs, dates and amounts
+func fixedColumn(title string, width int) tableColumn {
+	return tableColumn{Column: table.Column{Title: title, Width: width}}
+}
+
+// flexColumn is a column at least width cells wide, sharing the width left by the others proportionally to width,
+// e.g. for payees and notes (truncated with an ellipsis when too long)
+func flexColumn(title string, width int) tableColumn {
+	return tableColumn{Column: table.Column{Title: title, Width: width}, flex: true}
+}
+
+func createStandardTable(columns []tableColumn, rows []table.Row) table.Model {
 	t := table.New(
-		table.WithColumns(columns),
+		table.WithColumns(resizeColumns(columns, 0)),
 		table.WithRows(rows),
 		table.WithFocused(true),
-		table.WithHeight(6),
+		table.WithHeight(minTableHeight),
 		table.WithKeyMap(table.KeyMap{
 			LineUp:   key.NewBinding(key.WithKeys("up")),
 			LineDown: key.NewBinding(key.WithKeys("down")),
@@ -34,6 +58,57 @@ func createStandardTable(columns []table.Column, rows []table.Row) table.Model {
 	return t
 }
 
+// resizeColumns sizes the columns to fill a table width cells wide (borders included), flex columns keep their
+// minimum width when there's no room left
+func resizeColumns(columns []tableColumn, width int) []table.Column {
+	// The table is surrounded by a border (see baseStyle) and cells are padded by a space per side
+	free := width - 2 - 2*len(columns)
+	flexWidth := 0
+	for _, column := range columns {
+		if column.flex {
+			flexWidth += column.Width
+		} else {
+			free -= column.Width
+		}
+	}
+
+	resized := make([]table.Column, len(columns))
+	grown, last := 0, -1
+	for i, column := range columns {
+		resized[i] = column.Column
+		if column.flex && free > flexWidth {
+			resized[i].Width = column.Width * free / flexWidth
+			grown += resized[i].Width
+			last = i
+		}
+	}
+	// The last flex column takes the few cells left by rounding
+	if last >= 0 {
+		resized[last].Width += free - grown
+	}
+
+	return resized
+}
+
+// resizeTable fits t in width cells (borders included), showing height rows
+func resizeTable(t table.Model, columns []tableColumn, width int, height int) table.Model {
+	t.SetColumns(resizeColumns(columns, width))
+	t.SetHeight(max(height, minTableHeight))
+
+	return t
+}
+
+// fitTable resizes t to fill a terminal of the given size along with the rest of view, the screen currently showing
+// t. Tables keep their default size until the terminal size is known
+func fitTable(t table.Model, columns []tableColumn, view string, size tea.WindowSizeMsg) table.Model {
+	if size.Width == 0 {
+		return t
+	}
+
+	// The last line is left for the transient messages (see model.notice)
+	return resizeTable(t, columns, size.Width, size.Height-(lipgloss.Height(view)-t.Height())-1)
+}
+
 func accountsToTableRows(accounts ...ezex.Account) []table.Row {
 	var rows []table.Row
 
@@ -66,18 +141,167 @@ func transactionsToTableRows(transactions ...ezex.TransactionView) []table.Row {
 		if !transaction.Notes.Valid {
 			notes = "<NO NOTES>"
 		}
+		if transaction.AttachmentCount > 0 {
+			notes = "📎 " + notes
+		}
+		if transaction.DeleteDateUnix.Valid {
+			notes = "[DELETED] " + notes
+		}
 
 		rows = append(
 			rows,
 			table.Row{
 				strconv.Itoa(transaction.ID),
 				date,
+				transactionStatusLabel(transaction.Status),
 				encodeCents(transaction.AmountInCents, true),
 				transaction.PayeeName,
-				transaction.CategoryName,
+				categoryLabel(transaction),
+				ezex.FormatTags(transaction.Tags),
 				notes,
 			})
 	}
 
 	return rows
 }
+
+// transactionStatusLabel is the short status shown in tables, uncommitted transactions have none
+func transactionStatusLabel(status ezex.TransactionStatus) string {
+	switch status {
+	case ezex.StatusCleared:
+		return "C"
+	case ezex.StatusReconciled:
+		return "R"
+	}
+
+	return ""
+}
+
+func recentTransactionsToTableRows(transactions ...ezex.TransactionView) []table.Row {
+	var rows []table.Row
+
+	for _, transaction := range transactions {
+		rows = append(
+			rows,
+			table.Row{
+				strconv.Itoa(transaction.ID),
+				encodeUnixDate(transaction.TransactionDateUnix),
+				transaction.AccountName,
+				encodeCents(transaction.AmountInCents, true),
+				transaction.PayeeName,
+				categoryLabel(transaction),
+			})
+	}
+
+	return rows
+}
+
+func accountTransactionsToTableRows(transactions ...ezex.TransactionView) []table.Row {
+	var rows []table.Row
+
+	for _, transaction := range transactions {
+		rows = append(
+			rows,
+			table.Row{
+				strconv.Itoa(transaction.ID),
+				encodeUnixDate(transaction.TransactionDateUnix),
+				transactionStatusLabel(transaction.Status),
+				encodeCents(transaction.AmountInCents, true),
+				transaction.PayeeName,
+				categoryLabel(transaction),
+			})
+	}
+
+	return rows
+}
+
+func searchResultsToTableRows(transactions ...ezex.TransactionView) []table.Row {
+	var rows []table.Row
+
+	for _, transaction := range transactions {
+		rows = append(
+			rows,
+			table.Row{
+				strconv.Itoa(transaction.ID),
+				encodeUnixDate(transaction.TransactionDateUnix),
+				transaction.AccountName,
+				encodeCents(transaction.AmountInCents, true),
+				transaction.PayeeName,
+				categoryLabel(transaction),
+				transaction.Notes.String,
+			})
+	}
+
+	return rows
+}
+
+// categoryLabel returns the category name to show, split transactions are marked with the number of splits
+func categoryLabel(transaction ezex.TransactionView) string {
+	if transaction.SplitCount > 0 {
+		return fmt.Sprintf("<SPLIT (%d)>", transaction.SplitCount)
+	}
+
+	return transaction.CategoryName
+}
+
+// categoriesToTableRows renders the category tree (see ezex.GetCategoryTree) indenting subcategories,
+// "no category" is the root
+func categoriesToTableRows(categories ...ezex.Category) []table.Row {
+	var rows []table.Row
+
+	for _, category := range categories {
+		name := category.Name
+		if category.ID != 0 {
+			name = strings.Repeat("  ", category.Depth) + "└ " + name
+		}
+
+		desc := category.Description.String
+		if !category.Description.Valid {
+			desc = "<NO DESCRIPTION>"
+		}
+
+		rows = append(
+			rows,
+			table.Row{
+				strconv.Itoa(category.ID),
+				name,
+				desc,
+			})
+	}
+
+	return rows
+}
+
+// rulesToTableRows renders the rules in evaluation order (see ruleConditions and ruleActions)
+func rulesToTableRows(rules []ezex.Rule, accountNames map[int]string, categoryPaths map[int]string) []table.Row {
+	rows := make([]table.Row, len(rules))
+	for i, rule := range rules {
+		rows[i] = table.Row{
+			strconv.Itoa(i + 1),
+			rule.Name,
+			ruleConditions(rule, accountNames),
+			ruleActions(rule, categoryPaths),
+		}
+	}
+
+	return rows
+}
+
+// duplicatesToTableRows renders the likely duplicates, one pair per row
+func duplicatesToTableRows(pairs ...ezex.DuplicatePair) []table.Row {
+	rows := make([]table.Row, len(pairs))
+	for i, pair := range pairs {
+		rows[i] = table.Row{
+			pair.Transaction.AccountName,
+			pair.Transaction.PayeeName,
+			encodeCents(pair.Transaction.AmountInCents, true),
+			encodeUnixDate(pair.Transaction.TransactionDateUnix),
+			pair.Transaction.Notes.String,
+			encodeUnixDate(pair.Duplicate.TransactionDateUnix),
+			pair.Duplicate.Notes.String,
+			fmt.Sprintf("%.0f%%", pair.NotesSimilarity*100),
+		}
+	}
+
+	return rows
+}
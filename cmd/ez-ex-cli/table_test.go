@@ -0,0 +1,97 @@
+package main
+
+import (
+	ezex "github.com/armanimichael/ez-ex"
+	"github.com/armanimichael/ez-ex/cmd/ez-ex-cli/command"
+	customLogger "github.com/armanimichael/ez-ex/cmd/ez-ex-cli/logger"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestResizeColumns(t *testing.T) {
+	columns := []tableColumn{fixedColumn("ID", 5), flexColumn("Payee", 10), flexColumn("Notes", 20)}
+
+	// 8 cells of borders and padding, 5 for the ID, the flex columns share the other 36 as 10:20
+	resized := resizeColumns(columns, 49)
+	assert.Equal(t, []int{5, 12, 24}, columnWidths(resized))
+
+	// The last flex column takes the cells left by rounding
+	resized = resizeColumns(columns, 47)
+	assert.Equal(t, []int{5, 11, 23}, columnWidths(resized))
+
+	// Flex columns don't shrink below their width
+	resized = resizeColumns(columns, 20)
+	assert.Equal(t, []int{5, 10, 20}, columnWidths(resized))
+}
+
+func columnWidths(columns []table.Column) []int {
+	widths := make([]int, len(columns))
+	for i, column := range columns {
+		widths[i] = column.Width
+	}
+
+	return widths
+}
+
+func TestFitTable(t *testing.T) {
+	columns := []tableColumn{fixedColumn("ID", 5), flexColumn("Notes", 20)}
+	tbl := createStandardTable(columns, nil)
+	view := "Title\n" + baseStyle.Render(tbl.View()) + "\nkeys"
+
+	// The terminal size is unknown
+	assert.Equal(t, tbl, fitTable(tbl, columns, view, tea.WindowSizeMsg{}))
+
+	fitted := fitTable(tbl, columns, view, tea.WindowSizeMsg{Width: 100, Height: 40})
+	fittedView := "Title\n" + baseStyle.Render(fitted.View()) + "\nkeys"
+	assert.Equal(t, 100, lipgloss.Width(fittedView))
+	// A line is left for the transient messages
+	assert.Equal(t, 39, lipgloss.Height(fittedView))
+
+	// Tiny terminals still show a few rows
+	fitted = fitTable(tbl, columns, view, tea.WindowSizeMsg{Width: 10, Height: 5})
+	assert.Equal(t, minTableHeight, fitted.Height())
+}
+
+func TestAccountModel_WideLayout(t *testing.T) {
+	logger = customLogger.NewFileLogger(6, "")
+	db, err := ezex.OpenDB(ezex.WithInMemory())
+	assert.Nil(t, err)
+	defer db.Close()
+	assert.Nil(t, ezex.MigrateDB(db))
+
+	accountID, _ := ezex.AddAccount(db, ezex.Account{Name: "TestAccountModel_WideLayout"})
+	payeeID, _ := ezex.AddPayee(db, ezex.Payee{Name: "TestAccountModel_WideLayout payee"})
+	_, _ = ezex.AddTransaction(db, ezex.Transaction{
+		AccountID:           accountID,
+		PayeeID:             payeeID,
+		AmountInCents:       -1000,
+		TransactionDateUnix: time.Now().Unix(),
+	})
+
+	var m tea.Model = initialModel(db, t.TempDir(), []profile{{name: defaultProfileName}}, defaultProfileName, nil)
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 120, Height: 30})
+	m, cmd := m.Update(command.SwitchModelMsg{ModelID: accountModelID})
+	// The preview is only loaded in wide terminals
+	assert.Nil(t, cmd)
+	assert.NotContains(t, m.View(), "-10.00")
+	assertFits(t, m.View(), 120, 30)
+
+	m, cmd = m.Update(tea.WindowSizeMsg{Width: 200, Height: 40})
+	for _, msg := range runCmd(cmd) {
+		m, _ = m.Update(msg)
+	}
+	assert.Contains(t, m.View(), "Transactions of TestAccountModel_WideLayout")
+	assert.Contains(t, m.View(), "-10.00")
+	assertFits(t, m.View(), 200, 40)
+}
+
+// assertFits checks that view fills the terminal width, leaving a line for the transient messages
+func assertFits(t *testing.T, view string, width int, height int) {
+	t.Helper()
+	assert.Equal(t, width, lipgloss.Width(view))
+	assert.Equal(t, height-1, lipgloss.Height(view))
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	ezex "github.com/armanimichael/ez-ex"
 	"strings"
 )
 
@@ -16,3 +17,63 @@ func autocomplete[T interface{ GetName() string }](entities []T, val string) (ma
 
 	return nil, false
 }
+
+// findByName returns the entity named `name` (case-insensitive)
+func findByName[T interface{ GetName() string }](entities []T, name string) (match T, ok bool) {
+	for _, entity := range entities {
+		if strings.EqualFold(entity.GetName(), name) {
+			return entity, true
+		}
+	}
+
+	return match, false
+}
+
+// categoryPath makes categories autocompleted and found by their full path (`Parent:Child`) rather than their name
+type categoryPath struct {
+	ezex.Category
+}
+
+func (c categoryPath) GetName() string {
+	return c.Path
+}
+
+func categoryPaths(categories []ezex.Category) []categoryPath {
+	paths := make([]categoryPath, len(categories))
+	for i, category := range categories {
+		paths[i] = categoryPath{category}
+	}
+
+	return paths
+}
+
+// findCategory returns the category at path (case-insensitive), a new category with no ID if it doesn't exist
+func findCategory(categories []ezex.Category, path string) (ezex.Category, bool) {
+	if match, ok := findByName(categoryPaths(categories), path); ok {
+		return match.Category, true
+	}
+
+	return ezex.Category{Path: path}, false
+}
+
+// findCategoryByID returns the category with the given ID, false if it doesn't exist
+func findCategoryByID(categories []ezex.Category, id int) (ezex.Category, bool) {
+	for _, category := range categories {
+		if category.ID == id {
+			return category, true
+		}
+	}
+
+	return ezex.Category{}, false
+}
+
+// findAccountByID returns the account with the given ID, false if it doesn't exist
+func findAccountByID(accounts []ezex.Account, id int) (ezex.Account, bool) {
+	for _, account := range accounts {
+		if account.ID == id {
+			return account, true
+		}
+	}
+
+	return ezex.Account{}, false
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	ezex "github.com/armanimichael/ez-ex"
@@ -14,13 +15,26 @@ import (
 )
 
 type transactionModel struct {
-	db                 *sql.DB
-	newTransaction     ezex.Transaction
-	account            ezex.Account
+	// ctx is canceled when leaving the screen (see model.resetContext)
+	ctx            context.Context
+	db             *sql.DB
+	attachmentsDir string
+	newTransaction ezex.Transaction
+	account        ezex.Account
+	// balance splits the account balance by transaction status
+	balance            ezex.AccountBalance
 	transactions       []ezex.TransactionView
 	stage              int
 	transactionCreator transactionCreatorModel
-	err                struct {
+	transactionEditor  transactionEditorModel
+	transactionSearch  transactionSearchModel
+	transactionFilter  transactionFilterModel
+	reconcile          reconcileModel
+	transactionHistory transactionHistoryModel
+	// filter is the applied filter, without accounts and dates (see accountFilter)
+	filter ezex.TransactionFilter
+	size   tea.WindowSizeMsg
+	err    struct {
 		id  int64
 		msg string
 	}
@@ -41,8 +55,24 @@ type transactionModel struct {
 const (
 	transactionSelectionStage = iota
 	transactionCreationStage
+	transactionSearchStage
+	transactionFilterStage
+	transactionReconcileStage
+	transactionHistoryStage
+	transactionEditStage
 )
 
+var transactionTableColumns = []tableColumn{
+	fixedColumn("ID", 5),
+	fixedColumn("Date", 10),
+	fixedColumn("St.", 3),
+	fixedColumn("Amount", 10),
+	flexColumn("Payee", 20),
+	flexColumn("Category", 20),
+	flexColumn("Tags", 20),
+	flexColumn("Notes", 30),
+}
+
 var transactionTableKeySuggestions = formatKeySuggestions([][]string{
 	{"^C", "quit"},
 	{"{esc}", "accounts list"},
@@ -50,29 +80,76 @@ var transactionTableKeySuggestions = formatKeySuggestions([][]string{
 	{"{left}", "previous month"},
 	{"r", "reset month"},
 	{"d", "delete transaction"},
+	{"c", "toggle cleared"},
+	{"R", "reconcile"},
 	{"n", "create transaction"},
+	{"e", "edit transaction"},
+	{"C", "change category"},
+	{"/", "search transactions"},
+	{"f", "filter transactions"},
+	{"x", "clear filter"},
+	{"o", "open attachments"},
+	{"h", "transaction history"},
+	{"u", "undo"},
+	{"^R", "redo"},
 })
 
-func initTransactionModel(db *sql.DB, accountID int) (m transactionModel, err error) {
+// initTransactionModel creates the transactions screen of an account, filterValues restores the last applied
+// filter (see transactionFilterModel.values)
+func initTransactionModel(
+	ctx context.Context,
+	db *sql.DB,
+	attachmentsDir string,
+	accountID int,
+	filterValues []string,
+) (m transactionModel, err error) {
+	m.ctx = ctx
 	m.db = db
+	m.attachmentsDir = attachmentsDir
 	m.stage = transactionSelectionStage
-	m.transactionCreator = initTransactionCreator(db, accountID, ezex.GetPayees(db), ezex.GetCategories(db))
-	now := time.Now()
-	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
-	monthEnd := time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.Local)
-	m.table.selectedMonth = monthStart.Month()
-	m.table.selectedYear = monthStart.Year()
-	m = m.createTransactionsTable(ezex.GetTransactions(db, accountID, monthStart, monthEnd))
-	if len(m.transactions) > 0 {
-		m.table.selectedID = m.transactions[0].ID
-	}
 
 	m.account, err = ezex.GetAccount(db, accountID)
 	if err != nil {
 		logger.Fatal(fmt.Sprintf("Cannot get account ID = %d: %v", accountID, err))
+		return m, err
+	}
+	m.balance, err = ezex.GetAccountBalance(db, accountID)
+	if err != nil {
+		logger.Fatal(fmt.Sprintf("Cannot get account ID = %d balance: %v", accountID, err))
+		return m, err
+	}
+
+	payees := ezex.GetPayees(db)
+	categories := ezex.GetCategories(db)
+	m.transactionCreator = initTransactionCreator(
+		db,
+		accountID,
+		payees,
+		categories,
+		ezex.GetTags(db),
+		ezex.TrainCategoryClassifier(db),
+	)
+	m.transactionSearch = initTransactionSearch(ctx, db)
+	m.transactionFilter = initTransactionFilter(payees, categories, filterValues)
+	if m.transactionFilter.isValid() {
+		m.filter = m.transactionFilter.filter()
+	} else {
+		// Payees or categories of the last filter no longer exist
+		m.transactionFilter = m.transactionFilter.reset(nil)
+	}
+
+	now := time.Now()
+	filter := m.accountFilter()
+	filter.MinDate = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+	filter.MaxDate = time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.Local)
+	m.table.selectedMonth = filter.MinDate.Month()
+	m.table.selectedYear = filter.MinDate.Year()
+	m = m.createTransactionsTable(ezex.FilterTransactions(db, filter))
+	if len(m.transactions) > 0 {
+		m.table.selectedID = m.transactions[0].ID
 	}
 
-	return m, err
+	return m, nil
 }
 
 func (m transactionModel) Init() tea.Cmd {
@@ -83,6 +160,9 @@ func (m transactionModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 
 	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.size = msg
+		return m.resize(), nil
 	case command.HideErrorMessageMsg:
 		if msg.Message == m.err.msg && msg.ID == m.err.id {
 			m.err.msg = ""
@@ -95,44 +175,126 @@ func (m transactionModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.table.selectedMonth = month
 		m.table.selectedYear = year
 
+		m.stage = transactionSelectionStage
+		m = m.createTransactionsTable(transactions)
+
+		cursor := 0
+		for i, transaction := range transactions {
+			if transaction.ID == msg.SelectedID {
+				cursor = i
+				break
+			}
+		}
 		if len(transactions) > 0 {
-			m.table.selectedID = msg.Transactions[0].ID
+			m.table.selectedID = transactions[cursor].ID
 		}
-		m.table.model.SetCursor(0)
+		m.table.model.SetCursor(cursor)
 
-		return m.createTransactionsTable(transactions), cmd
+		return m, cmd
 	case command.CreateNewTransactionMsg:
 		if msg.Err != nil {
 			logger.Fatal(fmt.Sprintf("Error creating new transaction: %v", msg.Err))
-			m.err.msg = msg.Err.Error()
+			m.err.msg = formatError(msg.Err)
 			m.err.id = time.Now().UnixMicro()
 
 			return m, command.HideErrorMessageCmd(m.err.id, m.err.msg)
 		}
 
-		m.transactionCreator = m.transactionCreator.reset()
+		// The classifier learns the new transaction rather than being retrained on all of them
+		creator := m.transactionCreator
+		creator.classifier.Learn(msg.Transaction)
+		m.transactionCreator = creator.setEntities(msg.Payees, msg.Categories, msg.Tags, creator.classifier).reset()
 		m.transactions = msg.Transactions
 		m.account.BalanceInCents += msg.AmountInCents
+		// New transactions are uncommitted
+		m.balance.WorkingInCents += msg.AmountInCents
 		m.table.model.SetRows(transactionsToTableRows(msg.Transactions...))
 		m.stage = transactionSelectionStage
 		m.table.selectedID = msg.Transactions[0].ID
 		m.table.model.SetCursor(0)
+
+		// Reload the month, applying the current filter
+		now := time.Now()
+		return m, tea.Batch(
+			command.SwitchTransactionsMonthCmd(m.ctx, m.db, m.accountFilter(), now.Year(), now.Month()),
+			command.OperationDoneCmd(command.UndoableOperation{
+				Kind:          command.CreateTransactionOperation,
+				ID:            msg.ID,
+				AccountID:     m.account.ID,
+				AmountInCents: msg.AmountInCents,
+			}),
+		)
+	case command.EditTransactionMsg:
+		m.stage = transactionSelectionStage
+		if msg.Err != nil {
+			logger.Err(fmt.Sprintf("Error editing transaction: %v", msg.Err))
+			m.err.msg = formatError(msg.Err)
+			m.err.id = time.Now().UnixMicro()
+
+			return m, command.HideErrorMessageCmd(m.err.id, m.err.msg)
+		}
+
+		creator := m.transactionCreator
+		m.transactionCreator = creator.setEntities(msg.Payees, msg.Categories, creator.tags, creator.classifier)
+		if account, err := ezex.GetAccountContext(m.ctx, m.db, m.account.ID); err == nil {
+			m.account = account
+		}
+		if balance, err := ezex.GetAccountBalanceContext(m.ctx, m.db, m.account.ID); err == nil {
+			m.balance = balance
+		}
+
+		operation := command.UndoableOperation{
+			Kind:          command.EditTransactionOperation,
+			ID:            msg.After.ID,
+			AccountID:     msg.After.AccountID,
+			AmountInCents: msg.AmountInCents,
+			Before:        msg.Before,
+			After:         msg.After,
+		}
+		if recategorized(msg.Before, msg.After) {
+			operation.Kind = command.RecategorizeTransactionOperation
+		}
+
+		// The date may have moved the transaction to another month
+		return m, tea.Batch(
+			command.JumpToTransactionCmd(m.ctx, m.db, m.filter, ezex.TransactionView{
+				ID:                  msg.After.ID,
+				AccountID:           msg.After.AccountID,
+				TransactionDateUnix: msg.After.TransactionDateUnix,
+			}),
+			command.OperationDoneCmd(operation),
+		)
+	case command.OpenAttachmentsMsg:
+		if msg.Err != nil {
+			logger.Err(fmt.Sprintf("Error opening attachments: %v", msg.Err))
+			m.err.msg = formatError(msg.Err)
+			m.err.id = time.Now().UnixMicro()
+
+			return m, command.HideErrorMessageCmd(m.err.id, m.err.msg)
+		}
 	case command.DeleteTransactionMsg:
 		if msg.Err != nil {
 			logger.Fatal(fmt.Sprintf("Error deleting transaction: %v", msg.Err))
-			m.err.msg = msg.Err.Error()
+			m.err.msg = formatError(msg.Err)
 			m.err.id = time.Now().UnixMicro()
 
 			return m, command.HideErrorMessageCmd(m.err.id, m.err.msg)
 		}
 
 		// Remove deleted row from the table
+		operation := command.UndoableOperation{Kind: command.DeleteTransactionOperation, ID: msg.DeletedID}
 		updatedTransactions := make([]ezex.TransactionView, 0, len(m.transactions)-1)
 		for _, transaction := range m.transactions {
 			if transaction.ID != msg.DeletedID {
 				updatedTransactions = append(updatedTransactions, transaction)
 			} else {
+				operation.AccountID = transaction.AccountID
+				operation.AmountInCents = transaction.AmountInCents
 				m.account.BalanceInCents -= transaction.AmountInCents
+				m.balance.WorkingInCents -= transaction.AmountInCents
+				if transaction.Status == ezex.StatusCleared {
+					m.balance.ClearedInCents -= transaction.AmountInCents
+				}
 			}
 		}
 		m.transactions = updatedTransactions
@@ -146,11 +308,86 @@ func (m transactionModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.table.model.SetRows([]table.Row{})
 		}
 		m.table.model.GotoTop()
+
+		return m, command.OperationDoneCmd(operation)
+	case command.SetTransactionStatusMsg:
+		if msg.Err != nil {
+			logger.Err(fmt.Sprintf("Error changing transaction status: %v", msg.Err))
+			m.err.msg = formatError(msg.Err)
+			m.err.id = time.Now().UnixMicro()
+
+			return m, command.HideErrorMessageCmd(m.err.id, m.err.msg)
+		}
+
+		operation := command.UndoableOperation{Kind: command.SetTransactionStatusOperation, ID: msg.ID, To: int(msg.Status)}
+		m.balance = msg.Balance
+		for i := range m.transactions {
+			if m.transactions[i].ID == msg.ID {
+				operation.From = int(m.transactions[i].Status)
+				m.transactions[i].Status = msg.Status
+			}
+		}
+		m.table.model.SetRows(transactionsToTableRows(m.transactions...))
+
+		return m, command.OperationDoneCmd(operation)
+	case command.UndoMsg:
+		if msg.Err != nil {
+			return m, nil
+		}
+
+		// The operation may have changed the balance, or transactions of the month
+		if account, err := ezex.GetAccountContext(m.ctx, m.db, m.account.ID); err == nil {
+			m.account = account
+		}
+		if balance, err := ezex.GetAccountBalanceContext(m.ctx, m.db, m.account.ID); err == nil {
+			m.balance = balance
+		}
+		return m, command.SwitchTransactionsMonthCmd(m.ctx, m.db, m.accountFilter(), m.table.selectedYear, m.table.selectedMonth)
+	case command.ReconcileMsg:
+		m.stage = transactionSelectionStage
+		if msg.Err != nil {
+			logger.Err(fmt.Sprintf("Error reconciling account: %v", msg.Err))
+			m.err.msg = formatError(msg.Err)
+			m.err.id = time.Now().UnixMicro()
+
+			return m, command.HideErrorMessageCmd(m.err.id, m.err.msg)
+		}
+
+		m.balance = msg.Balance
+		return m, command.SwitchTransactionsMonthCmd(m.ctx, m.db, m.accountFilter(), m.table.selectedYear, m.table.selectedMonth)
 	}
 
 	if m.stage == transactionCreationStage {
 		m.transactionCreator, cmd = m.transactionCreator.Update(msg)
 		return m, cmd
+	} else if m.stage == transactionSearchStage {
+		return m.handleSearchCommands(msg)
+	} else if m.stage == transactionFilterStage {
+		return m.handleFilterCommands(msg)
+	} else if m.stage == transactionReconcileStage {
+		if msg, ok := msg.(tea.KeyMsg); ok && msg.String() == "esc" {
+			m.stage = transactionSelectionStage
+			return m, nil
+		}
+
+		m.reconcile, cmd = m.reconcile.Update(msg)
+		return m, cmd
+	} else if m.stage == transactionEditStage {
+		if msg, ok := msg.(tea.KeyMsg); ok && msg.String() == "esc" {
+			m.stage = transactionSelectionStage
+			return m, nil
+		}
+
+		m.transactionEditor, cmd = m.transactionEditor.Update(msg)
+		return m, cmd
+	} else if m.stage == transactionHistoryStage {
+		if msg, ok := msg.(tea.KeyMsg); ok && msg.String() == "esc" {
+			m.stage = transactionSelectionStage
+			return m, nil
+		}
+
+		m.transactionHistory, cmd = m.transactionHistory.Update(msg)
+		return m, cmd
 	} else {
 		m.table.model, cmd = m.table.model.Update(msg)
 	}
@@ -164,13 +401,13 @@ func (m transactionModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "right":
 			next := m.table.selectedMonth + 1
 			logger.Debug(fmt.Sprintf("Switch to %v", time.Date(m.table.selectedYear, next, 0, 0, 0, 0, 0, time.Local).Format("January 2006")))
-			return m, tea.Batch(cmd, command.SwitchTransactionsMonthCmd(m.db, m.account.ID, m.table.selectedYear, m.table.selectedMonth+1))
+			return m, tea.Batch(cmd, command.SwitchTransactionsMonthCmd(m.ctx, m.db, m.accountFilter(), m.table.selectedYear, m.table.selectedMonth+1))
 		case "left":
 			prev := m.table.selectedMonth - 1
 			logger.Debug(fmt.Sprintf("Switch to %v", time.Date(m.table.selectedYear, prev, 0, 0, 0, 0, 0, time.Local).Format("January 2006")))
-			return m, tea.Batch(cmd, command.SwitchTransactionsMonthCmd(m.db, m.account.ID, m.table.selectedYear, prev))
+			return m, tea.Batch(cmd, command.SwitchTransactionsMonthCmd(m.ctx, m.db, m.accountFilter(), m.table.selectedYear, prev))
 		case "r":
-			return m, tea.Batch(cmd, command.SwitchTransactionsMonthCmd(m.db, m.account.ID, time.Now().Year(), time.Now().Month()))
+			return m, tea.Batch(cmd, command.SwitchTransactionsMonthCmd(m.ctx, m.db, m.accountFilter(), time.Now().Year(), time.Now().Month()))
 		case "d":
 			if len(m.transactions) == 0 {
 				break
@@ -178,6 +415,18 @@ func (m transactionModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 			cursor := m.table.model.Cursor()
 			deletedTransaction := m.transactions[cursor]
+			if deletedTransaction.DeleteDateUnix.Valid {
+				m.err.msg = "transaction already deleted"
+				m.err.id = time.Now().UnixMicro()
+
+				return m, tea.Batch(command.HideErrorMessageCmd(m.err.id, m.err.msg), cmd)
+			}
+			if deletedTransaction.Status == ezex.StatusReconciled {
+				m.err.msg = ezex.ErrTransactionReconciled.Error()
+				m.err.id = time.Now().UnixMicro()
+
+				return m, tea.Batch(command.HideErrorMessageCmd(m.err.id, m.err.msg), cmd)
+			}
 			return m, tea.Batch(
 				command.DeleteTransactionCmd(
 					m.db, deletedTransaction.AccountID,
@@ -187,9 +436,110 @@ func (m transactionModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				),
 				cmd,
 			)
+		case "c":
+			if len(m.transactions) == 0 {
+				break
+			}
+
+			transaction := m.transactions[m.table.model.Cursor()]
+			if transaction.Status == ezex.StatusReconciled {
+				m.err.msg = ezex.ErrTransactionReconciled.Error()
+				m.err.id = time.Now().UnixMicro()
+
+				return m, tea.Batch(command.HideErrorMessageCmd(m.err.id, m.err.msg), cmd)
+			}
+
+			status := ezex.StatusCleared
+			if transaction.Status == ezex.StatusCleared {
+				status = ezex.StatusUncommitted
+			}
+			logger.Debug(fmt.Sprintf("Mark transaction ID %v as %v", transaction.ID, status))
+			return m, tea.Batch(command.SetTransactionStatusCmd(m.db, m.account.ID, transaction.ID, status), cmd)
+		case "R":
+			m.stage = transactionReconcileStage
+			m.reconcile = initReconcile(m.ctx, m.db, m.account.ID, m.balance).resize(m.size)
+			return m, textinput.Blink
 		case "n":
 			m.stage = transactionCreationStage
 			return m, textinput.Blink
+		case "e", "C":
+			if len(m.transactions) == 0 {
+				break
+			}
+
+			transaction := m.transactions[m.table.model.Cursor()]
+			errMsg := ""
+			switch {
+			case transaction.Status == ezex.StatusReconciled:
+				errMsg = ezex.ErrTransactionReconciled.Error()
+			case transaction.DeleteDateUnix.Valid:
+				errMsg = "deleted transactions can't be edited"
+			case transaction.SplitCount > 0:
+				errMsg = "split transactions can't be edited"
+			}
+			if errMsg != "" {
+				m.err.msg = errMsg
+				m.err.id = time.Now().UnixMicro()
+
+				return m, tea.Batch(command.HideErrorMessageCmd(m.err.id, m.err.msg), cmd)
+			}
+
+			stage := transactionEditDateStage
+			if msg.String() == "C" {
+				stage = transactionEditCategoryStage
+			}
+			m.stage = transactionEditStage
+			m.transactionEditor = initTransactionEditor(
+				m.db,
+				transaction,
+				m.transactionCreator.payees,
+				m.transactionCreator.categories,
+				stage,
+			)
+			return m, tea.Batch(textinput.Blink, cmd)
+		case "o":
+			if len(m.transactions) == 0 {
+				break
+			}
+
+			transaction := m.transactions[m.table.model.Cursor()]
+			if transaction.AttachmentCount == 0 {
+				m.err.msg = "the transaction has no attachments"
+				m.err.id = time.Now().UnixMicro()
+
+				return m, tea.Batch(command.HideErrorMessageCmd(m.err.id, m.err.msg), cmd)
+			}
+			return m, tea.Batch(command.OpenAttachmentsCmd(m.ctx, m.db, m.attachmentsDir, transaction.ID), cmd)
+		case "h":
+			if len(m.transactions) == 0 {
+				break
+			}
+
+			transaction := m.transactions[m.table.model.Cursor()]
+			m.stage = transactionHistoryStage
+			m.transactionHistory = initTransactionHistory(transaction.ID).resize(m.size)
+			return m, tea.Batch(command.TransactionHistoryCmd(m.ctx, m.db, transaction.ID), cmd)
+		case "u", "ctrl+r":
+			return m, tea.Batch(command.RequestUndoCmd(msg.String() == "ctrl+r"), cmd)
+		case "/":
+			m.stage = transactionSearchStage
+			m.transactionSearch = m.transactionSearch.reset()
+			return m, textinput.Blink
+		case "f":
+			m.stage = transactionFilterStage
+			return m, textinput.Blink
+		case "x":
+			if m.transactionFilter.summary() == "" {
+				break
+			}
+
+			m.filter = ezex.TransactionFilter{}
+			m.transactionFilter = m.transactionFilter.reset(nil)
+			return m, tea.Batch(
+				cmd,
+				command.TransactionFilterCmd(nil),
+				command.SwitchTransactionsMonthCmd(m.ctx, m.db, m.accountFilter(), m.table.selectedYear, m.table.selectedMonth),
+			)
 		case "down", "up":
 			r := m.table.model.SelectedRow()
 			if r != nil {
@@ -206,15 +556,35 @@ func (m transactionModel) View() string {
 	if m.stage == transactionCreationStage {
 		return m.transactionCreator.View()
 	}
+	if m.stage == transactionEditStage {
+		return fmt.Sprintf("Edit transaction %d\n\n", m.transactionEditor.transaction.ID) + m.transactionEditor.View()
+	}
+	if m.stage == transactionSearchStage {
+		return m.transactionSearch.View()
+	}
+	if m.stage == transactionFilterStage {
+		return "Filter transactions\n\n" + m.transactionFilter.View()
+	}
+	if m.stage == transactionReconcileStage {
+		return fmt.Sprintf("Reconcile %s\n\n", m.account.Name) + m.reconcile.View()
+	}
+	if m.stage == transactionHistoryStage {
+		return m.transactionHistory.View()
+	}
 
 	str := strings.Builder{}
 	str.WriteString(fmt.Sprintf("Account:\t(ID: %d) %s\n", m.account.ID, m.account.Name))
 	if m.account.Description.Valid {
 		str.WriteString(fmt.Sprintf("Description:\t%s\n", m.account.Description.String))
 	}
-	str.WriteString(fmt.Sprintf("Balance:\t%s\n\n", encodeCents(m.account.BalanceInCents, false)))
+	str.WriteString(fmt.Sprintf("Balance:\t%s\n", encodeBalance(m.account.BalanceInCents)))
+	str.WriteString(fmt.Sprintf("Cleared:\t%s\n", encodeBalance(m.balance.ClearedInCents)))
+	str.WriteString(fmt.Sprintf("Working:\t%s\n\n", encodeBalance(m.balance.WorkingInCents)))
 	str.WriteString(fmt.Sprintf("Month:\t\t%s %d\n", m.table.selectedMonth.String(), m.table.selectedYear))
 	str.WriteString(fmt.Sprintf("Count:\t\t%d\n", len(m.transactions)))
+	if summary := m.transactionFilter.summary(); summary != "" {
+		str.WriteString(fmt.Sprintf("Filter:\t\t%s\n", summary))
+	}
 	str.WriteString(baseStyle.Render(m.table.model.View()) + "\n")
 	str.WriteString(transactionTableKeySuggestions)
 
@@ -228,16 +598,88 @@ func (m transactionModel) View() string {
 func (m transactionModel) createTransactionsTable(transactions []ezex.TransactionView) transactionModel {
 	m.newTransaction = ezex.Transaction{}
 	m.transactions = transactions
-	m.table.model = createStandardTable(
-		[]table.Column{
-			{Title: "ID", Width: 5},
-			{Title: "Date", Width: 10},
-			{Title: "Amount", Width: 10},
-			{Title: "Payee", Width: 20},
-			{Title: "Category", Width: 20},
-			{Title: "Notes", Width: 40},
-		},
-		transactionsToTableRows(transactions...),
-	)
+	m.table.model = createStandardTable(transactionTableColumns, transactionsToTableRows(transactions...))
+	return m.resize()
+}
+
+// resize fits the tables of every stage in the terminal
+func (m transactionModel) resize() transactionModel {
+	selection := m
+	selection.stage = transactionSelectionStage
+	m.table.model = fitTable(m.table.model, transactionTableColumns, selection.View(), m.size)
+	m.transactionSearch = m.transactionSearch.resize(m.size)
+	m.reconcile = m.reconcile.resize(m.size)
+	m.transactionHistory = m.transactionHistory.resize(m.size)
+
 	return m
 }
+
+func (m transactionModel) handleSearchCommands(msg tea.Msg) (transactionModel, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "esc":
+			m.stage = transactionSelectionStage
+			return m, nil
+		case "enter":
+			result, ok := m.transactionSearch.selected()
+			if !ok {
+				return m, nil
+			}
+
+			logger.Debug(fmt.Sprintf("Jump to transaction ID %v (account ID %v)", result.ID, result.AccountID))
+			if result.AccountID == m.account.ID {
+				return m, command.JumpToTransactionCmd(m.ctx, m.db, m.filter, result)
+			}
+
+			return m, tea.Sequence(
+				command.SwitchModelCmd(transactionModelID, result.AccountID),
+				command.JumpToTransactionCmd(m.ctx, m.db, m.filter, result),
+			)
+		}
+	}
+
+	var cmd tea.Cmd
+	m.transactionSearch, cmd = m.transactionSearch.Update(msg)
+
+	return m, cmd
+}
+
+func (m transactionModel) handleFilterCommands(msg tea.Msg) (transactionModel, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "esc":
+			// Discard the changes, restoring the applied filter
+			m.transactionFilter = m.transactionFilter.reset(m.transactionFilter.appliedValues)
+			m.stage = transactionSelectionStage
+			return m, nil
+		case "enter":
+			if !m.transactionFilter.isValid() {
+				return m, nil
+			}
+
+			values := m.transactionFilter.values()
+			m.transactionFilter.appliedValues = values
+			m.filter = m.transactionFilter.filter()
+			m.stage = transactionSelectionStage
+			logger.Debug(fmt.Sprintf("Apply transactions filter: %s", m.transactionFilter.summary()))
+
+			return m, tea.Batch(
+				command.TransactionFilterCmd(values),
+				command.SwitchTransactionsMonthCmd(m.ctx, m.db, m.accountFilter(), m.table.selectedYear, m.table.selectedMonth),
+			)
+		}
+	}
+
+	var cmd tea.Cmd
+	m.transactionFilter, cmd = m.transactionFilter.Update(msg)
+
+	return m, cmd
+}
+
+// accountFilter returns the applied filter restricted to the current account
+func (m transactionModel) accountFilter() ezex.TransactionFilter {
+	filter := m.filter
+	filter.AccountIDs = []int{m.account.ID}
+
+	return filter
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/armanimichael/ez-ex/cmd/ez-ex-cli/command"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"strings"
 	"time"
 )
 
@@ -16,19 +17,37 @@ type transactionCreatorModel struct {
 	accountID  int
 	payees     []ezex.Payee
 	categories []ezex.Category
+	tags       []ezex.Tag
+	// classifier suggests the category when left empty, learned from the transactions history
+	classifier *ezex.CategoryClassifier
 	inputs     []standardTextInput
-	suggestion struct {
+	// splitting is set while the split editor is shown
+	splitting   bool
+	splitEditor transactionSplitModel
+	// duplicateWarning is set when a likely duplicate exists, the next {enter} saves anyway
+	duplicateWarning string
+	suggestion       struct {
 		autocompleteSuggestion string
 		payee                  ezex.Payee
 		category               ezex.Category
 	}
 }
 
+var transactionCreatorKeySuggestions = formatKeySuggestions([][]string{
+	{"{enter}", "create transaction"},
+	{"{tab}", "autocomplete"},
+	{"^S", "split across categories"},
+})
+
+// categorySuggestionMinConfidence is the lowest confidence of the learned category suggestions shown
+const categorySuggestionMinConfidence = 0.5
+
 const (
 	transactionDateStage = iota
 	transactionAmountStage
 	transactionPayeeStage
 	transactionCategoryStage
+	transactionTagsStage
 	transactionNoteStage
 )
 
@@ -37,12 +56,15 @@ func initTransactionCreator(
 	accountID int,
 	payees []ezex.Payee,
 	categories []ezex.Category,
+	tags []ezex.Tag,
+	classifier *ezex.CategoryClassifier,
 ) transactionCreatorModel {
-	inputs := make([]standardTextInput, 5)
+	inputs := make([]standardTextInput, 6)
 	inputs[transactionDateStage] = createTransactionInput(transactionDateStage)
 	inputs[transactionAmountStage] = createTransactionInput(transactionAmountStage)
 	inputs[transactionPayeeStage] = createTransactionInput(transactionPayeeStage)
 	inputs[transactionCategoryStage] = createTransactionInput(transactionCategoryStage)
+	inputs[transactionTagsStage] = createTransactionInput(transactionTagsStage)
 	inputs[transactionNoteStage] = createTransactionInput(transactionNoteStage)
 
 	return transactionCreatorModel{
@@ -55,16 +77,26 @@ func initTransactionCreator(
 			payee                  ezex.Payee
 			category               ezex.Category
 		}{autocompleteSuggestion: ""},
-		payees:     payees,
-		categories: categories,
+		payees:      payees,
+		categories:  categories,
+		tags:        tags,
+		classifier:  classifier,
+		splitEditor: initTransactionSplit(categories),
 	}
 }
 
 func (m transactionCreatorModel) Update(msg tea.Msg) (transactionCreatorModel, tea.Cmd) {
 	var cmd tea.Cmd
 
+	if m.splitting {
+		return m.handleSplitEditorCommands(msg)
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		warned := m.duplicateWarning != ""
+		m.duplicateWarning = ""
+
 		switch msg.String() {
 		case "enter":
 			if !areStandardTextInputsValid(m.inputs) {
@@ -72,29 +104,58 @@ func (m transactionCreatorModel) Update(msg tea.Msg) (transactionCreatorModel, t
 			}
 
 			notes := m.inputs[transactionNoteStage].model.Value()
+			category := ezex.Category{}
+			if path := strings.TrimSpace(m.inputs[transactionCategoryStage].model.Value()); path != "" {
+				category, _ = findCategory(m.categories, path)
+			}
+			if len(m.splitEditor.splits) > 0 {
+				// Split transactions use the splits categories
+				category = ezex.Category{}
+			}
+
+			transaction := ezex.Transaction{
+				CategoryID:          category.ID,
+				PayeeID:             m.suggestion.payee.ID,
+				AccountID:           m.accountID,
+				AmountInCents:       decodeCents(m.inputs[transactionAmountStage].model.Value()),
+				TransactionDateUnix: decodeUnixDate(m.inputs[transactionDateStage].model.Value()),
+				Notes: sql.NullString{
+					String: notes,
+					Valid:  notes != "",
+				},
+			}
+
+			// New payees can't have duplicates
+			if !warned && transaction.PayeeID != 0 {
+				duplicates := ezex.FindTransactionDuplicates(m.db, transaction, ezex.DuplicateMaxDays)
+				if len(duplicates) > 0 {
+					m.duplicateWarning = fmt.Sprintf(
+						"Possible duplicate of %s %s %s (%d similar), press {enter} again to save anyway",
+						encodeUnixDate(duplicates[0].TransactionDateUnix),
+						duplicates[0].PayeeName,
+						encodeCents(duplicates[0].AmountInCents, false),
+						len(duplicates),
+					)
+					return m, nil
+				}
+			}
 
 			// Create new transaction
 			return m, command.CreateNewTransactionCmd(
 				m.db,
-				ezex.Transaction{
-					CategoryID:          m.suggestion.category.ID,
-					PayeeID:             m.suggestion.payee.ID,
-					AccountID:           m.accountID,
-					AmountInCents:       decodeCents(m.inputs[transactionAmountStage].model.Value()),
-					TransactionDateUnix: decodeUnixDate(m.inputs[transactionDateStage].model.Value()),
-					Notes: sql.NullString{
-						String: notes,
-						Valid:  notes != "",
-					},
-				},
+				transaction,
 				ezex.Payee{
 					ID:   m.suggestion.payee.ID,
 					Name: m.inputs[transactionPayeeStage].model.Value(),
 				},
-				ezex.Category{
-					ID:   m.suggestion.category.ID,
-					Name: m.inputs[transactionCategoryStage].model.Value(),
-				})
+				category,
+				m.splitEditor.splits,
+				ezex.ParseTags(m.inputs[transactionTagsStage].model.Value()),
+			)
+		case "ctrl+s":
+			m.splitting = true
+			m.splitEditor.amountInCents = m.amountInCents()
+			return m, textinput.Blink
 		case "tab":
 			if m.suggestion.autocompleteSuggestion == "" {
 				break
@@ -105,8 +166,12 @@ func (m transactionCreatorModel) Update(msg tea.Msg) (transactionCreatorModel, t
 				m.inputs[m.stage].model.SetValue(m.suggestion.payee.Name)
 				m.inputs[m.stage].model.SetCursor(len(m.suggestion.payee.Name))
 			case transactionCategoryStage:
-				m.inputs[m.stage].model.SetValue(m.suggestion.category.Name)
-				m.inputs[m.stage].model.SetCursor(len(m.suggestion.category.Name))
+				m.inputs[m.stage].model.SetValue(m.suggestion.category.Path)
+				m.inputs[m.stage].model.SetCursor(len(m.suggestion.category.Path))
+			case transactionTagsStage:
+				value := m.inputs[m.stage].model.Value() + m.suggestion.autocompleteSuggestion
+				m.inputs[m.stage].model.SetValue(value)
+				m.inputs[m.stage].model.SetCursor(len(value))
 			}
 
 			m.suggestion.autocompleteSuggestion = ""
@@ -133,6 +198,7 @@ func (m transactionCreatorModel) Update(msg tea.Msg) (transactionCreatorModel, t
 			m.suggestion.payee.ID = 0
 		} else if m.stage == transactionCategoryStage {
 			m.suggestion.category.ID = 0
+			m = m.suggestLearnedCategory()
 		}
 
 		return m, cmd
@@ -158,15 +224,27 @@ func (m transactionCreatorModel) Update(msg tea.Msg) (transactionCreatorModel, t
 			break
 		}
 
-		if match, ok := autocomplete(m.categories, val); ok {
-			m.suggestion.autocompleteSuggestion = match.Name[len(val):]
-			m.suggestion.category = *match
+		if match, ok := autocomplete(categoryPaths(m.categories), val); ok {
+			m.suggestion.autocompleteSuggestion = match.Path[len(val):]
+			m.suggestion.category = match.Category
 		} else {
 			m.suggestion.autocompleteSuggestion = ""
-			if val != m.suggestion.category.Name {
+			if val != m.suggestion.category.Path {
 				m.suggestion.category.ID = 0
 			}
 		}
+	case transactionTagsStage:
+		// Only the tag being typed (the last one) is autocompleted
+		m.suggestion.autocompleteSuggestion = ""
+		fields := strings.Fields(val)
+		if len(fields) == 0 || strings.HasSuffix(val, " ") || strings.HasSuffix(val, ",") {
+			break
+		}
+
+		name := strings.TrimLeft(fields[len(fields)-1], "#,")
+		if match, ok := autocomplete(m.tags, name); ok && name != "" {
+			m.suggestion.autocompleteSuggestion = match.Name[len(name):]
+		}
 	default:
 		m.suggestion.autocompleteSuggestion = ""
 	}
@@ -175,7 +253,53 @@ func (m transactionCreatorModel) Update(msg tea.Msg) (transactionCreatorModel, t
 }
 
 func (m transactionCreatorModel) View() string {
-	return standardTextInputView(m.stage, m.inputs, m.suggestion.autocompleteSuggestion)
+	if m.splitting {
+		return m.splitEditor.View()
+	}
+
+	view := standardTextInputView(m.stage, m.inputs, m.suggestion.autocompleteSuggestion)
+	if n := len(m.splitEditor.splits); n > 0 {
+		view += fmt.Sprintf("\n\nSplit in %d lines, remaining %s", n, encodeCents(m.splitEditor.remainingInCents(), false))
+	}
+	if m.duplicateWarning != "" {
+		view += "\n\n" + warningMessageStyle.Render(m.duplicateWarning)
+	}
+
+	return view + "\n\n" + transactionCreatorKeySuggestions
+}
+
+func (m transactionCreatorModel) handleSplitEditorCommands(msg tea.Msg) (transactionCreatorModel, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok && msg.String() == "esc" {
+		m.splitting = false
+		m.inputs[transactionCategoryStage].errorMsg = m.validateInput(transactionCategoryStage)
+		return m, textinput.Blink
+	}
+
+	var cmd tea.Cmd
+	m.splitEditor, cmd = m.splitEditor.Update(msg)
+
+	return m, cmd
+}
+
+// amountInCents returns the amount input value, 0 if invalid
+func (m transactionCreatorModel) amountInCents() int64 {
+	return decodeCents(m.inputs[transactionAmountStage].model.Value())
+}
+
+// setEntities updates the payees, categories, tags and classifier used for autocompletion
+func (m transactionCreatorModel) setEntities(
+	payees []ezex.Payee,
+	categories []ezex.Category,
+	tags []ezex.Tag,
+	classifier *ezex.CategoryClassifier,
+) transactionCreatorModel {
+	m.payees = payees
+	m.categories = categories
+	m.tags = tags
+	m.classifier = classifier
+	m.splitEditor.categories = categories
+
+	return m
 }
 
 func (m transactionCreatorModel) switchTransaction(msg fmt.Stringer) (transactionCreatorModel, tea.Cmd) {
@@ -184,15 +308,51 @@ func (m transactionCreatorModel) switchTransaction(msg fmt.Stringer) (transactio
 	m.inputs[m.stage].model.SetCursor(0)
 	m.inputs[m.stage].model.Focus()
 
+	m.suggestion.autocompleteSuggestion = ""
+	if m.stage == transactionCategoryStage && m.inputs[m.stage].model.Value() == "" {
+		m = m.suggestLearnedCategory()
+	}
+
 	return m, textinput.Blink
 }
 
+// suggestLearnedCategory suggests the category predicted by the classifier from the other inputs,
+// split transactions and predictions below categorySuggestionMinConfidence aren't suggested
+func (m transactionCreatorModel) suggestLearnedCategory() transactionCreatorModel {
+	if len(m.splitEditor.splits) > 0 {
+		return m
+	}
+
+	notes := m.inputs[transactionNoteStage].model.Value()
+	suggestion, ok := m.classifier.Suggest(
+		m.inputs[transactionPayeeStage].model.Value(),
+		ezex.Transaction{
+			AccountID:     m.accountID,
+			AmountInCents: m.amountInCents(),
+			Notes:         sql.NullString{String: notes, Valid: notes != ""},
+		},
+	)
+	if !ok || suggestion.Confidence < categorySuggestionMinConfidence {
+		return m
+	}
+
+	for _, category := range m.categories {
+		if category.ID == suggestion.CategoryID {
+			m.suggestion.autocompleteSuggestion = category.Path
+			m.suggestion.category = category
+			break
+		}
+	}
+
+	return m
+}
+
 func (m transactionCreatorModel) validateInput(stage int) string {
 	currentInput := &m.inputs[stage]
 	value := currentInput.model.Value()
 
-	// Avoid multiple checks for same inputs (because of update)
-	if value == currentInput.previousInput && currentInput.previousInput != "" {
+	// Avoid multiple checks for same inputs (because of update), splits depend on the amount so they're always checked
+	if value == currentInput.previousInput && currentInput.previousInput != "" && stage != transactionCategoryStage {
 		return currentInput.errorMsg
 	}
 
@@ -202,13 +362,24 @@ func (m transactionCreatorModel) validateInput(stage int) string {
 			return err.Error()
 		}
 	case transactionAmountStage:
-		if !moneyFormatRegex.MatchString(value) {
-			return "invalid amount format, should look like `0.00` or `-0.00`"
+		if err := validateAmount(value); err != nil {
+			return err.Error()
 		}
 	case transactionPayeeStage:
 		if value == "" {
 			return "payee field is required"
 		}
+	case transactionCategoryStage:
+		if len(m.splitEditor.splits) == 0 {
+			if err := validateCategoryPath(value); value != "" && err != nil {
+				return err.Error()
+			}
+			break
+		}
+
+		if remaining := m.splitEditor.remainingInCents(); remaining != 0 {
+			return fmt.Sprintf("splits don't add up to the amount, %s left to allocate", encodeCents(remaining, false))
+		}
 	}
 
 	return ""
@@ -219,11 +390,15 @@ func (m transactionCreatorModel) reset() transactionCreatorModel {
 	m.suggestion.payee.ID = 0
 	m.suggestion.category.ID = 0
 	m.suggestion.autocompleteSuggestion = ""
+	m.splitting = false
+	m.splitEditor = m.splitEditor.reset(m.categories)
+	m.duplicateWarning = ""
 
 	m.inputs[transactionDateStage] = createTransactionInput(transactionDateStage)
 	m.inputs[transactionAmountStage] = createTransactionInput(transactionAmountStage)
 	m.inputs[transactionPayeeStage] = createTransactionInput(transactionPayeeStage)
 	m.inputs[transactionCategoryStage] = createTransactionInput(transactionCategoryStage)
+	m.inputs[transactionTagsStage] = createTransactionInput(transactionTagsStage)
 	m.inputs[transactionNoteStage] = createTransactionInput(transactionNoteStage)
 	return m
 }
@@ -268,6 +443,14 @@ func createTransactionInput(stage int) standardTextInput {
 			errorMsg: "",
 			label:    "Category",
 		}
+	case transactionTagsStage:
+		ti.Placeholder = "#tag1 #tag2"
+
+		return standardTextInput{
+			model:    ti,
+			errorMsg: "",
+			label:    "Tags",
+		}
 	case transactionNoteStage:
 		ti.Placeholder = "<NO NOTES>"
 
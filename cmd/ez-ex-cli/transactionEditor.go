@@ -0,0 +1,268 @@
+package main
+
+import (
+	"database/sql"
+	ezex "github.com/armanimichael/ez-ex"
+	"github.com/armanimichael/ez-ex/cmd/ez-ex-cli/command"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"strings"
+)
+
+// transactionEditorModel edits the date, amount, payee, category and notes of an existing transaction,
+// split transactions are edited through their splits only
+type transactionEditorModel struct {
+	db          *sql.DB
+	stage       int
+	transaction ezex.Transaction
+	payees      []ezex.Payee
+	categories  []ezex.Category
+	inputs      []standardTextInput
+	suggestion  struct {
+		autocompleteSuggestion string
+		payee                  ezex.Payee
+		category               ezex.Category
+	}
+}
+
+var transactionEditorKeySuggestions = formatKeySuggestions([][]string{
+	{"{enter}", "save transaction"},
+	{"{tab}", "autocomplete"},
+	{"{esc}", "cancel"},
+})
+
+const (
+	transactionEditDateStage = iota
+	transactionEditAmountStage
+	transactionEditPayeeStage
+	transactionEditCategoryStage
+	transactionEditNoteStage
+)
+
+// initTransactionEditor creates the editor of transaction, focusing the input of stage
+func initTransactionEditor(
+	db *sql.DB,
+	transaction ezex.TransactionView,
+	payees []ezex.Payee,
+	categories []ezex.Category,
+	stage int,
+) transactionEditorModel {
+	m := transactionEditorModel{
+		db:    db,
+		stage: stage,
+		transaction: ezex.Transaction{
+			ID:                  transaction.ID,
+			CategoryID:          transaction.CategoryID,
+			PayeeID:             transaction.PayeeID,
+			AccountID:           transaction.AccountID,
+			AmountInCents:       transaction.AmountInCents,
+			TransactionDateUnix: transaction.TransactionDateUnix,
+			UpdateDateUnix:      transaction.UpdateDateUnix,
+			DeleteDateUnix:      transaction.DeleteDateUnix,
+			Notes:               transaction.Notes,
+			Status:              transaction.Status,
+		},
+		payees:     payees,
+		categories: categories,
+	}
+	m.suggestion.payee = ezex.Payee{ID: transaction.PayeeID, Name: transaction.PayeeName}
+
+	categoryPath := ""
+	for _, category := range categories {
+		if category.ID == transaction.CategoryID && category.ID != 0 {
+			m.suggestion.category = category
+			categoryPath = category.Path
+			break
+		}
+	}
+
+	values := []string{
+		encodeUnixDate(transaction.TransactionDateUnix),
+		encodeCents(transaction.AmountInCents, false),
+		transaction.PayeeName,
+		categoryPath,
+		transaction.Notes.String,
+	}
+	m.inputs = make([]standardTextInput, len(values))
+	for i, creationStage := range []int{
+		transactionDateStage,
+		transactionAmountStage,
+		transactionPayeeStage,
+		transactionCategoryStage,
+		transactionNoteStage,
+	} {
+		m.inputs[i] = createTransactionInput(creationStage)
+		m.inputs[i].model.Blur()
+		m.inputs[i].model.SetValue(values[i])
+		m.inputs[i].previousInput = values[i]
+	}
+	m.inputs[m.stage].model.Focus()
+
+	return m
+}
+
+func (m transactionEditorModel) Update(msg tea.Msg) (transactionEditorModel, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "enter":
+			if !areStandardTextInputsValid(m.inputs) {
+				break
+			}
+
+			category := ezex.Category{}
+			if path := strings.TrimSpace(m.inputs[transactionEditCategoryStage].model.Value()); path != "" {
+				category, _ = findCategory(m.categories, path)
+			}
+			notes := m.inputs[transactionEditNoteStage].model.Value()
+
+			transaction := m.transaction
+			transaction.CategoryID = category.ID
+			transaction.PayeeID = m.suggestion.payee.ID
+			transaction.AmountInCents = decodeCents(m.inputs[transactionEditAmountStage].model.Value())
+			// The time of the day is kept unless the date changes
+			if date := m.inputs[transactionEditDateStage].model.Value(); date != encodeUnixDate(m.transaction.TransactionDateUnix) {
+				transaction.TransactionDateUnix = decodeUnixDate(date)
+			}
+			transaction.Notes = sql.NullString{String: notes, Valid: notes != ""}
+
+			return m, command.EditTransactionCmd(
+				m.db,
+				m.transaction,
+				transaction,
+				ezex.Payee{
+					ID:   m.suggestion.payee.ID,
+					Name: m.inputs[transactionEditPayeeStage].model.Value(),
+				},
+				category,
+			)
+		case "tab":
+			if m.suggestion.autocompleteSuggestion == "" {
+				break
+			}
+
+			switch m.stage {
+			case transactionEditPayeeStage:
+				m.inputs[m.stage].model.SetValue(m.suggestion.payee.Name)
+				m.inputs[m.stage].model.SetCursor(len(m.suggestion.payee.Name))
+			case transactionEditCategoryStage:
+				m.inputs[m.stage].model.SetValue(m.suggestion.category.Path)
+				m.inputs[m.stage].model.SetCursor(len(m.suggestion.category.Path))
+			}
+
+			m.suggestion.autocompleteSuggestion = ""
+		case "up", "down":
+			m.inputs[m.stage].model.Blur()
+			m.stage = handleSwitchInputStage(msg.String() == "up", m.stage, transactionEditDateStage, transactionEditNoteStage)
+			m.inputs[m.stage].model.SetCursor(0)
+			m.inputs[m.stage].model.Focus()
+			m.suggestion.autocompleteSuggestion = ""
+
+			return m, textinput.Blink
+		}
+	}
+
+	for i := range m.inputs {
+		errMsg := m.validateInput(i)
+		m.inputs[i].previousInput = m.inputs[i].model.Value()
+		m.inputs[i].errorMsg = errMsg
+	}
+	currentInput := &m.inputs[m.stage]
+	currentInput.model, cmd = currentInput.model.Update(msg)
+
+	// No value = no autosuggestion
+	val := currentInput.model.Value()
+	prevVal := currentInput.previousInput
+	if val == "" {
+		m.suggestion.autocompleteSuggestion = ""
+
+		if m.stage == transactionEditPayeeStage {
+			m.suggestion.payee.ID = 0
+		} else if m.stage == transactionEditCategoryStage {
+			m.suggestion.category.ID = 0
+		}
+
+		return m, cmd
+	}
+
+	switch m.stage {
+	case transactionEditPayeeStage:
+		if val == prevVal {
+			break
+		}
+
+		if match, ok := autocomplete(m.payees, val); ok {
+			m.suggestion.autocompleteSuggestion = match.Name[len(val):]
+			m.suggestion.payee = *match
+		} else {
+			m.suggestion.autocompleteSuggestion = ""
+			if val != m.suggestion.payee.Name {
+				m.suggestion.payee.ID = 0
+			}
+		}
+	case transactionEditCategoryStage:
+		if val == prevVal {
+			break
+		}
+
+		if match, ok := autocomplete(categoryPaths(m.categories), val); ok {
+			m.suggestion.autocompleteSuggestion = match.Path[len(val):]
+			m.suggestion.category = match.Category
+		} else {
+			m.suggestion.autocompleteSuggestion = ""
+			if val != m.suggestion.category.Path {
+				m.suggestion.category.ID = 0
+			}
+		}
+	default:
+		m.suggestion.autocompleteSuggestion = ""
+	}
+
+	return m, cmd
+}
+
+func (m transactionEditorModel) View() string {
+	return standardTextInputView(m.stage, m.inputs, m.suggestion.autocompleteSuggestion) +
+		"\n\n" + transactionEditorKeySuggestions
+}
+
+func (m transactionEditorModel) validateInput(stage int) string {
+	currentInput := &m.inputs[stage]
+	value := currentInput.model.Value()
+
+	// Avoid multiple checks for same inputs (because of update)
+	if value == currentInput.previousInput && currentInput.previousInput != "" {
+		return currentInput.errorMsg
+	}
+
+	switch stage {
+	case transactionEditDateStage:
+		if err := validateDateString(value); err != nil {
+			return err.Error()
+		}
+	case transactionEditAmountStage:
+		if err := validateAmount(value); err != nil {
+			return err.Error()
+		}
+	case transactionEditPayeeStage:
+		if value == "" {
+			return "payee field is required"
+		}
+	case transactionEditCategoryStage:
+		if err := validateCategoryPath(value); value != "" && err != nil {
+			return err.Error()
+		}
+	}
+
+	return ""
+}
+
+// recategorized reports whether the category is the only change from before to after
+func recategorized(before ezex.Transaction, after ezex.Transaction) bool {
+	changed := before.CategoryID != after.CategoryID
+	before.CategoryID = after.CategoryID
+
+	return changed && before == after
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	ezex "github.com/armanimichael/ez-ex"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"strings"
+)
+
+// transactionFilterModel is the filter bar of the transactions screen, the filter is only applied by the parent model
+type transactionFilterModel struct {
+	stage      int
+	payees     []ezex.Payee
+	categories []ezex.Category
+	inputs     []standardTextInput
+	// appliedValues are the inputs of the filter currently applied, restored when editing is cancelled
+	appliedValues []string
+}
+
+const (
+	filterCategoriesStage = iota
+	filterPayeesStage
+	filterMinAmountStage
+	filterMaxAmountStage
+	filterSignStage
+	filterTextStage
+	filterDeletedStage
+)
+
+const filterStagesCount = filterDeletedStage + 1
+
+var transactionFilterKeySuggestions = formatKeySuggestions([][]string{
+	{"{enter}", "apply filter"},
+	{"{esc}", "cancel"},
+	{"{up}/{down}", "switch field"},
+})
+
+// initTransactionFilter creates the filter bar, restoring the inputs of a previous filter (see values) if any
+func initTransactionFilter(payees []ezex.Payee, categories []ezex.Category, values []string) transactionFilterModel {
+	m := transactionFilterModel{
+		payees:     payees,
+		categories: categories,
+		inputs:     make([]standardTextInput, filterStagesCount),
+	}
+
+	return m.reset(values)
+}
+
+func (m transactionFilterModel) Update(msg tea.Msg) (transactionFilterModel, tea.Cmd) {
+	var cmd tea.Cmd
+
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "up", "down":
+			m.inputs[m.stage].model.Blur()
+			m.stage = handleSwitchInputStage(msg.String() == "up", m.stage, filterCategoriesStage, filterDeletedStage)
+			m.inputs[m.stage].model.SetCursor(0)
+			m.inputs[m.stage].model.Focus()
+
+			return m, textinput.Blink
+		}
+	}
+
+	currentInput := &m.inputs[m.stage]
+	currentInput.model, cmd = currentInput.model.Update(msg)
+
+	for i := range m.inputs {
+		m.inputs[i].errorMsg = m.validateInput(i)
+		m.inputs[i].previousInput = m.inputs[i].model.Value()
+	}
+
+	return m, cmd
+}
+
+func (m transactionFilterModel) View() string {
+	return standardTextInputView(m.stage, m.inputs, "") + "\n\n" + transactionFilterKeySuggestions
+}
+
+// reset sets the inputs to the given (applied) values (see values), missing values are left empty
+func (m transactionFilterModel) reset(values []string) transactionFilterModel {
+	m.stage = filterCategoriesStage
+	m.appliedValues = values
+	for i := range m.inputs {
+		m.inputs[i] = createFilterInput(i)
+		if i < len(values) {
+			m.inputs[i].model.SetValue(values[i])
+		}
+	}
+	m.inputs[m.stage].model.Focus()
+
+	return m
+}
+
+// values returns the raw inputs, to be restored with reset
+func (m transactionFilterModel) values() []string {
+	values := make([]string, len(m.inputs))
+	for i, input := range m.inputs {
+		values[i] = strings.TrimSpace(input.model.Value())
+	}
+
+	return values
+}
+
+func (m transactionFilterModel) isValid() bool {
+	for i := range m.inputs {
+		if m.validateInput(i) != "" {
+			return false
+		}
+	}
+
+	return true
+}
+
+// filter converts the inputs to a library filter, the inputs must be valid
+func (m transactionFilterModel) filter() ezex.TransactionFilter {
+	values := m.values()
+	filter := ezex.TransactionFilter{
+		Text:           values[filterTextStage],
+		IncludeDeleted: values[filterDeletedStage] == "yes",
+	}
+
+	for _, name := range splitNames(values[filterCategoriesStage]) {
+		category, _ := findCategory(m.categories, name)
+		filter.CategoryIDs = append(filter.CategoryIDs, category.ID)
+	}
+	for _, name := range splitNames(values[filterPayeesStage]) {
+		payee, _ := findByName(m.payees, name)
+		filter.PayeeIDs = append(filter.PayeeIDs, payee.ID)
+	}
+
+	if value := values[filterMinAmountStage]; value != "" {
+		filter.MinAmountInCents = sql.NullInt64{Int64: decodeCents(value), Valid: true}
+	}
+	if value := values[filterMaxAmountStage]; value != "" {
+		filter.MaxAmountInCents = sql.NullInt64{Int64: decodeCents(value), Valid: true}
+	}
+
+	switch values[filterSignStage] {
+	case "income":
+		filter.Sign = ezex.IncomeAmount
+	case "expense":
+		filter.Sign = ezex.ExpenseAmount
+	}
+
+	return filter
+}
+
+// summary describes the non-empty filter inputs, empty if no filter is set
+func (m transactionFilterModel) summary() string {
+	var parts []string
+	for i, value := range m.values() {
+		if value != "" {
+			parts = append(parts, fmt.Sprintf("%s %s", strings.ToLower(m.inputs[i].label), value))
+		}
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+func (m transactionFilterModel) validateInput(stage int) string {
+	value := strings.TrimSpace(m.inputs[stage].model.Value())
+	if value == "" {
+		return ""
+	}
+
+	switch stage {
+	case filterCategoriesStage:
+		for _, name := range splitNames(value) {
+			if _, ok := findCategory(m.categories, name); !ok {
+				return fmt.Sprintf("unknown category: %s", name)
+			}
+		}
+	case filterPayeesStage:
+		for _, name := range splitNames(value) {
+			if _, ok := findByName(m.payees, name); !ok {
+				return fmt.Sprintf("unknown payee: %s", name)
+			}
+		}
+	case filterMinAmountStage, filterMaxAmountStage:
+		if err := validateAmount(value); err != nil {
+			return err.Error()
+		}
+	case filterSignStage:
+		if value != "income" && value != "expense" {
+			return "type should be `income`, `expense` or empty"
+		}
+	case filterDeletedStage:
+		if value != "yes" && value != "no" {
+			return "deleted should be `yes`, `no` or empty"
+		}
+	}
+
+	return ""
+}
+
+// splitNames splits a comma separated list of names, ignoring empty ones
+func splitNames(value string) []string {
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+func createFilterInput(stage int) standardTextInput {
+	ti := textinput.New()
+	ti.Prompt = ""
+
+	switch stage {
+	case filterCategoriesStage:
+		ti.Placeholder = "<ANY> (comma separated)"
+		return standardTextInput{model: ti, label: "Categories"}
+	case filterPayeesStage:
+		ti.Placeholder = "<ANY> (comma separated)"
+		return standardTextInput{model: ti, label: "Payees"}
+	case filterMinAmountStage:
+		ti.Placeholder = "<ANY>"
+		return standardTextInput{model: ti, label: "Min amount"}
+	case filterMaxAmountStage:
+		ti.Placeholder = "<ANY>"
+		return standardTextInput{model: ti, label: "Max amount"}
+	case filterSignStage:
+		ti.Placeholder = "<ANY> (income / expense)"
+		return standardTextInput{model: ti, label: "Type"}
+	case filterTextStage:
+		ti.Placeholder = "<ANY>"
+		return standardTextInput{model: ti, label: "Text"}
+	case filterDeletedStage:
+		ti.Placeholder = "no (yes / no)"
+		return standardTextInput{model: ti, label: "Deleted"}
+	}
+
+	panic("unsupported transaction filter stage")
+}
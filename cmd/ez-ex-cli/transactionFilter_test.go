@@ -0,0 +1,54 @@
+package main
+
+import (
+	"database/sql"
+	ezex "github.com/armanimichael/ez-ex"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTransactionFilterModel_Filter(t *testing.T) {
+	m := initTransactionFilter(
+		[]ezex.Payee{{ID: 1, Name: "Grocer"}, {ID: 2, Name: "Pharmacy"}},
+		[]ezex.Category{
+			{ID: 0, Name: "no category", Path: "no category"},
+			{ID: 3, Name: "Food", Path: "Food"},
+			{ID: 4, Name: "Fruit", ParentID: 3, Path: "Food:Fruit", Depth: 1},
+		},
+		[]string{"food, No Category, food:fruit", "grocer", "-10.00", "0.00", "expense", "milk", "yes"},
+	)
+
+	assert.True(t, m.isValid())
+	assert.Equal(t, ezex.TransactionFilter{
+		CategoryIDs:      []int{3, 0, 4},
+		PayeeIDs:         []int{1},
+		MinAmountInCents: sql.NullInt64{Int64: -1000, Valid: true},
+		MaxAmountInCents: sql.NullInt64{Int64: 0, Valid: true},
+		Sign:             ezex.ExpenseAmount,
+		Text:             "milk",
+		IncludeDeleted:   true,
+	}, m.filter())
+}
+
+func TestTransactionFilterModel_Validation(t *testing.T) {
+	cases := []struct {
+		name   string
+		values []string
+	}{
+		{"unknown category", []string{"Travel"}},
+		{"unknown payee", []string{"", "Airline"}},
+		{"invalid min amount", []string{"", "", "0.125"}},
+		{"invalid max amount", []string{"", "", "", "abc"}},
+		{"invalid type", []string{"", "", "", "", "both"}},
+		{"invalid deleted", []string{"", "", "", "", "", "", "maybe"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			m := initTransactionFilter(nil, nil, c.values)
+			assert.False(t, m.isValid())
+		})
+	}
+
+	assert.True(t, initTransactionFilter(nil, nil, nil).isValid())
+	assert.Equal(t, "", initTransactionFilter(nil, nil, nil).summary())
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	ezex "github.com/armanimichael/ez-ex"
+	"github.com/armanimichael/ez-ex/cmd/ez-ex-cli/command"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"strings"
+	"time"
+)
+
+// transactionHistoryModel lists the changes of a transaction recorded in the audit log (see ezex.GetHistory)
+type transactionHistoryModel struct {
+	transactionID int
+	history       []ezex.AuditEntry
+	table         table.Model
+}
+
+var transactionHistoryTableColumns = []tableColumn{
+	fixedColumn("Date", 19),
+	fixedColumn("Action", 6),
+	fixedColumn("Source", 6),
+	flexColumn("Changes", 40),
+}
+
+var transactionHistoryKeySuggestions = formatKeySuggestions([][]string{
+	{"{up}/{down}", "scroll changes"},
+	{"{esc}", "back to transactions"},
+})
+
+func initTransactionHistory(transactionID int) transactionHistoryModel {
+	return transactionHistoryModel{
+		transactionID: transactionID,
+		table:         createStandardTable(transactionHistoryTableColumns, nil),
+	}
+}
+
+func (m transactionHistoryModel) Update(msg tea.Msg) (transactionHistoryModel, tea.Cmd) {
+	if msg, ok := msg.(command.TransactionHistoryMsg); ok {
+		// A late reply for a previously opened transaction
+		if msg.TransactionID != m.transactionID {
+			return m, nil
+		}
+
+		m.history = msg.History
+		m.table.SetRows(m.rows())
+		m.table.SetCursor(0)
+
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.table, cmd = m.table.Update(msg)
+
+	return m, cmd
+}
+
+func (m transactionHistoryModel) View() string {
+	str := strings.Builder{}
+	str.WriteString(fmt.Sprintf("History of transaction ID %d\n\n", m.transactionID))
+	if len(m.history) == 0 {
+		str.WriteString(lowOpacityForegroundStyle.Render("No recorded changes") + "\n\n")
+	} else {
+		str.WriteString(baseStyle.Render(m.table.View()) + "\n")
+	}
+	str.WriteString(transactionHistoryKeySuggestions)
+
+	return str.String()
+}
+
+// resize fits the changes table in the terminal
+func (m transactionHistoryModel) resize(size tea.WindowSizeMsg) transactionHistoryModel {
+	// The table is hidden until changes are loaded, it's measured as it'll be shown
+	loaded := m
+	loaded.history = []ezex.AuditEntry{{}}
+	m.table = fitTable(m.table, transactionHistoryTableColumns, loaded.View(), size)
+
+	return m
+}
+
+func (m transactionHistoryModel) rows() []table.Row {
+	rows := make([]table.Row, len(m.history))
+	for i, entry := range m.history {
+		rows[i] = table.Row{
+			time.Unix(entry.DateUnix, 0).Format(time.DateTime),
+			string(entry.Action),
+			string(entry.Source),
+			formatAuditEntry(entry),
+		}
+	}
+
+	return rows
+}
+
+// formatAuditEntry summarizes the changed columns of an update, e.g. `amount: -10.00 → -12.00`
+func formatAuditEntry(entry ezex.AuditEntry) string {
+	switch entry.Action {
+	case ezex.AuditInsert:
+		return "created"
+	case ezex.AuditDelete:
+		return "deleted"
+	}
+
+	changes, err := entry.Changes()
+	if err != nil {
+		logger.Err(fmt.Sprintf("Cannot decode audit entry ID %d: %v", entry.ID, err))
+		return "?"
+	}
+
+	summaries := make([]string, len(changes))
+	for i, change := range changes {
+		summaries[i] = fmt.Sprintf(
+			"%s: %s → %s",
+			strings.TrimSuffix(strings.TrimSuffix(change.Column, "_in_cents"), "_unix"),
+			formatAuditValue(change.Column, change.Old),
+			formatAuditValue(change.Column, change.New),
+		)
+	}
+
+	return strings.Join(summaries, "; ")
+}
+
+// formatAuditValue formats a column value like the rest of the TUI, `-` for NULL
+func formatAuditValue(column string, value any) string {
+	number, ok := value.(json.Number)
+	if !ok {
+		if value == nil {
+			return "-"
+		}
+
+		return fmt.Sprint(value)
+	}
+
+	n, err := number.Int64()
+	switch {
+	case err != nil:
+		return number.String()
+	case strings.HasSuffix(column, "_in_cents"):
+		return encodeCents(n, false)
+	case strings.HasSuffix(column, "_date_unix"):
+		return encodeUnixDate(n)
+	case column == "status":
+		return ezex.TransactionStatus(n).String()
+	}
+
+	return number.String()
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	ezex "github.com/armanimichael/ez-ex"
+	"github.com/armanimichael/ez-ex/cmd/ez-ex-cli/command"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestFormatAuditEntry(t *testing.T) {
+	date := time.Date(2111, 3, 4, 0, 0, 0, 0, time.Local).Unix()
+	entry := ezex.AuditEntry{
+		Action:    ezex.AuditUpdate,
+		OldValues: sql.NullString{String: `{"amount_in_cents": -1000, "notes": null, "status": 0, "payee_id": 2}`, Valid: true},
+		NewValues: sql.NullString{
+			String: fmt.Sprintf(`{"amount_in_cents": -1250, "notes": "Lunch", "status": 1, "payee_id": 2, "delete_date_unix": %d}`, date),
+			Valid:  true,
+		},
+	}
+
+	assert.Equal(
+		t,
+		"amount: -10.00 → -12.50; delete_date: - → 2111-03-04; notes: - → Lunch; status: uncommitted → cleared",
+		formatAuditEntry(entry),
+	)
+	assert.Equal(t, "created", formatAuditEntry(ezex.AuditEntry{Action: ezex.AuditInsert}))
+	assert.Equal(t, "?", formatAuditEntry(ezex.AuditEntry{
+		Action:    ezex.AuditUpdate,
+		OldValues: sql.NullString{String: "{", Valid: true},
+	}))
+}
+
+func TestTransactionHistoryModel_IgnoresOtherTransactions(t *testing.T) {
+	m := initTransactionHistory(1)
+	history := []ezex.AuditEntry{{ID: 1, EntityID: 1, Action: ezex.AuditInsert, Source: ezex.AuditSourceTUI}}
+
+	m, _ = m.Update(command.TransactionHistoryMsg{TransactionID: 2, History: history})
+	assert.Len(t, m.history, 0)
+	assert.Contains(t, m.View(), "No recorded changes")
+
+	m, _ = m.Update(command.TransactionHistoryMsg{TransactionID: 1, History: history})
+	assert.Equal(t, history, m.history)
+	assert.Len(t, m.table.Rows(), 1)
+	assert.Equal(t, "tui", m.table.Rows()[0][2])
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	ezex "github.com/armanimichael/ez-ex"
+	"github.com/armanimichael/ez-ex/cmd/ez-ex-cli/command"
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"strings"
+)
+
+// transactionSearchModel searches the notes, payees and categories of the transactions across all accounts
+type transactionSearchModel struct {
+	ctx     context.Context
+	db      *sql.DB
+	input   textinput.Model
+	results []ezex.TransactionView
+	table   table.Model
+}
+
+var transactionSearchTableColumns = []tableColumn{
+	fixedColumn("ID", 5),
+	fixedColumn("Date", 10),
+	flexColumn("Account", 15),
+	fixedColumn("Amount", 10),
+	flexColumn("Payee", 20),
+	flexColumn("Category", 15),
+	flexColumn("Notes", 30),
+}
+
+var transactionSearchKeySuggestions = formatKeySuggestions([][]string{
+	{"^C", "quit"},
+	{"{esc}", "back to transactions"},
+	{"{enter}", "jump to transaction month"},
+})
+
+func initTransactionSearch(ctx context.Context, db *sql.DB) transactionSearchModel {
+	ti := textinput.New()
+	ti.Prompt = ""
+	ti.Placeholder = "notes, payee or category..."
+	ti.Focus()
+
+	return transactionSearchModel{
+		ctx:   ctx,
+		db:    db,
+		input: ti,
+		table: createStandardTable(transactionSearchTableColumns, nil),
+	}
+}
+
+func (m transactionSearchModel) Update(msg tea.Msg) (transactionSearchModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case command.SearchTransactionsMsg:
+		// Discard results of outdated queries
+		if msg.Query != m.input.Value() {
+			return m, nil
+		}
+
+		m.results = msg.Results
+		m.table.SetRows(searchResultsToTableRows(m.results...))
+		m.table.SetCursor(0)
+
+		return m, nil
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "up", "down":
+			var cmd tea.Cmd
+			m.table, cmd = m.table.Update(msg)
+			return m, cmd
+		}
+	}
+
+	var cmd tea.Cmd
+	query := m.input.Value()
+	m.input, cmd = m.input.Update(msg)
+
+	if m.input.Value() != query {
+		return m, tea.Batch(cmd, command.SearchTransactionsCmd(m.ctx, m.db, m.input.Value()))
+	}
+
+	return m, cmd
+}
+
+func (m transactionSearchModel) View() string {
+	str := strings.Builder{}
+	str.WriteString(inputBoxSelectedStyle.Render("Search: ") + m.input.View() + "\n")
+	str.WriteString(fmt.Sprintf("Results:\t%d\n", len(m.results)))
+	str.WriteString(baseStyle.Render(m.table.View()) + "\n")
+	str.WriteString(transactionSearchKeySuggestions)
+
+	return str.String()
+}
+
+// selected returns the highlighted search result
+func (m transactionSearchModel) selected() (ezex.TransactionView, bool) {
+	if len(m.results) == 0 {
+		return ezex.TransactionView{}, false
+	}
+
+	return m.results[m.table.Cursor()], true
+}
+
+// resize fits the results table in the terminal
+func (m transactionSearchModel) resize(size tea.WindowSizeMsg) transactionSearchModel {
+	m.table = fitTable(m.table, transactionSearchTableColumns, m.View(), size)
+	return m
+}
+
+func (m transactionSearchModel) reset() transactionSearchModel {
+	m.input.SetValue("")
+	m.input.Focus()
+	m.results = nil
+	m.table.SetRows(nil)
+
+	return m
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"fmt"
+	ezex "github.com/armanimichael/ez-ex"
+	"github.com/armanimichael/ez-ex/cmd/ez-ex-cli/command"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"strings"
+)
+
+// transactionSplitModel edits the splits of the transaction being created,
+// each split is added from the inputs and the remaining (unallocated) amount is always shown
+type transactionSplitModel struct {
+	stage         int
+	categories    []ezex.Category
+	inputs        []standardTextInput
+	splits        []command.NewTransactionSplit
+	amountInCents int64
+	suggestion    struct {
+		autocompleteSuggestion string
+		category               ezex.Category
+	}
+}
+
+const (
+	splitCategoryStage = iota
+	splitAmountStage
+	splitNoteStage
+)
+
+var transactionSplitKeySuggestions = formatKeySuggestions([][]string{
+	{"{enter}", "add split"},
+	{"^D", "remove last split"},
+	{"{tab}", "autocomplete category"},
+	{"{esc}", "back to transaction"},
+})
+
+func initTransactionSplit(categories []ezex.Category) transactionSplitModel {
+	m := transactionSplitModel{
+		categories: categories,
+		inputs:     make([]standardTextInput, 3),
+	}
+
+	return m.resetInputs()
+}
+
+func (m transactionSplitModel) Update(msg tea.Msg) (transactionSplitModel, tea.Cmd) {
+	var cmd tea.Cmd
+
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "enter":
+			if !areStandardTextInputsValid(m.inputs) || m.inputs[splitAmountStage].model.Value() == "" {
+				break
+			}
+
+			m.splits = append(m.splits, m.newSplit())
+			m = m.resetInputs()
+			return m, textinput.Blink
+		case "ctrl+d":
+			if len(m.splits) > 0 {
+				m.splits = m.splits[:len(m.splits)-1]
+			}
+			return m, nil
+		case "tab":
+			if m.stage == splitCategoryStage && m.suggestion.autocompleteSuggestion != "" {
+				path := m.suggestion.category.Path
+				m.inputs[m.stage].model.SetValue(path)
+				m.inputs[m.stage].model.SetCursor(len(path))
+				m.suggestion.autocompleteSuggestion = ""
+			}
+			return m, nil
+		case "up", "down":
+			m.inputs[m.stage].model.Blur()
+			m.stage = handleSwitchInputStage(msg.String() == "up", m.stage, splitCategoryStage, splitNoteStage)
+			m.inputs[m.stage].model.SetCursor(0)
+			m.inputs[m.stage].model.Focus()
+			return m, textinput.Blink
+		}
+	}
+
+	currentInput := &m.inputs[m.stage]
+	currentInput.model, cmd = currentInput.model.Update(msg)
+
+	for i := range m.inputs {
+		m.inputs[i].errorMsg = m.validateInput(i)
+		m.inputs[i].previousInput = m.inputs[i].model.Value()
+	}
+
+	m.suggestion.autocompleteSuggestion = ""
+	if val := m.inputs[splitCategoryStage].model.Value(); m.stage == splitCategoryStage && val != "" {
+		if match, ok := autocomplete(categoryPaths(m.categories), val); ok {
+			m.suggestion.autocompleteSuggestion = match.Path[len(val):]
+			m.suggestion.category = match.Category
+		}
+	}
+
+	return m, cmd
+}
+
+func (m transactionSplitModel) View() string {
+	str := strings.Builder{}
+	str.WriteString(fmt.Sprintf("Split transaction of %s\n\n", encodeCents(m.amountInCents, false)))
+
+	for i, split := range m.splits {
+		category := split.Category.Path
+		if category == "" {
+			category = "<NO CATEGORY>"
+		}
+
+		str.WriteString(fmt.Sprintf(
+			"%2d. %-20s %s  %s\n",
+			i+1,
+			category,
+			encodeCents(split.AmountInCents, true),
+			lowOpacityForegroundStyle.Render(split.Notes.String),
+		))
+	}
+
+	remaining := m.remainingInCents()
+	remainingStr := fmt.Sprintf("Remaining:\t%s", encodeCents(remaining, false))
+	if remaining == 0 {
+		str.WriteString(successMessageStyle.Render(remainingStr) + "\n\n")
+	} else {
+		str.WriteString(errorMessageStyle.Render(remainingStr) + "\n\n")
+	}
+
+	str.WriteString(standardTextInputView(m.stage, m.inputs, m.suggestion.autocompleteSuggestion))
+	str.WriteString("\n\n" + transactionSplitKeySuggestions)
+
+	return str.String()
+}
+
+// remainingInCents returns the part of the transaction amount not allocated to any split yet
+func (m transactionSplitModel) remainingInCents() int64 {
+	remaining := m.amountInCents
+	for _, split := range m.splits {
+		remaining -= split.AmountInCents
+	}
+
+	return remaining
+}
+
+// newSplit creates a split from the inputs, unknown categories will be created along with the transaction
+func (m transactionSplitModel) newSplit() command.NewTransactionSplit {
+	notes := m.inputs[splitNoteStage].model.Value()
+
+	category := ezex.Category{}
+	if path := strings.TrimSpace(m.inputs[splitCategoryStage].model.Value()); path != "" {
+		category, _ = findCategory(m.categories, path)
+	}
+
+	split := command.NewTransactionSplit{
+		Category:      category,
+		AmountInCents: decodeCents(m.inputs[splitAmountStage].model.Value()),
+	}
+	split.Notes.String = notes
+	split.Notes.Valid = notes != ""
+
+	return split
+}
+
+func (m transactionSplitModel) validateInput(stage int) string {
+	value := m.inputs[stage].model.Value()
+
+	if stage == splitAmountStage && value != "" {
+		if err := validateAmount(value); err != nil {
+			return err.Error()
+		}
+	}
+	if stage == splitCategoryStage && value != "" {
+		if err := validateCategoryPath(value); err != nil {
+			return err.Error()
+		}
+	}
+
+	return ""
+}
+
+func (m transactionSplitModel) reset(categories []ezex.Category) transactionSplitModel {
+	m.categories = categories
+	m.splits = nil
+	m.amountInCents = 0
+
+	return m.resetInputs()
+}
+
+func (m transactionSplitModel) resetInputs() transactionSplitModel {
+	m.stage = splitCategoryStage
+	m.suggestion.autocompleteSuggestion = ""
+
+	for i := range m.inputs {
+		ti := textinput.New()
+		ti.Prompt = ""
+		m.inputs[i] = standardTextInput{model: ti}
+	}
+
+	m.inputs[splitCategoryStage].model.Placeholder = "No category"
+	m.inputs[splitCategoryStage].label = "Category"
+	m.inputs[splitCategoryStage].model.Focus()
+	m.inputs[splitAmountStage].model.Placeholder = "0.00"
+	m.inputs[splitAmountStage].label = "Amount*"
+	m.inputs[splitNoteStage].model.Placeholder = "<NO NOTES>"
+	m.inputs[splitNoteStage].label = "Note"
+
+	return m
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"github.com/armanimichael/ez-ex/cmd/ez-ex-cli/command"
+)
+
+// maxUndoOperations caps the operations that can be undone, the oldest ones are forgotten
+const maxUndoOperations = 100
+
+// undoStack keeps the operations made during the session (see command.UndoableOperation): the last one done is
+// the first one undone, and the last one undone the first one redone, until a new operation is made
+type undoStack struct {
+	done   []command.UndoableOperation
+	undone []command.UndoableOperation
+}
+
+// push records a new operation, which can't be followed by the ones undone before it
+func (s undoStack) push(operation command.UndoableOperation) undoStack {
+	s.done = append(s.done, operation)
+	if len(s.done) > maxUndoOperations {
+		s.done = s.done[len(s.done)-maxUndoOperations:]
+	}
+	s.undone = nil
+
+	return s
+}
+
+// pop removes the next operation to undo, or to redo, false when there are none
+func (s undoStack) pop(redo bool) (undoStack, command.UndoableOperation, bool) {
+	stack := &s.done
+	if redo {
+		stack = &s.undone
+	}
+	if len(*stack) == 0 {
+		return s, command.UndoableOperation{}, false
+	}
+
+	operation := (*stack)[len(*stack)-1]
+	*stack = (*stack)[:len(*stack)-1]
+
+	return s, operation, true
+}
+
+// finish records a popped operation once undone (or redone), or puts it back when it failed
+func (s undoStack) finish(operation command.UndoableOperation, redo bool, failed bool) undoStack {
+	// A successful undo and a failed redo both leave the operation to redo
+	if redo == failed {
+		s.undone = append(s.undone, operation)
+	} else {
+		s.done = append(s.done, operation)
+	}
+
+	return s
+}
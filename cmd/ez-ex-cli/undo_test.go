@@ -0,0 +1,195 @@
+package main
+
+import (
+	ezex "github.com/armanimichael/ez-ex"
+	"github.com/armanimichael/ez-ex/cmd/ez-ex-cli/command"
+	customLogger "github.com/armanimichael/ez-ex/cmd/ez-ex-cli/logger"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestUndoStack(t *testing.T) {
+	first := command.UndoableOperation{Kind: command.DeleteTransactionOperation, ID: 1}
+	second := command.UndoableOperation{Kind: command.DeleteAccountOperation, ID: 2}
+	s := undoStack{}.push(first).push(second)
+
+	s, operation, ok := s.pop(false)
+	assert.True(t, ok)
+	assert.Equal(t, second, operation)
+	s = s.finish(operation, false, false)
+
+	// A failed redo can be retried
+	s, operation, _ = s.pop(true)
+	assert.Equal(t, second, operation)
+	s = s.finish(operation, true, true)
+	assert.Equal(t, []command.UndoableOperation{second}, s.undone)
+
+	// New operations can't be followed by the undone ones
+	s = s.push(second)
+	_, _, ok = s.pop(true)
+	assert.False(t, ok)
+	assert.Equal(t, []command.UndoableOperation{first, second}, s.done)
+
+	for i := 0; i < maxUndoOperations+10; i++ {
+		s = s.push(first)
+	}
+	assert.Len(t, s.done, maxUndoOperations)
+}
+
+// runCmd runs cmd and its batched commands, returning their messages. Timers (e.g. HideErrorMessageCmd) are
+// left running and their messages dropped
+func runCmd(cmd tea.Cmd) []tea.Msg {
+	if cmd == nil {
+		return nil
+	}
+
+	msgs := make(chan tea.Msg, 1)
+	go func() {
+		msgs <- cmd()
+	}()
+	var msg tea.Msg
+	select {
+	case msg = <-msgs:
+	case <-time.After(300 * time.Millisecond):
+		return nil
+	}
+
+	if batch, ok := msg.(tea.BatchMsg); ok {
+		var msgs []tea.Msg
+		for _, cmd := range batch {
+			msgs = append(msgs, runCmd(cmd)...)
+		}
+		return msgs
+	}
+
+	return []tea.Msg{msg}
+}
+
+func TestModel_UndoDeleteTransaction(t *testing.T) {
+	logger = customLogger.NewFileLogger(6, "")
+	db, err := ezex.OpenDB(ezex.WithInMemory())
+	assert.Nil(t, err)
+	defer db.Close()
+	assert.Nil(t, ezex.MigrateDB(db))
+
+	accountID, _ := ezex.AddAccount(db, ezex.Account{Name: "TestModel_UndoDeleteTransaction", BalanceInCents: 5000})
+	payeeID, _ := ezex.AddPayee(db, ezex.Payee{Name: "TestModel_UndoDeleteTransaction"})
+	id, _ := ezex.AddTransaction(db, ezex.Transaction{
+		AccountID:           accountID,
+		PayeeID:             payeeID,
+		AmountInCents:       -1000,
+		TransactionDateUnix: time.Now().Unix(),
+	})
+
+	var m tea.Model = initialModel(db, t.TempDir(), []profile{{name: defaultProfileName}}, defaultProfileName, nil)
+	m, _ = m.Update(command.SwitchModelMsg{ModelID: transactionModelID, AccountID: accountID})
+	// update applies msg to m, then the messages of the commands it returns, until there are none
+	var update func(msg tea.Msg)
+	update = func(msg tea.Msg) {
+		var cmd tea.Cmd
+		m, cmd = m.Update(msg)
+		for _, msg := range runCmd(cmd) {
+			if msg != nil {
+				update(msg)
+			}
+		}
+	}
+
+	update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}})
+	account, _ := ezex.GetAccount(db, accountID)
+	assert.Equal(t, int64(6000), account.BalanceInCents)
+	assert.Contains(t, m.View(), "Deleted transaction")
+	assert.Contains(t, m.View(), "press u to undo")
+
+	update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'u'}})
+	account, _ = ezex.GetAccount(db, accountID)
+	assert.Equal(t, int64(5000), account.BalanceInCents)
+	assert.Contains(t, m.View(), "Undone: Deleted transaction")
+	history := ezex.GetHistory(db, ezex.AuditTransaction, id)
+	assert.Equal(t, ezex.AuditSourceTUI, history[len(history)-1].Source)
+	assert.Equal(t, int64(5000), m.(model).currentModel.(transactionModel).account.BalanceInCents)
+
+	update(tea.KeyMsg{Type: tea.KeyCtrlR})
+	account, _ = ezex.GetAccount(db, accountID)
+	assert.Equal(t, int64(6000), account.BalanceInCents)
+	assert.Contains(t, m.View(), "Redone: Deleted transaction")
+
+	// Nothing is left to redo
+	update(tea.KeyMsg{Type: tea.KeyCtrlR})
+	assert.Contains(t, m.View(), "nothing to redo")
+}
+
+func TestModel_UndoEditTransaction(t *testing.T) {
+	logger = customLogger.NewFileLogger(6, "")
+	db, err := ezex.OpenDB(ezex.WithInMemory())
+	assert.Nil(t, err)
+	defer db.Close()
+	assert.Nil(t, ezex.MigrateDB(db))
+
+	accountID, _ := ezex.AddAccount(db, ezex.Account{Name: "TestModel_UndoEditTransaction", BalanceInCents: 5000})
+	payeeID, _ := ezex.AddPayee(db, ezex.Payee{Name: "TestModel_UndoEditTransaction"})
+	foodID, _ := ezex.AddCategory(db, ezex.Category{Name: "Food"})
+	fuelID, _ := ezex.AddCategory(db, ezex.Category{Name: "Fuel"})
+	_, _ = ezex.AddTransaction(db, ezex.Transaction{
+		AccountID:           accountID,
+		PayeeID:             payeeID,
+		CategoryID:          foodID,
+		AmountInCents:       -1000,
+		TransactionDateUnix: time.Now().Unix(),
+	})
+
+	var m tea.Model = initialModel(db, t.TempDir(), []profile{{name: defaultProfileName}}, defaultProfileName, nil)
+	m, _ = m.Update(command.SwitchModelMsg{ModelID: transactionModelID, AccountID: accountID})
+	var update func(msg tea.Msg)
+	update = func(msg tea.Msg) {
+		var cmd tea.Cmd
+		m, cmd = m.Update(msg)
+		for _, msg := range runCmd(cmd) {
+			if msg != nil {
+				update(msg)
+			}
+		}
+	}
+	// replace clears the focused input and types value
+	replace := func(value string) {
+		update(tea.KeyMsg{Type: tea.KeyEnd})
+		update(tea.KeyMsg{Type: tea.KeyCtrlU})
+		for _, r := range value {
+			update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		}
+	}
+	transaction := func() ezex.TransactionView {
+		return ezex.FilterTransactions(db, ezex.TransactionFilter{AccountIDs: []int{accountID}})[0]
+	}
+
+	update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'C'}})
+	replace("Fuel")
+	update(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Equal(t, fuelID, transaction().CategoryID)
+	assert.Contains(t, m.View(), "Recategorized transaction")
+
+	update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'u'}})
+	assert.Equal(t, foodID, transaction().CategoryID)
+	assert.Contains(t, m.View(), "Undone: Recategorized transaction")
+	update(tea.KeyMsg{Type: tea.KeyCtrlR})
+	assert.Equal(t, fuelID, transaction().CategoryID)
+
+	// Editing the amount adjusts the balance by the difference
+	update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'e'}})
+	update(tea.KeyMsg{Type: tea.KeyDown})
+	replace("-12")
+	update(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Equal(t, int64(-1200), transaction().AmountInCents)
+	account, _ := ezex.GetAccount(db, accountID)
+	assert.Equal(t, int64(4800), account.BalanceInCents)
+	assert.Contains(t, m.View(), "Edited transaction")
+
+	update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'u'}})
+	assert.Equal(t, int64(-1000), transaction().AmountInCents)
+	assert.Equal(t, fuelID, transaction().CategoryID)
+	account, _ = ezex.GetAccount(db, accountID)
+	assert.Equal(t, int64(5000), account.BalanceInCents)
+	assert.Equal(t, int64(5000), m.(model).currentModel.(transactionModel).account.BalanceInCents)
+}
@@ -2,11 +2,13 @@ package main
 
 import (
 	"errors"
+	"fmt"
+	ezex "github.com/armanimichael/ez-ex"
 	"regexp"
 	"strconv"
+	"strings"
 )
 
-var moneyFormatRegex = regexp.MustCompile(`^-?(?P<integer>\d+)(\.(?P<cents>\d{2}))+$`)
 var dateFormatRegex = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
 
 func validateDateString(value string) error {
@@ -40,3 +42,27 @@ func validateDateString(value string) error {
 
 	return nil
 }
+
+// validateAmount checks an amount (see ezex.ParseMoney), its currency must be the configured one if any
+func validateAmount(value string) error {
+	money, err := ezex.ParseMoney(value)
+	if err != nil {
+		return err
+	}
+	if money.Currency != "" && currencyCode != "" && money.Currency != currencyCode {
+		return fmt.Errorf("the amount currency should be %s", currencyCode)
+	}
+
+	return nil
+}
+
+// validateCategoryPath checks a `Parent:Child` category path, every name must be non-empty
+func validateCategoryPath(value string) error {
+	for _, name := range strings.Split(value, ezex.CategoryPathSeparator) {
+		if strings.TrimSpace(name) == "" {
+			return errors.New("invalid category, should look like `Category` or `Parent:Child`")
+		}
+	}
+
+	return nil
+}
@@ -5,7 +5,7 @@ import (
 	"testing"
 )
 
-func TestMoneyFormatRegex(t *testing.T) {
+func TestValidateAmount(t *testing.T) {
 	cases := []struct {
 		value   string
 		isValid bool
@@ -16,23 +16,41 @@ func TestMoneyFormatRegex(t *testing.T) {
 		{"123.12", true},
 		{"-123.12", true},
 		{"123.00", true},
-		{"0,00", false},
-		{"1.0", false},
-		{".0", false},
-		{"123", false},
-		{"-123", false},
-		{".10", false},
-		{"0", false},
-		{"-.12", false},
+		{"0,00", true},
+		{"1.0", true},
+		{"123", true},
+		{"-123", true},
+		{".10", true},
+		{"0", true},
+		{"-.12", true},
+		{"1,234.56", true},
+		{"1.234,56", true},
+		{"12.00 EUR", true},
+		{"", false},
+		{".0.", false},
+		{"1.234.5", false},
+		{"0.125", false},
+		{"12 USD", false},
+		{"abc", false},
 	}
+	currencyCode = "EUR"
+	defer func() {
+		currencyCode = ""
+	}()
 	for _, c := range cases {
 		t.Run(c.value, func(t *testing.T) {
-			isValid := moneyFormatRegex.MatchString(c.value)
-			assert.Equal(t, c.isValid, isValid)
+			err := validateAmount(c.value)
+			assert.Equal(t, c.isValid, err == nil)
 		})
 	}
 }
 
+func TestDecodeCents(t *testing.T) {
+	assert.Equal(t, int64(150), decodeCents("1.5"))
+	assert.Equal(t, int64(123456), decodeCents("1,234.56"))
+	assert.Equal(t, int64(0), decodeCents("invalid"))
+}
+
 func TestValidateDateString(t *testing.T) {
 	cases := []struct {
 		value   string
@@ -75,3 +93,24 @@ func TestValidateDateString(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateCategoryPath(t *testing.T) {
+	cases := []struct {
+		value   string
+		isValid bool
+	}{
+		{"Car", true},
+		{"Car:Fuel", true},
+		{"Car : Fuel", true},
+		{"Car:", false},
+		{":Fuel", false},
+		{"Car::Fuel", false},
+		{" ", false},
+	}
+	for _, c := range cases {
+		t.Run(c.value, func(t *testing.T) {
+			err := validateCategoryPath(c.value)
+			assert.Equal(t, c.isValid, err == nil)
+		})
+	}
+}
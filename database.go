@@ -1,62 +1,255 @@
 package ezex
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/mattn/go-sqlite3"
 	"reflect"
+	"strings"
+	"time"
 )
 
+var (
+	// ErrNotFound is returned when an entity, or one it refers to (e.g. the payee of a transaction), doesn't exist
+	ErrNotFound = errors.New("not found")
+	// ErrDuplicateName is returned when the name of an entity is already taken
+	ErrDuplicateName = errors.New("name already exists")
+	// ErrInUse is returned when deleting an entity still referred to by others (e.g. a payee with transactions)
+	ErrInUse = errors.New("in use")
+	// ErrProtectedCategory is returned when changing or deleting the root category (ID 0)
+	ErrProtectedCategory = errors.New("the root category can't be changed or deleted")
+)
+
+// dbExecutor runs queries, it's implemented by *sql.DB, *sql.Tx and their context-bound versions (see withContext)
+type dbExecutor interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// contextDB runs the queries of db with ctx (QueryContext, ExecContext, ...), so that they stop once ctx is done.
+// SQLite doesn't stop waiting for a locked DB when interrupted, so writes wait at most until the ctx deadline
+// (see withBusyTimeout)
+type contextDB struct {
+	ctx context.Context
+	db  *sql.DB
+}
+
+// contextTx is the transaction of a contextDB, its queries run with ctx too
+type contextTx struct {
+	ctx context.Context
+	tx  *sql.Tx
+}
+
+// withContext binds db to ctx, the XContext variants of the API (e.g. GetAccountsContext) run their queries with it
+func withContext(ctx context.Context, db *sql.DB) dbExecutor {
+	return contextDB{ctx: ctx, db: db}
+}
+
+func (c contextDB) Exec(query string, args ...any) (sql.Result, error) {
+	var result sql.Result
+	// The audit source is set per transaction (see withAuditSource)
+	if _, ok := c.ctx.Value(auditSourceKey{}).(AuditSource); ok {
+		err := dbTransaction(c, func(tx dbExecutor) error {
+			var err error
+			result, err = tx.Exec(query, args...)
+			return err
+		})
+
+		return result, err
+	}
+
+	err := withBusyTimeout(c.ctx, c.db, func(conn *sql.Conn) error {
+		var err error
+		result, err = conn.ExecContext(c.ctx, query, args...)
+		return err
+	})
+
+	return result, err
+}
+
+func (c contextDB) Query(query string, args ...any) (*sql.Rows, error) {
+	return c.db.QueryContext(c.ctx, query, args...)
+}
+
+func (c contextDB) QueryRow(query string, args ...any) *sql.Row {
+	return c.db.QueryRowContext(c.ctx, query, args...)
+}
+
+func (c contextTx) Exec(query string, args ...any) (sql.Result, error) {
+	return c.tx.ExecContext(c.ctx, query, args...)
+}
+
+func (c contextTx) Query(query string, args ...any) (*sql.Rows, error) {
+	return c.tx.QueryContext(c.ctx, query, args...)
+}
+
+func (c contextTx) QueryRow(query string, args ...any) *sql.Row {
+	return c.tx.QueryRowContext(c.ctx, query, args...)
+}
+
+// withBusyTimeout runs fn with a connection waiting for a locked DB until the ctx deadline at most, instead of
+// the DB busy timeout. Errors caused by the deadline wrap ctx.Err()
+func withBusyTimeout(ctx context.Context, db *sql.DB, fn func(conn *sql.Conn) error) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer func(conn *sql.Conn) {
+		_ = conn.Close()
+	}(conn)
+
+	deadline, ok := ctx.Deadline()
+	if ok {
+		var busyTimeout int64
+		if err = conn.QueryRowContext(ctx, `PRAGMA busy_timeout`).Scan(&busyTimeout); err != nil {
+			return err
+		}
+
+		// Rounded up, so that the deadline has passed once SQLite gives up
+		if timeout := time.Until(deadline).Milliseconds() + 1; timeout < busyTimeout {
+			if _, err = conn.ExecContext(ctx, fmt.Sprintf(`PRAGMA busy_timeout = %d`, timeout)); err != nil {
+				return err
+			}
+			defer func(conn *sql.Conn) {
+				// The connection goes back to the pool, so the timeout is restored even if ctx is done
+				_, _ = conn.ExecContext(context.WithoutCancel(ctx), fmt.Sprintf(`PRAGMA busy_timeout = %d`, busyTimeout))
+			}(conn)
+		}
+	}
+
+	if err = fn(conn); err != nil && ctx.Err() != nil && !errors.Is(err, ctx.Err()) {
+		return fmt.Errorf("%w: %w", ctx.Err(), err)
+	}
+
+	return err
+}
+
+// dbTransaction runs fn inside a DB transaction, committing if fn succeeds and rolling back otherwise.
+// If db already is a transaction fn is part of it
+func dbTransaction(db dbExecutor, fn func(tx dbExecutor) error) error {
+	switch db := db.(type) {
+	case *sql.DB:
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+
+		return commitOrRollback(tx, fn(tx))
+	case contextDB:
+		return withBusyTimeout(db.ctx, db.db, func(conn *sql.Conn) error {
+			tx, err := conn.BeginTx(db.ctx, nil)
+			if err != nil {
+				return err
+			}
+
+			contextTx := contextTx{ctx: db.ctx, tx: tx}
+			return commitOrRollback(tx, withAuditSource(db.ctx, contextTx, func() error {
+				return fn(contextTx)
+			}))
+		})
+	}
+
+	return fn(db)
+}
+
+// commitOrRollback commits tx if err is nil, rolling it back and returning err otherwise
+func commitOrRollback(tx *sql.Tx, err error) error {
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// dbError translates the constraint violations of err: unique ones into ErrDuplicateName and foreign key ones into
+// fkErr (ErrNotFound when referring to a missing entity, ErrInUse when deleting a referred one). err is still wrapped
+func dbError(err error, fkErr error) error {
+	var sqliteErr sqlite3.Error
+	if !errors.As(err, &sqliteErr) {
+		return err
+	}
+
+	switch {
+	case sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique, sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey:
+		return fmt.Errorf("%w: %w", ErrDuplicateName, err)
+	// Statement-level foreign key failures only have the generic constraint code
+	case sqliteErr.ExtendedCode == sqlite3.ErrConstraintForeignKey,
+		sqliteErr.Code == sqlite3.ErrConstraint && strings.HasPrefix(sqliteErr.Error(), "FOREIGN KEY"):
+		return fmt.Errorf("%w: %w", fkErr, err)
+	}
+
+	return err
+}
+
 // dbAdd handles insert queries and returns the new entity ID if successful
-func dbAdd(db *sql.DB, query string, args ...any) (int, error) {
+func dbAdd(db dbExecutor, query string, args ...any) (int, error) {
 	result, err := db.Exec(query, args...)
 	if err != nil {
-		return -1, err
+		return -1, dbError(err, ErrNotFound)
 	}
 
 	id, err := result.LastInsertId()
 	return int(id), err
 }
 
-// dbUpdate handles update queries and returns the number of affected rows
-func dbUpdate(db *sql.DB, query string, args ...any) (int, error) {
+// dbUpdate handles update queries and returns the number of affected rows, ErrNotFound if there are none
+func dbUpdate(db dbExecutor, query string, args ...any) (int, error) {
 	result, err := db.Exec(query, args...)
 	if err != nil {
-		return 0, err
+		return 0, dbError(err, ErrNotFound)
 	}
 
-	n, err := result.RowsAffected()
-
-	return int(n), err
+	return rowsAffected(result)
 }
 
-// dbDelete handles delete queries and returns the number of affected rows
-func dbDelete(db *sql.DB, query string, args ...any) int {
-	result, _ := db.Exec(query, args...)
-	if result == nil {
-		return 0
+// dbDelete handles delete queries and returns the number of affected rows, ErrNotFound if there are none
+func dbDelete(db dbExecutor, query string, args ...any) (int, error) {
+	result, err := db.Exec(query, args...)
+	if err != nil {
+		return 0, dbError(err, ErrInUse)
 	}
 
-	n, _ := result.RowsAffected()
+	return rowsAffected(result)
+}
+
+func rowsAffected(result sql.Result) (int, error) {
+	n, err := result.RowsAffected()
+	if err == nil && n == 0 {
+		err = ErrNotFound
+	}
 
-	return int(n)
+	return int(n), err
 }
 
 // dbGet returns a slice of entities given a query
-func dbGet[T any](db *sql.DB, query string, args ...any) []T {
-	var rows *sql.Rows
-	if args == nil {
-		rows, _ = db.Query(query)
-	} else {
-		rows, _ = db.Query(query, args...)
+func dbGet[T any](db dbExecutor, query string, args ...any) []T {
+	var mappedRows []T
+	_ = dbEach(db, func(row T) error {
+		mappedRows = append(mappedRows, row)
+		return nil
+	}, query, args...)
+
+	return mappedRows
+}
+
+// dbEach maps each row returned by a query to an entity and passes it to fn, one row at a time.
+// Iteration stops at the first error, either from the DB or from fn
+func dbEach[T any](db dbExecutor, fn func(T) error, query string, args ...any) error {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return err
 	}
 
 	defer func(rows *sql.Rows) {
 		_ = rows.Close()
 	}(rows)
 
-	var mappedRows []T
 	// Get row struct value = T
-	rowsValue := reflect.ValueOf(&mappedRows).Elem()
-	rowType := rowsValue.Type().Elem()
+	rowType := reflect.TypeOf((*T)(nil)).Elem()
 
 	// Get the number of exported fields in the struct representing the DB row (1 field = 1 column)
 	columnValues := make([]any, rowType.NumField())
@@ -72,11 +265,14 @@ func dbGet[T any](db *sql.DB, query string, args ...any) []T {
 			columnValues[i] = rowVal.Field(i).Addr().Interface()
 		}
 
-		_ = rows.Scan(columnValues...)
+		if err = rows.Scan(columnValues...); err != nil {
+			return err
+		}
 
-		// Add row to the list of returned rows
-		rowsValue.Set(reflect.Append(rowsValue, rowVal))
+		if err = fn(rowVal.Interface().(T)); err != nil {
+			return err
+		}
 	}
 
-	return mappedRows
+	return rows.Err()
 }
@@ -0,0 +1,82 @@
+package ezex
+
+import (
+	"context"
+	"database/sql"
+	"github.com/stretchr/testify/assert"
+	"path"
+	"testing"
+	"time"
+)
+
+// lockDB opens a DB waiting up to 10s for locks, along with a transaction holding the write lock on it
+func lockDB(t *testing.T) (*sql.DB, *sql.Tx) {
+	dbPath := path.Join(t.TempDir(), "locked.db")
+	db, err := OpenDB(WithDBPath(dbPath), WithDSNParam("_busy_timeout", "10000"))
+	assert.Nil(t, err)
+	assert.Nil(t, MigrateDB(db))
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	locker, err := OpenDB(WithDBPath(dbPath))
+	assert.Nil(t, err)
+	tx, err := locker.Begin()
+	assert.Nil(t, err)
+	// Writing locks the DB for other writers until the transaction ends
+	_, err = tx.Exec(`INSERT INTO payees (name) VALUES ('Locker')`)
+	assert.Nil(t, err)
+	t.Cleanup(func() {
+		_ = tx.Rollback()
+		_ = locker.Close()
+	})
+
+	return db, tx
+}
+
+func TestContext_LockedDB(t *testing.T) {
+	db, tx := lockDB(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := AddAccountContext(ctx, db, Account{Name: "TestContext_LockedDB"})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start = time.Now()
+	err = MoveCategoryContext(ctx, db, 1, 0)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	// Once unlocked, connections wait for the DB busy timeout again
+	_ = tx.Rollback()
+	var busyTimeout int
+	assert.Nil(t, db.QueryRow(`PRAGMA busy_timeout`).Scan(&busyTimeout))
+	assert.Equal(t, 10000, busyTimeout)
+	_, err = AddAccountContext(context.Background(), db, Account{Name: "TestContext_LockedDB"})
+	assert.Nil(t, err)
+}
+
+func TestContext_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := AddAccountContext(ctx, testDB, Account{Name: "TestContext_Canceled"})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Nil(t, GetAccountsContext(ctx, testDB))
+	assert.ErrorIs(t, WalkTransactionsContext(ctx, testDB, TransactionFilter{}, func(TransactionView) error {
+		return nil
+	}), context.Canceled)
+
+	// Queries run within the same unit of work as the canceled context
+	err = NewSQLiteStoreContext(ctx, testDB).UnitOfWork(func(s Store) error {
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	for _, account := range GetAccounts(testDB) {
+		assert.NotEqual(t, "TestContext_Canceled", account.Name)
+	}
+}
@@ -0,0 +1,261 @@
+-- Append-only log of every change to accounts, transactions, payees and categories, written by triggers within
+-- the transaction making the change. Values are the JSON of the row columns, NULL before inserts and after deletes
+CREATE TABLE IF NOT EXISTS audit_log
+(
+    id          INTEGER PRIMARY KEY,
+    -- Changed table name
+    entity      TEXT    NOT NULL,
+    entity_id   INTEGER NOT NULL,
+    -- insert, update or delete
+    action      TEXT    NOT NULL,
+    old_values  TEXT,
+    new_values  TEXT,
+    date_unix   INTEGER NOT NULL DEFAULT (CAST(strftime('%s', 'now') AS INTEGER)),
+    -- tui, cli, import or api (see audit_source)
+    source      TEXT    NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS ix_audit_log_by_entity_entity_id ON audit_log (entity, entity_id);
+
+CREATE TRIGGER IF NOT EXISTS tr_audit_log_update
+    BEFORE UPDATE
+    ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'the audit log is append-only');
+END;
+
+CREATE TRIGGER IF NOT EXISTS tr_audit_log_delete
+    BEFORE DELETE
+    ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'the audit log is append-only');
+END;
+
+-- Source of the changes made by the current transaction, set and cleared by it (NULL = api)
+CREATE TABLE IF NOT EXISTS audit_source
+(
+    id     INTEGER PRIMARY KEY CHECK (id = 0),
+    source TEXT
+);
+INSERT OR IGNORE INTO audit_source (id, source)
+VALUES (0, NULL);
+
+CREATE TRIGGER IF NOT EXISTS tr_accounts_audit_insert
+    AFTER INSERT
+    ON accounts
+BEGIN
+    INSERT INTO audit_log (entity, entity_id, action, new_values, source)
+    VALUES ('accounts',
+            NEW.id,
+            'insert',
+            json_object('id', NEW.id,
+                        'name', NEW.name,
+                        'description', NEW.description,
+                        'initial_balance_in_cents', NEW.initial_balance_in_cents,
+                        'balance_in_cents', NEW.balance_in_cents,
+                        'delete_date_unix', NEW.delete_date_unix),
+            COALESCE((SELECT source FROM audit_source), 'api'));
+END;
+
+CREATE TRIGGER IF NOT EXISTS tr_accounts_audit_update
+    AFTER UPDATE
+    ON accounts
+BEGIN
+    INSERT INTO audit_log (entity, entity_id, action, old_values, new_values, source)
+    SELECT 'accounts', NEW.id, 'update', old_values, new_values, COALESCE((SELECT source FROM audit_source), 'api')
+    FROM (SELECT json_object('id', OLD.id,
+                             'name', OLD.name,
+                             'description', OLD.description,
+                             'initial_balance_in_cents', OLD.initial_balance_in_cents,
+                             'balance_in_cents', OLD.balance_in_cents,
+                             'delete_date_unix', OLD.delete_date_unix) AS old_values,
+                 json_object('id', NEW.id,
+                             'name', NEW.name,
+                             'description', NEW.description,
+                             'initial_balance_in_cents', NEW.initial_balance_in_cents,
+                             'balance_in_cents', NEW.balance_in_cents,
+                             'delete_date_unix', NEW.delete_date_unix) AS new_values)
+    -- Updates changing nothing aren't logged
+    WHERE old_values != new_values;
+END;
+
+CREATE TRIGGER IF NOT EXISTS tr_accounts_audit_delete
+    AFTER DELETE
+    ON accounts
+BEGIN
+    INSERT INTO audit_log (entity, entity_id, action, old_values, source)
+    VALUES ('accounts',
+            OLD.id,
+            'delete',
+            json_object('id', OLD.id,
+                        'name', OLD.name,
+                        'description', OLD.description,
+                        'initial_balance_in_cents', OLD.initial_balance_in_cents,
+                        'balance_in_cents', OLD.balance_in_cents,
+                        'delete_date_unix', OLD.delete_date_unix),
+            COALESCE((SELECT source FROM audit_source), 'api'));
+END;
+
+CREATE TRIGGER IF NOT EXISTS tr_transactions_audit_insert
+    AFTER INSERT
+    ON transactions
+BEGIN
+    INSERT INTO audit_log (entity, entity_id, action, new_values, source)
+    VALUES ('transactions',
+            NEW.id,
+            'insert',
+            json_object('id', NEW.id,
+                        'category_id', NEW.category_id,
+                        'payee_id', NEW.payee_id,
+                        'account_id', NEW.account_id,
+                        'amount_in_cents', NEW.amount_in_cents,
+                        'transaction_date_unix', NEW.transaction_date_unix,
+                        'update_date_unix', NEW.update_date_unix,
+                        'delete_date_unix', NEW.delete_date_unix,
+                        'notes', NEW.notes,
+                        'status', NEW.status),
+            COALESCE((SELECT source FROM audit_source), 'api'));
+END;
+
+CREATE TRIGGER IF NOT EXISTS tr_transactions_audit_update
+    AFTER UPDATE
+    ON transactions
+BEGIN
+    INSERT INTO audit_log (entity, entity_id, action, old_values, new_values, source)
+    SELECT 'transactions', NEW.id, 'update', old_values, new_values, COALESCE((SELECT source FROM audit_source), 'api')
+    FROM (SELECT json_object('id', OLD.id,
+                             'category_id', OLD.category_id,
+                             'payee_id', OLD.payee_id,
+                             'account_id', OLD.account_id,
+                             'amount_in_cents', OLD.amount_in_cents,
+                             'transaction_date_unix', OLD.transaction_date_unix,
+                             'update_date_unix', OLD.update_date_unix,
+                             'delete_date_unix', OLD.delete_date_unix,
+                             'notes', OLD.notes,
+                             'status', OLD.status) AS old_values,
+                 json_object('id', NEW.id,
+                             'category_id', NEW.category_id,
+                             'payee_id', NEW.payee_id,
+                             'account_id', NEW.account_id,
+                             'amount_in_cents', NEW.amount_in_cents,
+                             'transaction_date_unix', NEW.transaction_date_unix,
+                             'update_date_unix', NEW.update_date_unix,
+                             'delete_date_unix', NEW.delete_date_unix,
+                             'notes', NEW.notes,
+                             'status', NEW.status) AS new_values)
+    -- Updates changing nothing aren't logged
+    WHERE old_values != new_values;
+END;
+
+CREATE TRIGGER IF NOT EXISTS tr_transactions_audit_delete
+    AFTER DELETE
+    ON transactions
+BEGIN
+    INSERT INTO audit_log (entity, entity_id, action, old_values, source)
+    VALUES ('transactions',
+            OLD.id,
+            'delete',
+            json_object('id', OLD.id,
+                        'category_id', OLD.category_id,
+                        'payee_id', OLD.payee_id,
+                        'account_id', OLD.account_id,
+                        'amount_in_cents', OLD.amount_in_cents,
+                        'transaction_date_unix', OLD.transaction_date_unix,
+                        'update_date_unix', OLD.update_date_unix,
+                        'delete_date_unix', OLD.delete_date_unix,
+                        'notes', OLD.notes,
+                        'status', OLD.status),
+            COALESCE((SELECT source FROM audit_source), 'api'));
+END;
+
+CREATE TRIGGER IF NOT EXISTS tr_payees_audit_insert
+    AFTER INSERT
+    ON payees
+BEGIN
+    INSERT INTO audit_log (entity, entity_id, action, new_values, source)
+    VALUES ('payees',
+            NEW.id,
+            'insert',
+            json_object('id', NEW.id,
+                        'name', NEW.name,
+                        'description', NEW.description),
+            COALESCE((SELECT source FROM audit_source), 'api'));
+END;
+
+CREATE TRIGGER IF NOT EXISTS tr_payees_audit_update
+    AFTER UPDATE
+    ON payees
+BEGIN
+    INSERT INTO audit_log (entity, entity_id, action, old_values, new_values, source)
+    SELECT 'payees', NEW.id, 'update', old_values, new_values, COALESCE((SELECT source FROM audit_source), 'api')
+    FROM (SELECT json_object('id', OLD.id,
+                             'name', OLD.name,
+                             'description', OLD.description) AS old_values,
+                 json_object('id', NEW.id,
+                             'name', NEW.name,
+                             'description', NEW.description) AS new_values)
+    -- Updates changing nothing aren't logged
+    WHERE old_values != new_values;
+END;
+
+CREATE TRIGGER IF NOT EXISTS tr_payees_audit_delete
+    AFTER DELETE
+    ON payees
+BEGIN
+    INSERT INTO audit_log (entity, entity_id, action, old_values, source)
+    VALUES ('payees',
+            OLD.id,
+            'delete',
+            json_object('id', OLD.id,
+                        'name', OLD.name,
+                        'description', OLD.description),
+            COALESCE((SELECT source FROM audit_source), 'api'));
+END;
+
+CREATE TRIGGER IF NOT EXISTS tr_categories_audit_insert
+    AFTER INSERT
+    ON categories
+BEGIN
+    INSERT INTO audit_log (entity, entity_id, action, new_values, source)
+    VALUES ('categories',
+            NEW.id,
+            'insert',
+            json_object('id', NEW.id,
+                        'name', NEW.name,
+                        'description', NEW.description,
+                        'parent_id', NEW.parent_id),
+            COALESCE((SELECT source FROM audit_source), 'api'));
+END;
+
+CREATE TRIGGER IF NOT EXISTS tr_categories_audit_update
+    AFTER UPDATE
+    ON categories
+BEGIN
+    INSERT INTO audit_log (entity, entity_id, action, old_values, new_values, source)
+    SELECT 'categories', NEW.id, 'update', old_values, new_values, COALESCE((SELECT source FROM audit_source), 'api')
+    FROM (SELECT json_object('id', OLD.id,
+                             'name', OLD.name,
+                             'description', OLD.description,
+                             'parent_id', OLD.parent_id) AS old_values,
+                 json_object('id', NEW.id,
+                             'name', NEW.name,
+                             'description', NEW.description,
+                             'parent_id', NEW.parent_id) AS new_values)
+    -- Updates changing nothing aren't logged
+    WHERE old_values != new_values;
+END;
+
+CREATE TRIGGER IF NOT EXISTS tr_categories_audit_delete
+    AFTER DELETE
+    ON categories
+BEGIN
+    INSERT INTO audit_log (entity, entity_id, action, old_values, source)
+    VALUES ('categories',
+            OLD.id,
+            'delete',
+            json_object('id', OLD.id,
+                        'name', OLD.name,
+                        'description', OLD.description,
+                        'parent_id', OLD.parent_id),
+            COALESCE((SELECT source FROM audit_source), 'api'));
+END;
@@ -0,0 +1,21 @@
+-- Rebuilds the categories table of DBs created before hierarchical categories (no parent_id),
+-- names were globally unique so every category becomes top-level.
+-- Must run with foreign keys disabled, otherwise dropping the table would reset the transactions categories
+CREATE TABLE categories_tree
+(
+    id          INTEGER PRIMARY KEY,
+    name        TEXT    NOT NULL,
+    description TEXT,
+    -- 0 ("no category") is the root, top-level categories have it as parent
+    parent_id   INTEGER NOT NULL DEFAULT 0,
+
+    UNIQUE (parent_id, name),
+    FOREIGN KEY (parent_id) REFERENCES categories ON DELETE SET DEFAULT
+);
+
+INSERT INTO categories_tree (id, name, description, parent_id)
+SELECT id, name, description, 0
+FROM categories;
+
+DROP TABLE categories;
+ALTER TABLE categories_tree RENAME TO categories;
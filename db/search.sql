@@ -0,0 +1,109 @@
+-- Full-text search index over transaction notes, payee names and category paths (rowid = transactions.id)
+-- Only applied when SQLite is compiled with FTS5 (`sqlite_fts5` build tag)
+CREATE VIRTUAL TABLE IF NOT EXISTS transactions_search USING fts5
+(
+    notes,
+    payee,
+    category,
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
+-- Full paths of the category and split categories of every transaction, as indexed in transactions_search
+CREATE VIEW IF NOT EXISTS transactions_search_categories AS
+SELECT t.id AS transaction_id,
+       (SELECT group_concat(cp.path, ' ')
+        FROM category_paths cp
+        WHERE cp.id = t.category_id
+           OR cp.id IN (SELECT category_id FROM transaction_splits WHERE transaction_id = t.id)) AS category
+FROM transactions t;
+
+CREATE TRIGGER IF NOT EXISTS tr_transactions_search_insert
+    AFTER INSERT
+    ON transactions
+BEGIN
+    INSERT INTO transactions_search (rowid, notes, payee, category)
+    VALUES (NEW.id,
+            NEW.notes,
+            (SELECT name FROM payees WHERE id = NEW.payee_id),
+            (SELECT category FROM transactions_search_categories WHERE transaction_id = NEW.id));
+END;
+
+CREATE TRIGGER IF NOT EXISTS tr_transactions_search_update
+    AFTER UPDATE OF notes, payee_id, category_id
+    ON transactions
+BEGIN
+    DELETE FROM transactions_search WHERE rowid = OLD.id;
+    INSERT INTO transactions_search (rowid, notes, payee, category)
+    VALUES (NEW.id,
+            NEW.notes,
+            (SELECT name FROM payees WHERE id = NEW.payee_id),
+            (SELECT category FROM transactions_search_categories WHERE transaction_id = NEW.id));
+END;
+
+CREATE TRIGGER IF NOT EXISTS tr_transactions_search_delete
+    AFTER DELETE
+    ON transactions
+BEGIN
+    DELETE FROM transactions_search WHERE rowid = OLD.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS tr_payees_search_update
+    AFTER UPDATE OF name
+    ON payees
+BEGIN
+    UPDATE transactions_search
+    SET payee = NEW.name
+    WHERE rowid IN (SELECT id FROM transactions WHERE payee_id = NEW.id);
+END;
+
+-- Renaming or moving a category changes its path and the paths of its subcategories
+CREATE TRIGGER IF NOT EXISTS tr_categories_search_update
+    AFTER UPDATE OF name, parent_id
+    ON categories
+BEGIN
+    UPDATE transactions_search
+    SET category = (SELECT category FROM transactions_search_categories WHERE transaction_id = transactions_search.rowid)
+    WHERE rowid IN (SELECT id
+                    FROM transactions
+                    WHERE category_id IN (SELECT category_id FROM category_ancestors WHERE ancestor_id = NEW.id)
+                    UNION
+                    SELECT transaction_id
+                    FROM transaction_splits
+                    WHERE category_id IN (SELECT category_id FROM category_ancestors WHERE ancestor_id = NEW.id));
+END;
+
+CREATE TRIGGER IF NOT EXISTS tr_transaction_splits_search_insert
+    AFTER INSERT
+    ON transaction_splits
+BEGIN
+    UPDATE transactions_search
+    SET category = (SELECT category FROM transactions_search_categories WHERE transaction_id = NEW.transaction_id)
+    WHERE rowid = NEW.transaction_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS tr_transaction_splits_search_update
+    AFTER UPDATE OF transaction_id, category_id
+    ON transaction_splits
+BEGIN
+    UPDATE transactions_search
+    SET category = (SELECT category FROM transactions_search_categories WHERE transaction_id = transactions_search.rowid)
+    WHERE rowid IN (OLD.transaction_id, NEW.transaction_id);
+END;
+
+CREATE TRIGGER IF NOT EXISTS tr_transaction_splits_search_delete
+    AFTER DELETE
+    ON transaction_splits
+BEGIN
+    UPDATE transactions_search
+    SET category = (SELECT category FROM transactions_search_categories WHERE transaction_id = OLD.transaction_id)
+    WHERE rowid = OLD.transaction_id;
+END;
+
+-- Index transactions created before the search index existed
+INSERT INTO transactions_search (rowid, notes, payee, category)
+SELECT t.id,
+       t.notes,
+       (SELECT name FROM payees WHERE id = t.payee_id),
+       (SELECT category FROM transactions_search_categories WHERE transaction_id = t.id)
+FROM transactions t
+WHERE t.id NOT IN (SELECT rowid FROM transactions_search);
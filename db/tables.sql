@@ -18,12 +18,50 @@ CREATE TABLE IF NOT EXISTS payees
 CREATE TABLE IF NOT EXISTS categories
 (
     id          INTEGER PRIMARY KEY,
-    name        TEXT NOT NULL UNIQUE,
-    description TEXT
+    name        TEXT    NOT NULL,
+    description TEXT,
+    -- 0 ("no category") is the root, top-level categories have it as parent
+    parent_id   INTEGER NOT NULL DEFAULT 0,
+
+    UNIQUE (parent_id, name),
+    FOREIGN KEY (parent_id) REFERENCES categories ON DELETE SET DEFAULT
 );
 INSERT OR IGNORE INTO categories (id, name)
 VALUES (0, 'no category');
 
+CREATE INDEX IF NOT EXISTS ix_categories_by_parent_id ON categories (parent_id);
+
+-- Full path (`Parent:Child`) and depth (0 for top-level categories) of every category
+CREATE VIEW IF NOT EXISTS category_paths AS
+WITH RECURSIVE paths (id, path, depth) AS
+(
+    SELECT id, name, 0
+    FROM categories
+    WHERE parent_id = 0
+    UNION ALL
+    SELECT c.id, p.path || ':' || c.name, p.depth + 1
+    FROM categories c
+    JOIN paths p ON c.parent_id = p.id
+    WHERE p.id != 0
+)
+SELECT id, path, depth
+FROM paths;
+
+-- Every category paired with itself and each of its ancestors, used to roll subcategories up
+CREATE VIEW IF NOT EXISTS category_ancestors AS
+WITH RECURSIVE ancestors (category_id, ancestor_id) AS
+(
+    SELECT id, id
+    FROM categories
+    UNION ALL
+    SELECT a.category_id, c.parent_id
+    FROM ancestors a
+    JOIN categories c ON c.id = a.ancestor_id
+    WHERE c.parent_id != 0
+)
+SELECT category_id, ancestor_id
+FROM ancestors;
+
 CREATE TABLE IF NOT EXISTS transactions
 (
     id                    INTEGER PRIMARY KEY,
@@ -35,6 +73,8 @@ CREATE TABLE IF NOT EXISTS transactions
     update_date_unix      INTEGER,
     delete_date_unix      INTEGER,
     notes                 TEXT,
+    -- 0 = uncommitted, 1 = cleared (confirmed by the bank), 2 = reconciled (locked)
+    status                INTEGER NOT NULL DEFAULT 0,
 
     FOREIGN KEY (category_id) REFERENCES categories ON DELETE SET DEFAULT,
     FOREIGN KEY (payee_id) REFERENCES payees ON DELETE RESTRICT,
@@ -42,3 +82,95 @@ CREATE TABLE IF NOT EXISTS transactions
 );
 
 CREATE INDEX IF NOT EXISTS ix_transactions_by_account_id_transaction_date_unix ON transactions (account_id, transaction_date_unix);
+
+CREATE TABLE IF NOT EXISTS transaction_splits
+(
+    id              INTEGER PRIMARY KEY,
+    transaction_id  INTEGER NOT NULL,
+    category_id     INTEGER NOT NULL DEFAULT 0,
+    amount_in_cents INTEGER NOT NULL,
+    notes           TEXT,
+
+    FOREIGN KEY (transaction_id) REFERENCES transactions ON DELETE CASCADE,
+    FOREIGN KEY (category_id) REFERENCES categories ON DELETE SET DEFAULT
+);
+
+CREATE INDEX IF NOT EXISTS ix_transaction_splits_by_transaction_id ON transaction_splits (transaction_id);
+
+CREATE TABLE IF NOT EXISTS tags
+(
+    id   INTEGER PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE COLLATE NOCASE
+);
+
+CREATE TABLE IF NOT EXISTS transaction_tags
+(
+    transaction_id INTEGER NOT NULL,
+    tag_id         INTEGER NOT NULL,
+
+    PRIMARY KEY (transaction_id, tag_id),
+    FOREIGN KEY (transaction_id) REFERENCES transactions ON DELETE CASCADE,
+    FOREIGN KEY (tag_id) REFERENCES tags ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS ix_transaction_tags_by_tag_id ON transaction_tags (tag_id);
+
+CREATE TABLE IF NOT EXISTS attachments
+(
+    id               INTEGER PRIMARY KEY,
+    transaction_id   INTEGER NOT NULL,
+    -- Original file name, the stored file is named after the content hash
+    file_name        TEXT    NOT NULL,
+    content_hash     TEXT    NOT NULL,
+    size_in_bytes    INTEGER NOT NULL,
+    create_date_unix INTEGER NOT NULL,
+
+    FOREIGN KEY (transaction_id) REFERENCES transactions ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS ix_attachments_by_transaction_id ON attachments (transaction_id);
+
+CREATE TABLE IF NOT EXISTS rules
+(
+    id                  INTEGER PRIMARY KEY,
+    name                TEXT    NOT NULL,
+    -- Evaluation order, lowest first
+    position            INTEGER NOT NULL,
+    -- Conditions, empty/NULL matches any transaction
+    payee_pattern       TEXT    NOT NULL DEFAULT '',
+    min_amount_in_cents INTEGER,
+    max_amount_in_cents INTEGER,
+    account_id          INTEGER,
+    notes_pattern       TEXT    NOT NULL DEFAULT '',
+    -- Actions, empty/NULL leaves the transaction unchanged
+    category_id         INTEGER,
+    payee_name          TEXT    NOT NULL DEFAULT '',
+    tags                TEXT    NOT NULL DEFAULT '',
+
+    FOREIGN KEY (account_id) REFERENCES accounts ON DELETE CASCADE,
+    FOREIGN KEY (category_id) REFERENCES categories ON DELETE SET NULL
+);
+
+-- Pairs of transactions marked as not duplicates, transaction_id < duplicate_id
+CREATE TABLE IF NOT EXISTS dismissed_duplicates
+(
+    transaction_id INTEGER NOT NULL,
+    duplicate_id   INTEGER NOT NULL,
+
+    PRIMARY KEY (transaction_id, duplicate_id),
+    FOREIGN KEY (transaction_id) REFERENCES transactions ON DELETE CASCADE,
+    FOREIGN KEY (duplicate_id) REFERENCES transactions ON DELETE CASCADE
+);
+
+-- Category lines of every transaction: its splits if any, the transaction itself otherwise
+CREATE VIEW IF NOT EXISTS transaction_lines AS
+SELECT s.transaction_id,
+       s.category_id,
+       s.amount_in_cents
+FROM transaction_splits s
+UNION ALL
+SELECT t.id,
+       t.category_id,
+       t.amount_in_cents
+FROM transactions t
+WHERE NOT EXISTS (SELECT 1 FROM transaction_splits s WHERE s.transaction_id = t.id);
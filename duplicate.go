@@ -0,0 +1,278 @@
+package ezex
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// DuplicateMaxDays is the default maximum distance, in days, between the dates of duplicate transactions
+	DuplicateMaxDays = 3
+	// DuplicateMinNotesSimilarity is the lowest notes similarity (see NotesSimilarity) of duplicate transactions
+	DuplicateMinNotesSimilarity = 0.6
+)
+
+// DuplicatePair is a likely duplicate: same account, amount and payee, close dates and similar notes.
+// Duplicate is the transaction created last
+type DuplicatePair struct {
+	Transaction     TransactionView
+	Duplicate       TransactionView
+	NotesSimilarity float64
+}
+
+// FindDuplicates returns the likely duplicates dated at most maxDays apart, excluding deleted transactions
+// and dismissed pairs (see DismissDuplicate), most recent first
+func FindDuplicates(db *sql.DB, maxDays int) []DuplicatePair {
+	return findDuplicates(db, maxDays)
+}
+
+func FindDuplicatesContext(ctx context.Context, db *sql.DB, maxDays int) []DuplicatePair {
+	return findDuplicates(withContext(ctx, db), maxDays)
+}
+
+func findDuplicates(db dbExecutor, maxDays int) []DuplicatePair {
+	type candidate struct {
+		TransactionID int
+		DuplicateID   int
+	}
+	candidates := dbGet[candidate](
+		db,
+		`
+		SELECT		t1.id,
+					t2.id
+		FROM		transactions t1
+		JOIN		transactions t2
+		ON			t2.account_id = t1.account_id
+				AND t2.amount_in_cents = t1.amount_in_cents
+				AND t2.payee_id = t1.payee_id
+				AND t2.id > t1.id
+				AND ABS(t2.transaction_date_unix - t1.transaction_date_unix) <= $maxSeconds
+		JOIN		accounts a
+		ON			a.id = t1.account_id
+		WHERE		t1.delete_date_unix IS NULL
+				AND t2.delete_date_unix IS NULL
+				AND a.delete_date_unix IS NULL
+				AND NOT EXISTS (
+					SELECT	1
+					FROM	dismissed_duplicates d
+					WHERE	d.transaction_id = t1.id
+						AND d.duplicate_id = t2.id
+				)
+		ORDER BY	t2.transaction_date_unix DESC, t2.id DESC
+		`,
+		maxSeconds(maxDays),
+	)
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	q := queryBuilder{}
+	ids := make([]int, 0, len(candidates)*2)
+	for _, c := range candidates {
+		ids = append(ids, c.TransactionID, c.DuplicateID)
+	}
+	whereIn(&q, "t.id", ids)
+
+	views := make(map[int]TransactionView, len(ids))
+	for _, view := range dbGet[TransactionView](db, transactionViewQuery+q.whereClause(), q.args...) {
+		views[view.ID] = view
+	}
+
+	var pairs []DuplicatePair
+	for _, c := range candidates {
+		transaction, duplicate := views[c.TransactionID], views[c.DuplicateID]
+		if similarity, ok := similarNotes(transaction.Notes.String, duplicate.Notes.String); ok {
+			pairs = append(pairs, DuplicatePair{
+				Transaction:     transaction,
+				Duplicate:       duplicate,
+				NotesSimilarity: similarity,
+			})
+		}
+	}
+
+	return pairs
+}
+
+// FindTransactionDuplicates returns the existing transactions a draft (e.g. a transaction being created) is
+// a likely duplicate of, most recent first
+func FindTransactionDuplicates(db *sql.DB, draft Transaction, maxDays int) []TransactionView {
+	return findTransactionDuplicates(db, draft, maxDays)
+}
+
+func FindTransactionDuplicatesContext(ctx context.Context, db *sql.DB, draft Transaction, maxDays int) []TransactionView {
+	return findTransactionDuplicates(withContext(ctx, db), draft, maxDays)
+}
+
+func findTransactionDuplicates(db dbExecutor, draft Transaction, maxDays int) []TransactionView {
+	transactions := dbGet[TransactionView](
+		db,
+		transactionViewQuery+`
+		WHERE		t.account_id = $accountID
+				AND t.amount_in_cents = $amountInCents
+				AND t.payee_id = $payeeID
+				AND t.id != $id
+				AND ABS(t.transaction_date_unix - $transactionDateUnix) <= $maxSeconds
+				AND t.delete_date_unix IS NULL
+		ORDER BY	`+newestFirst,
+		draft.AccountID,
+		draft.AmountInCents,
+		draft.PayeeID,
+		draft.ID,
+		draft.TransactionDateUnix,
+		maxSeconds(maxDays),
+	)
+
+	var duplicates []TransactionView
+	for _, transaction := range transactions {
+		if _, ok := similarNotes(draft.Notes.String, transaction.Notes.String); ok {
+			duplicates = append(duplicates, transaction)
+		}
+	}
+
+	return duplicates
+}
+
+// DismissDuplicate marks a pair of transactions as not duplicates, so that FindDuplicates skips it
+func DismissDuplicate(db *sql.DB, id int, otherID int) error {
+	return dismissDuplicate(db, id, otherID)
+}
+
+func DismissDuplicateContext(ctx context.Context, db *sql.DB, id int, otherID int) error {
+	return dismissDuplicate(withContext(ctx, db), id, otherID)
+}
+
+func dismissDuplicate(db dbExecutor, id int, otherID int) error {
+	_, err := db.Exec(
+		`INSERT OR IGNORE INTO dismissed_duplicates (transaction_id, duplicate_id) VALUES ($transactionID, $duplicateID)`,
+		min(id, otherID),
+		max(id, otherID),
+	)
+
+	return dbError(err, ErrNotFound)
+}
+
+// MergeDuplicate merges a duplicate into the transaction to keep: tags and attachments are moved, the notes and
+// category are copied if the kept transaction has none, then the duplicate is soft-deleted and its amount removed
+// from the account balance (see UpdateAccountBalance), all at once. Reconciled transactions can't be merged,
+// ErrNotFound is returned when either transaction is deleted (e.g. merging twice)
+func MergeDuplicate(db *sql.DB, keepID int, duplicateID int) error {
+	return mergeDuplicate(db, keepID, duplicateID)
+}
+
+func MergeDuplicateContext(ctx context.Context, db *sql.DB, keepID int, duplicateID int) error {
+	return mergeDuplicate(withContext(ctx, db), keepID, duplicateID)
+}
+
+func mergeDuplicate(db dbExecutor, keepID int, duplicateID int) error {
+	if keepID == duplicateID {
+		return fmt.Errorf("can't merge transaction %d with itself", keepID)
+	}
+
+	return dbTransaction(db, func(tx dbExecutor) error {
+		type transactionRow struct {
+			AccountID     int
+			AmountInCents int64
+		}
+		// Deleted transactions (e.g. an already merged duplicate) aren't found
+		query := `SELECT account_id, amount_in_cents FROM transactions WHERE id = $id AND delete_date_unix IS NULL`
+		keep := dbGet[transactionRow](tx, query, keepID)
+		duplicate := dbGet[transactionRow](tx, query, duplicateID)
+		if len(keep) == 0 || len(duplicate) == 0 {
+			return fmt.Errorf("%w: transaction %d or %d", ErrNotFound, keepID, duplicateID)
+		}
+		if keep[0].AccountID != duplicate[0].AccountID {
+			return fmt.Errorf("can't merge transactions of different accounts")
+		}
+		if isTransactionReconciled(tx, keepID) || isTransactionReconciled(tx, duplicateID) {
+			return ErrTransactionReconciled
+		}
+
+		queries := []string{
+			`
+			INSERT OR IGNORE INTO transaction_tags	(transaction_id, tag_id)
+			SELECT									$keepID, tag_id
+			FROM									transaction_tags
+			WHERE									transaction_id = $duplicateID
+			`,
+			`UPDATE attachments SET transaction_id = $keepID WHERE transaction_id = $duplicateID`,
+			`
+			UPDATE	transactions
+			SET		notes = (SELECT notes FROM transactions WHERE id = $duplicateID)
+			WHERE	id = $keepID
+				AND COALESCE(notes, '') = ''
+			`,
+			`
+			UPDATE	transactions
+			SET		category_id = (SELECT category_id FROM transactions WHERE id = $duplicateID)
+			WHERE	id = $keepID
+				AND category_id = 0
+				AND NOT EXISTS (SELECT 1 FROM transaction_splits WHERE transaction_id = $keepID)
+			`,
+		}
+		for _, query := range queries {
+			if _, err := tx.Exec(query, sql.Named("keepID", keepID), sql.Named("duplicateID", duplicateID)); err != nil {
+				return err
+			}
+		}
+
+		_, err := tx.Exec(
+			`UPDATE transactions SET delete_date_unix = $date WHERE id = $id`,
+			time.Now().Unix(),
+			duplicateID,
+		)
+		if err != nil {
+			return err
+		}
+
+		_, err = updateAccountBalance(tx, duplicate[0].AccountID, duplicate[0].AmountInCents)
+		return err
+	})
+}
+
+// NotesSimilarity compares two notes (case-insensitively), from 0 (different) to 1 (same),
+// it's based on the edit distance between them
+func NotesSimilarity(a string, b string) float64 {
+	ra := []rune(strings.ToLower(strings.TrimSpace(a)))
+	rb := []rune(strings.ToLower(strings.TrimSpace(b)))
+	if len(ra) == 0 && len(rb) == 0 {
+		return 1
+	}
+
+	// Levenshtein distance, keeping only the previous row
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return 1 - float64(previous[len(rb)])/float64(max(len(ra), len(rb)))
+}
+
+// similarNotes reports whether the notes are similar enough for duplicates, missing notes match any note
+// (e.g. imported transactions with notes and the same ones entered by hand without)
+func similarNotes(a string, b string) (similarity float64, ok bool) {
+	similarity = NotesSimilarity(a, b)
+	if strings.TrimSpace(a) == "" || strings.TrimSpace(b) == "" {
+		return similarity, true
+	}
+
+	return similarity, similarity >= DuplicateMinNotesSimilarity
+}
+
+func maxSeconds(days int) int64 {
+	return int64(days) * int64(24*time.Hour/time.Second)
+}
@@ -0,0 +1,129 @@
+package ezex
+
+import (
+	"database/sql"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestNotesSimilarity(t *testing.T) {
+	assert.Equal(t, 1.0, NotesSimilarity("", " "))
+	assert.Equal(t, 1.0, NotesSimilarity("Weekly groceries", "weekly GROCERIES "))
+	assert.Equal(t, 0.0, NotesSimilarity("abc", ""))
+	assert.InDelta(t, 0.75, NotesSimilarity("fuel", "fuek"), 0.001)
+	assert.Less(t, NotesSimilarity("rent", "dinner with friends"), DuplicateMinNotesSimilarity)
+}
+
+func TestFindDuplicates(t *testing.T) {
+	payeeID, _ := AddPayee(testDB, Payee{Name: "TestFindDuplicates"})
+	accountID, _ := AddAccount(testDB, Account{Name: "TestFindDuplicates"})
+	date := time.Date(2107, 1, 10, 0, 0, 0, 0, time.Local)
+	add := func(days int, amountInCents int64, notes string) int {
+		id, _ := AddTransaction(testDB, Transaction{
+			PayeeID:             payeeID,
+			AccountID:           accountID,
+			AmountInCents:       amountInCents,
+			TransactionDateUnix: date.AddDate(0, 0, days).Unix(),
+			Notes:               sql.NullString{String: notes, Valid: notes != ""},
+		})
+		return id
+	}
+
+	originalID := add(0, -1000, "Pizza night")
+	duplicateID := add(2, -1000, "pizza night!")
+	manualID := add(-1, -1000, "")
+	add(10, -1000, "Pizza night")    // too far
+	add(1, -1001, "Pizza night")     // different amount
+	add(1, -1000, "Car maintenance") // different notes
+
+	// Missing notes match any note
+	pairs := accountDuplicates(FindDuplicates(testDB, DuplicateMaxDays), accountID)
+	assert.Len(t, pairs, 4)
+	assert.Equal(t, originalID, pairs[0].Transaction.ID)
+	assert.Equal(t, duplicateID, pairs[0].Duplicate.ID)
+	assert.Greater(t, pairs[0].NotesSimilarity, DuplicateMinNotesSimilarity)
+
+	// Drafts are checked the same way
+	draft := Transaction{
+		PayeeID:             payeeID,
+		AccountID:           accountID,
+		AmountInCents:       -1000,
+		TransactionDateUnix: date.Unix(),
+		Notes:               sql.NullString{String: "Pizza night", Valid: true},
+	}
+	assert.Len(t, FindTransactionDuplicates(testDB, draft, DuplicateMaxDays), 3)
+	assert.Len(t, FindTransactionDuplicates(testDB, draft, 0), 1)
+
+	assert.Nil(t, DismissDuplicate(testDB, duplicateID, originalID))
+	pairs = accountDuplicates(FindDuplicates(testDB, DuplicateMaxDays), accountID)
+	assert.Len(t, pairs, 3)
+	for _, pair := range pairs {
+		assert.Contains(t, []int{pair.Transaction.ID, pair.Duplicate.ID}, manualID)
+	}
+}
+
+func TestMergeDuplicate(t *testing.T) {
+	payeeID, _ := AddPayee(testDB, Payee{Name: "TestMergeDuplicate"})
+	accountID, _ := AddAccount(testDB, Account{Name: "TestMergeDuplicate"})
+	otherAccountID, _ := AddAccount(testDB, Account{Name: "TestMergeDuplicate_other"})
+	categoryID, _ := AddCategory(testDB, Category{Name: "TestMergeDuplicate"})
+	date := time.Date(2107, 2, 1, 0, 0, 0, 0, time.Local).Unix()
+
+	keepID, _ := AddTransaction(testDB, Transaction{PayeeID: payeeID, AccountID: accountID, AmountInCents: -500, TransactionDateUnix: date})
+	duplicateID, _ := AddTransaction(testDB, Transaction{
+		PayeeID:             payeeID,
+		AccountID:           accountID,
+		CategoryID:          categoryID,
+		AmountInCents:       -500,
+		TransactionDateUnix: date,
+		Notes:               sql.NullString{String: "Imported", Valid: true},
+	})
+	_ = TagTransaction(testDB, duplicateID, "TestMergeDuplicate")
+	otherID, _ := AddTransaction(testDB, Transaction{PayeeID: payeeID, AccountID: otherAccountID, AmountInCents: -500, TransactionDateUnix: date})
+	reconciledID, _ := AddTransaction(testDB, Transaction{PayeeID: payeeID, AccountID: accountID, AmountInCents: -500, TransactionDateUnix: date})
+	_ = Reconcile(testDB, accountID, []int{reconciledID})
+
+	assert.Error(t, MergeDuplicate(testDB, keepID, keepID))
+	assert.Error(t, MergeDuplicate(testDB, keepID, otherID))
+	assert.ErrorIs(t, MergeDuplicate(testDB, keepID, reconciledID), ErrTransactionReconciled)
+	assert.ErrorIs(t, MergeDuplicate(testDB, reconciledID, keepID), ErrTransactionReconciled)
+	assert.Nil(t, MergeDuplicate(testDB, keepID, duplicateID))
+	// The duplicate expense is removed from the balance
+	account, _ := GetAccount(testDB, accountID)
+	assert.Equal(t, int64(500), account.BalanceInCents)
+
+	// Merging again (e.g. a stale review entry) finds no duplicate, the balance is adjusted once
+	assert.ErrorIs(t, MergeDuplicate(testDB, keepID, duplicateID), ErrNotFound)
+	assert.ErrorIs(t, MergeDuplicate(testDB, duplicateID, keepID), ErrNotFound)
+	account, _ = GetAccount(testDB, accountID)
+	assert.Equal(t, int64(500), account.BalanceInCents)
+
+	transactions := FilterTransactions(testDB, TransactionFilter{AccountIDs: []int{accountID}, IncludeDeleted: true})
+	assert.Len(t, transactions, 3)
+	for _, transaction := range transactions {
+		switch transaction.ID {
+		case keepID:
+			assert.False(t, transaction.DeleteDateUnix.Valid)
+			assert.Equal(t, categoryID, transaction.CategoryID)
+			assert.Equal(t, "Imported", transaction.Notes.String)
+			assert.Equal(t, "TestMergeDuplicate", transaction.Tags)
+		case duplicateID:
+			assert.True(t, transaction.DeleteDateUnix.Valid)
+		case reconciledID:
+			assert.False(t, transaction.DeleteDateUnix.Valid)
+		}
+	}
+}
+
+// accountDuplicates returns the pairs of an account
+func accountDuplicates(pairs []DuplicatePair, accountID int) []DuplicatePair {
+	var filtered []DuplicatePair
+	for _, pair := range pairs {
+		if pair.Transaction.AccountID == accountID {
+			filtered = append(filtered, pair)
+		}
+	}
+
+	return filtered
+}
@@ -0,0 +1,78 @@
+// Package export writes ez-ex data (transactions, accounts, payees and categories) as CSV or JSON.
+//
+// Transactions are streamed from the DB one row at a time, so exporting large ledgers
+// does not require loading them entirely into memory.
+package export
+
+import (
+	"database/sql"
+	"fmt"
+	ezex "github.com/armanimichael/ez-ex"
+	"io"
+)
+
+type Format string
+
+const (
+	CSV  Format = "csv"
+	JSON Format = "json"
+)
+
+// ParseFormat returns the Format matching name (`csv` or `json`)
+func ParseFormat(name string) (Format, error) {
+	switch Format(name) {
+	case CSV, JSON:
+		return Format(name), nil
+	}
+
+	return "", fmt.Errorf("unsupported export format: %s", name)
+}
+
+// Transactions writes the transactions matching the filter to w
+func Transactions(db *sql.DB, w io.Writer, format Format, filter ezex.TransactionFilter) error {
+	rw, err := newRecordWriter(w, format, transactionHeader)
+	if err != nil {
+		return err
+	}
+
+	err = ezex.WalkTransactions(db, filter, func(t ezex.TransactionView) error {
+		return rw.write(newTransactionRecord(t))
+	})
+	if err != nil {
+		return err
+	}
+
+	return rw.close()
+}
+
+// Accounts writes every non-deleted account to w
+func Accounts(db *sql.DB, w io.Writer, format Format) error {
+	return writeAll(w, format, accountHeader, ezex.GetAccounts(db), newAccountRecord)
+}
+
+// Payees writes every payee to w
+func Payees(db *sql.DB, w io.Writer, format Format) error {
+	return writeAll(w, format, namedHeader, ezex.GetPayees(db), func(p ezex.Payee) record {
+		return newNamedRecord(p.ID, p.Name, p.Description)
+	})
+}
+
+// Categories writes every category to w, parents before their subcategories
+func Categories(db *sql.DB, w io.Writer, format Format) error {
+	return writeAll(w, format, categoryHeader, ezex.GetCategoryTree(db), newCategoryRecord)
+}
+
+func writeAll[T any](w io.Writer, format Format, header []string, entities []T, toRecord func(T) record) error {
+	rw, err := newRecordWriter(w, format, header)
+	if err != nil {
+		return err
+	}
+
+	for _, entity := range entities {
+		if err = rw.write(toRecord(entity)); err != nil {
+			return err
+		}
+	}
+
+	return rw.close()
+}
@@ -0,0 +1,138 @@
+package export
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	ezex "github.com/armanimichael/ez-ex"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"path"
+	"testing"
+	"time"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", path.Join(t.TempDir(), "export-test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	if err = ezex.MigrateDB(db); err != nil {
+		t.Fatal(err)
+	}
+
+	accountID, _ := ezex.AddAccount(db, ezex.Account{Name: "Account"})
+	otherAccountID, _ := ezex.AddAccount(db, ezex.Account{Name: "Other"})
+	payeeID, _ := ezex.AddPayee(db, ezex.Payee{Name: "Payee"})
+	categoryID, _ := ezex.AddCategory(db, ezex.Category{Name: "Category"})
+
+	_, _ = ezex.AddTransaction(db, ezex.Transaction{
+		CategoryID:          categoryID,
+		PayeeID:             payeeID,
+		AccountID:           accountID,
+		AmountInCents:       -1234,
+		TransactionDateUnix: time.Date(2023, 1, 10, 0, 0, 0, 0, time.Local).Unix(),
+		Notes:               sql.NullString{String: "comma, \"quoted\"", Valid: true},
+	})
+	_, _ = ezex.AddTransaction(db, ezex.Transaction{
+		PayeeID:             payeeID,
+		AccountID:           accountID,
+		AmountInCents:       500,
+		TransactionDateUnix: time.Date(2023, 2, 10, 0, 0, 0, 0, time.Local).Unix(),
+	})
+	_, _ = ezex.AddTransaction(db, ezex.Transaction{
+		PayeeID:             payeeID,
+		AccountID:           otherAccountID,
+		AmountInCents:       700,
+		TransactionDateUnix: time.Date(2023, 1, 15, 0, 0, 0, 0, time.Local).Unix(),
+	})
+
+	return db
+}
+
+func TestTransactions_CSV(t *testing.T) {
+	db := openTestDB(t)
+	buf := bytes.Buffer{}
+
+	err := Transactions(db, &buf, CSV, ezex.TransactionFilter{
+		AccountIDs: []int{1},
+		MaxDate:    time.Date(2023, 2, 1, 0, 0, 0, 0, time.Local),
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(
+		t,
+		"id,date,account_id,account,payee_id,payee,category_id,category,amount_in_cents,notes\n"+
+			"1,2023-01-10,1,Account,1,Payee,1,Category,-1234,\"comma, \"\"quoted\"\"\"\n",
+		buf.String(),
+	)
+}
+
+func TestTransactions_JSON(t *testing.T) {
+	db := openTestDB(t)
+	buf := bytes.Buffer{}
+
+	err := Transactions(db, &buf, JSON, ezex.TransactionFilter{
+		MinDate: time.Date(2023, 1, 11, 0, 0, 0, 0, time.Local),
+	})
+
+	var records []transactionRecord
+	assert.Nil(t, err)
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &records))
+	assert.Equal(t, []transactionRecord{
+		{ID: 3, Date: "2023-01-15", AccountID: 2, Account: "Other", PayeeID: 1, Payee: "Payee", Category: "no category", AmountInCents: 700},
+		{ID: 2, Date: "2023-02-10", AccountID: 1, Account: "Account", PayeeID: 1, Payee: "Payee", Category: "no category", AmountInCents: 500},
+	}, records)
+}
+
+func TestTransactions_EmptyJSON(t *testing.T) {
+	db := openTestDB(t)
+	buf := bytes.Buffer{}
+
+	err := Transactions(db, &buf, JSON, ezex.TransactionFilter{AccountIDs: []int{999}})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "[]\n", buf.String())
+}
+
+func TestAccounts(t *testing.T) {
+	db := openTestDB(t)
+	buf := bytes.Buffer{}
+
+	err := Accounts(db, &buf, CSV)
+
+	assert.Nil(t, err)
+	assert.Equal(
+		t,
+		"id,name,description,initial_balance_in_cents,balance_in_cents\n2,Other,,0,0\n1,Account,,0,0\n",
+		buf.String(),
+	)
+}
+
+func TestParseFormat(t *testing.T) {
+	format, err := ParseFormat("json")
+	assert.Nil(t, err)
+	assert.Equal(t, JSON, format)
+
+	_, err = ParseFormat("xml")
+	assert.Error(t, err)
+}
+
+func TestCategories(t *testing.T) {
+	db := openTestDB(t)
+	_, _ = ezex.AddCategoryPath(db, "Category:Child")
+	buf := bytes.Buffer{}
+
+	err := Categories(db, &buf, CSV)
+
+	assert.Nil(t, err)
+	assert.Equal(
+		t,
+		"id,name,description,parent_id,path\n0,no category,,0,no category\n1,Category,,0,Category\n2,Child,,1,Category:Child\n",
+		buf.String(),
+	)
+}
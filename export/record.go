@@ -0,0 +1,186 @@
+package export
+
+import (
+	"database/sql"
+	ezex "github.com/armanimichael/ez-ex"
+	"strconv"
+	"time"
+)
+
+// record is a single exported row, JSON uses the struct tags while CSV uses csvValues
+type record interface {
+	csvValues() []string
+}
+
+var transactionHeader = []string{
+	"id",
+	"date",
+	"account_id",
+	"account",
+	"payee_id",
+	"payee",
+	"category_id",
+	"category",
+	"amount_in_cents",
+	"notes",
+}
+
+type transactionRecord struct {
+	ID            int     `json:"id"`
+	Date          string  `json:"date"`
+	AccountID     int     `json:"account_id"`
+	Account       string  `json:"account"`
+	PayeeID       int     `json:"payee_id"`
+	Payee         string  `json:"payee"`
+	CategoryID    int     `json:"category_id"`
+	Category      string  `json:"category"`
+	AmountInCents int64   `json:"amount_in_cents"`
+	Notes         *string `json:"notes"`
+}
+
+func newTransactionRecord(t ezex.TransactionView) record {
+	return transactionRecord{
+		ID:            t.ID,
+		Date:          time.Unix(t.TransactionDateUnix, 0).Format(time.DateOnly),
+		AccountID:     t.AccountID,
+		Account:       t.AccountName,
+		PayeeID:       t.PayeeID,
+		Payee:         t.PayeeName,
+		CategoryID:    t.CategoryID,
+		Category:      t.CategoryName,
+		AmountInCents: t.AmountInCents,
+		Notes:         nullableString(t.Notes),
+	}
+}
+
+func (r transactionRecord) csvValues() []string {
+	return []string{
+		strconv.Itoa(r.ID),
+		r.Date,
+		strconv.Itoa(r.AccountID),
+		r.Account,
+		strconv.Itoa(r.PayeeID),
+		r.Payee,
+		strconv.Itoa(r.CategoryID),
+		r.Category,
+		strconv.FormatInt(r.AmountInCents, 10),
+		stringOrEmpty(r.Notes),
+	}
+}
+
+var accountHeader = []string{
+	"id",
+	"name",
+	"description",
+	"initial_balance_in_cents",
+	"balance_in_cents",
+}
+
+type accountRecord struct {
+	ID                    int     `json:"id"`
+	Name                  string  `json:"name"`
+	Description           *string `json:"description"`
+	InitialBalanceInCents int64   `json:"initial_balance_in_cents"`
+	BalanceInCents        int64   `json:"balance_in_cents"`
+}
+
+func newAccountRecord(a ezex.Account) record {
+	return accountRecord{
+		ID:                    a.ID,
+		Name:                  a.Name,
+		Description:           nullableString(a.Description),
+		InitialBalanceInCents: a.InitialBalanceInCents,
+		BalanceInCents:        a.BalanceInCents,
+	}
+}
+
+func (r accountRecord) csvValues() []string {
+	return []string{
+		strconv.Itoa(r.ID),
+		r.Name,
+		stringOrEmpty(r.Description),
+		strconv.FormatInt(r.InitialBalanceInCents, 10),
+		strconv.FormatInt(r.BalanceInCents, 10),
+	}
+}
+
+// namedHeader is used by payees
+var namedHeader = []string{
+	"id",
+	"name",
+	"description",
+}
+
+type namedRecord struct {
+	ID          int     `json:"id"`
+	Name        string  `json:"name"`
+	Description *string `json:"description"`
+}
+
+func newNamedRecord(id int, name string, description sql.NullString) record {
+	return namedRecord{
+		ID:          id,
+		Name:        name,
+		Description: nullableString(description),
+	}
+}
+
+func (r namedRecord) csvValues() []string {
+	return []string{
+		strconv.Itoa(r.ID),
+		r.Name,
+		stringOrEmpty(r.Description),
+	}
+}
+
+var categoryHeader = []string{
+	"id",
+	"name",
+	"description",
+	"parent_id",
+	"path",
+}
+
+type categoryRecord struct {
+	ID          int     `json:"id"`
+	Name        string  `json:"name"`
+	Description *string `json:"description"`
+	ParentID    int     `json:"parent_id"`
+	Path        string  `json:"path"`
+}
+
+func newCategoryRecord(c ezex.Category) record {
+	return categoryRecord{
+		ID:          c.ID,
+		Name:        c.Name,
+		Description: nullableString(c.Description),
+		ParentID:    c.ParentID,
+		Path:        c.Path,
+	}
+}
+
+func (r categoryRecord) csvValues() []string {
+	return []string{
+		strconv.Itoa(r.ID),
+		r.Name,
+		stringOrEmpty(r.Description),
+		strconv.Itoa(r.ParentID),
+		r.Path,
+	}
+}
+
+func nullableString(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+
+	return &s.String
+}
+
+func stringOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+
+	return *s
+}
@@ -0,0 +1,77 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+type recordWriter interface {
+	write(r record) error
+	close() error
+}
+
+func newRecordWriter(w io.Writer, format Format, header []string) (recordWriter, error) {
+	switch format {
+	case CSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(header); err != nil {
+			return nil, err
+		}
+
+		return csvWriter{writer: cw}, nil
+	case JSON:
+		return &jsonWriter{writer: w}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported export format: %s", format)
+}
+
+type csvWriter struct {
+	writer *csv.Writer
+}
+
+func (c csvWriter) write(r record) error {
+	return c.writer.Write(r.csvValues())
+}
+
+func (c csvWriter) close() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+// jsonWriter writes records as a JSON array, one element at a time
+type jsonWriter struct {
+	writer io.Writer
+	count  int
+}
+
+func (j *jsonWriter) write(r record) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	separator := ",\n"
+	if j.count == 0 {
+		separator = "[\n"
+	}
+	j.count++
+
+	if _, err = io.WriteString(j.writer, separator); err != nil {
+		return err
+	}
+	_, err = j.writer.Write(data)
+	return err
+}
+
+func (j *jsonWriter) close() error {
+	closing := "\n]\n"
+	if j.count == 0 {
+		closing = "[]\n"
+	}
+
+	_, err := io.WriteString(j.writer, closing)
+	return err
+}
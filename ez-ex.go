@@ -1,49 +1,328 @@
 package ezex
 
 import (
+	"context"
 	"database/sql"
 	_ "embed"
+	"errors"
 	"fmt"
 	_ "github.com/mattn/go-sqlite3"
+	"net/url"
 	"os"
 	"path"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
 )
 
 //go:embed db/tables.sql
 var dbInitScriptSQL string
 
+//go:embed db/audit.sql
+var dbAuditScriptSQL string
+
+//go:embed db/search.sql
+var dbSearchScriptSQL string
+
+//go:embed db/migrate-categories.sql
+var dbMigrateCategoriesScriptSQL string
+
 const DefaultDBName = "user-data.db"
+
+// UserDataDir is the data directory name inside the user home (see DataDir)
 const UserDataDir = ".ez-ex"
 
+// DataDirEnv overrides the data directory (see DataDir)
+const DataDirEnv = "EZEX_HOME"
+
+// xdgDataDir is the data directory name inside $XDG_DATA_HOME
+const xdgDataDir = "ez-ex"
+
+// memoryDBCount makes each in-memory DB unique (see WithInMemory)
+var memoryDBCount atomic.Int64
+
 type appOptions struct {
-	dbName string
+	dataDir   string
+	dbName    string
+	dbPath    string
+	inMemory  bool
+	dsnParams url.Values
 }
 
 type OptionsBuilder = func(*appOptions)
 
-// WithDBName sets user-data DB name
+// WithDataDir sets the user-data directory, instead of DataDir
+func WithDataDir(dir string) OptionsBuilder {
+	return func(o *appOptions) {
+		o.dataDir = dir
+	}
+}
+
+// WithDBName sets user-data DB name, inside the data directory
 func WithDBName(name string) OptionsBuilder {
 	return func(o *appOptions) {
 		o.dbName = name
 	}
 }
 
-func OpenDB(opts ...OptionsBuilder) (*sql.DB, error) {
+// WithDBPath sets the DB file path, ignoring the data directory and DB name
+func WithDBPath(dbPath string) OptionsBuilder {
+	return func(o *appOptions) {
+		o.dbPath = dbPath
+	}
+}
+
+// WithInMemory opens a new, empty in-memory DB shared by the pool connections, it's lost once the DB is closed
+func WithInMemory() OptionsBuilder {
+	return func(o *appOptions) {
+		o.inMemory = true
+	}
+}
+
+// WithDSNParam adds a parameter to the DSN (e.g. `_busy_timeout`, see github.com/mattn/go-sqlite3),
+// overriding the default ones
+func WithDSNParam(key string, value string) OptionsBuilder {
+	return func(o *appOptions) {
+		o.dsnParams.Set(key, value)
+	}
+}
+
+func newAppOptions(opts []OptionsBuilder) appOptions {
 	options := appOptions{
-		dbName: DefaultDBName,
+		dbName:    DefaultDBName,
+		dsnParams: url.Values{},
 	}
 	for _, option := range opts {
 		option(&options)
 	}
+	if options.dataDir == "" {
+		options.dataDir = DataDir()
+	}
+
+	return options
+}
+
+// DataDir returns the user-data directory: $EZEX_HOME if set, `~/.ez-ex` if it already exists,
+// `$XDG_DATA_HOME/ez-ex` if XDG_DATA_HOME is set, `~/.ez-ex` otherwise
+func DataDir() string {
+	if dir := os.Getenv(DataDirEnv); dir != "" {
+		return dir
+	}
 
 	home, _ := os.UserHomeDir()
-	_ = os.MkdirAll(path.Join(home, UserDataDir), 0700)
+	legacyDir := path.Join(home, UserDataDir)
+	if _, err := os.Stat(legacyDir); err == nil {
+		return legacyDir
+	}
+	if xdgDataHome := os.Getenv("XDG_DATA_HOME"); xdgDataHome != "" {
+		return path.Join(xdgDataHome, xdgDataDir)
+	}
+
+	return legacyDir
+}
+
+// DBPath returns the path of the DB file opened with the same options, empty for in-memory DBs
+func DBPath(opts ...OptionsBuilder) string {
+	options := newAppOptions(opts)
+	if options.inMemory {
+		return ""
+	}
+	if options.dbPath != "" {
+		return options.dbPath
+	}
+
+	return path.Join(options.dataDir, options.dbName)
+}
+
+// OpenDB opens the user-data DB (see DBPath), creating its directory if missing. Foreign keys are enabled
+func OpenDB(opts ...OptionsBuilder) (*sql.DB, error) {
+	options := newAppOptions(opts)
+
+	params := url.Values{"_foreign_keys": {"true"}}
+	for key, values := range options.dsnParams {
+		params[key] = values
+	}
+
+	if options.inMemory {
+		// The memdb VFS shares the DB between the connections opening the same name, unlike `:memory:`
+		params.Set("vfs", "memdb")
+		return sql.Open("sqlite3", fmt.Sprintf("file:/ez-ex-%d?%s", memoryDBCount.Add(1), params.Encode()))
+	}
+
+	dbPath := DBPath(opts...)
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0700); err != nil {
+		return nil, err
+	}
+
+	return sql.Open("sqlite3", fileDSN(dbPath, params))
+}
+
+// fileDSN is the SQLite URI of the DB file at dbPath with the given query params
+func fileDSN(dbPath string, params url.Values) string {
+	// Characters with a meaning in URIs are escaped, SQLite decodes them
+	escapedPath := strings.NewReplacer("%", "%25", "?", "%3f", "#", "%23", " ", "%20").Replace(dbPath)
+	if len(params) == 0 {
+		return "file:" + escapedPath
+	}
 
-	dsName := fmt.Sprintf("file:%s/.ez-ex/%s?_foreign_keys=true", home, options.dbName)
-	return sql.Open("sqlite3", dsName)
+	return fmt.Sprintf("file:%s?%s", escapedPath, params.Encode())
 }
 
-func MigrateDB(db *sql.DB) error {
-	_, err := db.Exec(dbInitScriptSQL)
+type migrateOptions struct {
+	backupDir  string
+	backupName string
+}
+
+type MigrateOptionsBuilder = func(*migrateOptions)
+
+// WithMigrationBackup backs up existing DBs into dir (see CreateBackup) before MigrateDB changes them
+func WithMigrationBackup(dir string, name string) MigrateOptionsBuilder {
+	return func(o *migrateOptions) {
+		o.backupDir = dir
+		o.backupName = name
+	}
+}
+
+func MigrateDB(db *sql.DB, opts ...MigrateOptionsBuilder) error {
+	return MigrateDBContext(context.Background(), db, opts...)
+}
+
+func MigrateDBContext(ctx context.Context, db *sql.DB, opts ...MigrateOptionsBuilder) error {
+	options := migrateOptions{}
+	for _, option := range opts {
+		option(&options)
+	}
+
+	executor := withContext(ctx, db)
+	if options.backupDir != "" && needsMigration(executor) {
+		if _, err := CreateBackupContext(ctx, db, options.backupDir, options.backupName); err != nil {
+			return fmt.Errorf("backup before migrating: %w", err)
+		}
+	}
+
+	if err := migrateCategoriesTree(ctx, db); err != nil {
+		return err
+	}
+	if err := migrateTransactionStatus(executor); err != nil {
+		return err
+	}
+
+	if _, err := executor.Exec(dbInitScriptSQL); err != nil {
+		return err
+	}
+	if _, err := executor.Exec(dbAuditScriptSQL); err != nil {
+		return err
+	}
+
+	// The full-text search index is optional, SearchTransactions falls back to plain pattern matching without it
+	if !isFTS5Available(executor) {
+		return nil
+	}
+
+	if err := migrateSearchIndex(executor); err != nil {
+		return err
+	}
+
+	_, err := executor.Exec(dbSearchScriptSQL)
+	return err
+}
+
+// isFTS5Available reports whether SQLite was compiled with FTS5 (`sqlite_fts5` build tag)
+func isFTS5Available(db dbExecutor) bool {
+	var used bool
+	err := db.QueryRow(`SELECT sqlite_compileoption_used('ENABLE_FTS5')`).Scan(&used)
+
+	return err == nil && used
+}
+
+// migrateCategoriesTree adds the parent category to DBs created before hierarchical categories,
+// it's noop on new or already migrated DBs
+func migrateCategoriesTree(ctx context.Context, db *sql.DB) error {
+	var columns, parentColumns int
+	err := db.QueryRowContext(
+		ctx,
+		`SELECT COUNT(*), COUNT(CASE WHEN name = 'parent_id' THEN 1 END) FROM pragma_table_info('categories')`,
+	).Scan(&columns, &parentColumns)
+	if err != nil || columns == 0 || parentColumns > 0 {
+		return err
+	}
+
+	// Foreign keys can only be toggled outside transactions and per connection
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer func(conn *sql.Conn) {
+		_ = conn.Close()
+	}(conn)
+
+	var foreignKeys bool
+	if err = conn.QueryRowContext(ctx, `PRAGMA foreign_keys`).Scan(&foreignKeys); err != nil {
+		return err
+	}
+	// Legacy renames keep the triggers and views referencing the dropped table as they are
+	if _, err = conn.ExecContext(ctx, `PRAGMA foreign_keys = OFF; PRAGMA legacy_alter_table = ON`); err != nil {
+		return err
+	}
+	defer func(conn *sql.Conn) {
+		// The connection goes back to the pool, so the pragmas are restored even if ctx is done
+		ctx := context.WithoutCancel(ctx)
+		_, _ = conn.ExecContext(ctx, `PRAGMA legacy_alter_table = OFF`)
+		if foreignKeys {
+			_, _ = conn.ExecContext(ctx, `PRAGMA foreign_keys = ON`)
+		}
+	}(conn)
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if _, err = tx.Exec(dbMigrateCategoriesScriptSQL); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// migrateSearchIndex drops the search index of DBs created when it only had the category names, not their paths,
+// so that the search script creates it again. It's noop on new or already migrated DBs
+func migrateSearchIndex(db dbExecutor) error {
+	var triggerSQL string
+	err := db.QueryRow(
+		`SELECT sql FROM sqlite_master WHERE type = 'trigger' AND name = 'tr_transactions_search_insert'`,
+	).Scan(&triggerSQL)
+	if errors.Is(err, sql.ErrNoRows) || strings.Contains(triggerSQL, "transactions_search_categories") {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return dbTransaction(db, func(tx dbExecutor) error {
+		_, err := tx.Exec(`
+			DROP TRIGGER tr_transactions_search_insert;
+			DROP TRIGGER IF EXISTS tr_transactions_search_update;
+			DROP TRIGGER IF EXISTS tr_transactions_search_delete;
+			DROP TRIGGER IF EXISTS tr_payees_search_update;
+			DROP TRIGGER IF EXISTS tr_categories_search_update;
+			DROP TABLE IF EXISTS transactions_search;
+		`)
+		return err
+	})
+}
+
+// migrateTransactionStatus adds the status to DBs created before cleared/reconciled transactions,
+// it's noop on new or already migrated DBs
+func migrateTransactionStatus(db dbExecutor) error {
+	var columns, statusColumns int
+	err := db.QueryRow(
+		`SELECT COUNT(*), COUNT(CASE WHEN name = 'status' THEN 1 END) FROM pragma_table_info('transactions')`,
+	).Scan(&columns, &statusColumns)
+	if err != nil || columns == 0 || statusColumns > 0 {
+		return err
+	}
+
+	_, err = db.Exec(`ALTER TABLE transactions ADD COLUMN status INTEGER NOT NULL DEFAULT 0`)
 	return err
 }
@@ -0,0 +1,82 @@
+package ezex
+
+import (
+	"context"
+	"database/sql"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestDataDir(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(DataDirEnv, "")
+	t.Setenv("XDG_DATA_HOME", "")
+	assert.Equal(t, path.Join(home, UserDataDir), DataDir())
+
+	t.Setenv("XDG_DATA_HOME", path.Join(home, "xdg"))
+	assert.Equal(t, path.Join(home, "xdg", "ez-ex"), DataDir())
+
+	// Existing data isn't moved
+	_ = os.Mkdir(path.Join(home, UserDataDir), 0700)
+	assert.Equal(t, path.Join(home, UserDataDir), DataDir())
+
+	t.Setenv(DataDirEnv, path.Join(home, "ledgers"))
+	assert.Equal(t, path.Join(home, "ledgers"), DataDir())
+	assert.Equal(t, path.Join(home, "ledgers", AttachmentsDirName), DefaultAttachmentsDir())
+}
+
+func TestOpenDB(t *testing.T) {
+	dir := t.TempDir()
+
+	assert.Equal(t, path.Join(dir, "data", DefaultDBName), DBPath(WithDataDir(path.Join(dir, "data"))))
+	assert.Equal(t, path.Join(dir, "data", "other.db"), DBPath(WithDataDir(path.Join(dir, "data")), WithDBName("other.db")))
+	assert.Equal(t, path.Join(dir, "ledger#1.db"), DBPath(WithDataDir(dir), WithDBPath(path.Join(dir, "ledger#1.db"))))
+	assert.Equal(t, "", DBPath(WithInMemory()))
+
+	db, err := OpenDB(WithDBPath(path.Join(dir, "nested", "ledger#1.db")), WithDSNParam("_busy_timeout", "1000"))
+	assert.Nil(t, err)
+	assert.Nil(t, MigrateDB(db))
+	_ = db.Close()
+	assert.FileExists(t, path.Join(dir, "nested", "ledger#1.db"))
+
+	_, err = OpenDB(WithDBPath(path.Join(dir, "nested", "ledger#1.db", "sub", "db.db")))
+	assert.Error(t, err)
+}
+
+func TestOpenDB_InMemory(t *testing.T) {
+	db, err := OpenDB(WithInMemory())
+	assert.Nil(t, err)
+	defer func(db *sql.DB) {
+		_ = db.Close()
+	}(db)
+	other, err := OpenDB(WithInMemory())
+	assert.Nil(t, err)
+	defer func(db *sql.DB) {
+		_ = db.Close()
+	}(other)
+
+	assert.Nil(t, MigrateDB(db))
+	assert.Nil(t, MigrateDB(other))
+	_, _ = AddAccount(db, Account{Name: "TestOpenDB_InMemory"})
+
+	// Every pool connection sees the same DB, separate in-memory DBs are isolated
+	db.SetMaxIdleConns(5)
+	conns := make([]*sql.Conn, 3)
+	for i := range conns {
+		conns[i], _ = db.Conn(context.Background())
+		var count int
+		assert.Nil(t, conns[i].QueryRowContext(context.Background(), `SELECT COUNT(*) FROM accounts`).Scan(&count))
+		assert.Equal(t, 1, count)
+	}
+	for _, conn := range conns {
+		_ = conn.Close()
+	}
+	assert.Len(t, GetAccounts(other), 0)
+
+	var foreignKeys bool
+	assert.Nil(t, db.QueryRow(`PRAGMA foreign_keys`).Scan(&foreignKeys))
+	assert.True(t, foreignKeys)
+}
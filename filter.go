@@ -0,0 +1,135 @@
+package ezex
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// AmountSign restricts transactions to incomes (positive amounts) or expenses (negative amounts)
+type AmountSign int
+
+const (
+	AnyAmount AmountSign = iota
+	IncomeAmount
+	ExpenseAmount
+)
+
+// TransactionFilter restricts the transactions returned by a query, zero values don't filter
+type TransactionFilter struct {
+	// AccountIDs limits the results to these accounts, empty means every account
+	AccountIDs []int
+	// CategoryIDs limits the results to these categories and their subcategories (including split lines),
+	// empty means every category
+	CategoryIDs []int
+	// PayeeIDs limits the results to these payees, empty means every payee
+	PayeeIDs []int
+	// TagIDs limits the results to the transactions with any of these tags, empty means any (or no) tag
+	TagIDs []int
+	// MinAmountInCents is the smallest included amount
+	MinAmountInCents sql.NullInt64
+	// MaxAmountInCents is the biggest included amount
+	MaxAmountInCents sql.NullInt64
+	Sign             AmountSign
+	// MinDate is the first included date
+	MinDate time.Time
+	// MaxDate is the first excluded date
+	MaxDate time.Time
+	// Text must be contained by the notes, payee or category name (every word, in any of them)
+	Text string
+	// Statuses limits the results to these statuses, empty means any status
+	Statuses []TransactionStatus
+	// IncludeDeleted includes soft-deleted transactions and the transactions of soft-deleted accounts
+	IncludeDeleted bool
+}
+
+// queryBuilder collects the WHERE conditions (joined by AND) of a dynamic query with their `?` arguments
+type queryBuilder struct {
+	conditions []string
+	args       []any
+}
+
+func (q *queryBuilder) where(condition string, args ...any) {
+	q.conditions = append(q.conditions, condition)
+	q.args = append(q.args, args...)
+}
+
+// whereIn adds a `column IN (?, ...)` condition, noop if values is empty
+func whereIn[T any](q *queryBuilder, column string, values []T) {
+	if len(values) == 0 {
+		return
+	}
+
+	args := make([]any, len(values))
+	for i, value := range values {
+		args[i] = value
+	}
+
+	q.where(column+" IN ("+strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", ")+")", args...)
+}
+
+func (q *queryBuilder) whereClause() string {
+	if len(q.conditions) == 0 {
+		return ""
+	}
+
+	return "WHERE " + strings.Join(q.conditions, "\n\t\tAND ")
+}
+
+// apply adds the filter conditions to q, the query must alias transactions as `t`, accounts as `a`,
+// payees as `p` and category paths as `cp`
+func (f TransactionFilter) apply(db dbExecutor, q *queryBuilder) {
+	if !f.IncludeDeleted {
+		q.where("t.delete_date_unix IS NULL")
+		q.where("a.delete_date_unix IS NULL")
+	}
+
+	whereIn(q, "t.account_id", f.AccountIDs)
+	if len(f.CategoryIDs) > 0 {
+		// Split transactions match if any of their splits does, subcategories match along with their ancestors
+		lines := queryBuilder{}
+		whereIn(&lines, "ca.ancestor_id", f.CategoryIDs)
+		q.where(
+			`t.id IN (
+				SELECT	l.transaction_id
+				FROM	transaction_lines l
+				JOIN	category_ancestors ca
+				ON		ca.category_id = l.category_id
+				`+lines.whereClause()+`
+			)`,
+			lines.args...,
+		)
+	}
+	whereIn(q, "t.payee_id", f.PayeeIDs)
+	whereIn(q, "t.status", f.Statuses)
+	if len(f.TagIDs) > 0 {
+		tags := queryBuilder{}
+		whereIn(&tags, "tag_id", f.TagIDs)
+		q.where("t.id IN (SELECT transaction_id FROM transaction_tags "+tags.whereClause()+")", tags.args...)
+	}
+
+	if f.MinAmountInCents.Valid {
+		q.where("t.amount_in_cents >= ?", f.MinAmountInCents.Int64)
+	}
+	if f.MaxAmountInCents.Valid {
+		q.where("t.amount_in_cents <= ?", f.MaxAmountInCents.Int64)
+	}
+
+	switch f.Sign {
+	case IncomeAmount:
+		q.where("t.amount_in_cents > 0")
+	case ExpenseAmount:
+		q.where("t.amount_in_cents < 0")
+	}
+
+	if !f.MinDate.IsZero() {
+		q.where("t.transaction_date_unix >= ?", f.MinDate.Unix())
+	}
+	if !f.MaxDate.IsZero() {
+		q.where("t.transaction_date_unix < ?", f.MaxDate.Unix())
+	}
+
+	if words := strings.Fields(f.Text); len(words) > 0 {
+		whereText(db, q, words)
+	}
+}
@@ -0,0 +1,101 @@
+package ezex
+
+import (
+	"database/sql"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestFilterTransactions(t *testing.T) {
+	payee1ID, _ := AddPayee(testDB, Payee{Name: "TestFilterTransactions1"})
+	payee2ID, _ := AddPayee(testDB, Payee{Name: "TestFilterTransactions2"})
+	category1ID, _ := AddCategory(testDB, Category{Name: "TestFilterTransactions1"})
+	category2ID, _ := AddCategory(testDB, Category{Name: "TestFilterTransactions2"})
+	accountID, _ := AddAccount(testDB, Account{Name: "TestFilterTransactions"})
+	deletedAccountID, _ := AddAccount(testDB, Account{Name: "TestFilterTransactions deleted"})
+
+	date := time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC).Unix()
+	add := func(transaction Transaction) int {
+		transaction.TransactionDateUnix += date
+		id, _ := AddTransaction(testDB, transaction)
+		return id
+	}
+
+	income := add(Transaction{CategoryID: category1ID, PayeeID: payee1ID, AccountID: accountID, AmountInCents: 5000})
+	expense := add(Transaction{CategoryID: category2ID, PayeeID: payee2ID, AccountID: accountID, AmountInCents: -1500, TransactionDateUnix: 1})
+	smallExpense := add(Transaction{
+		CategoryID:          category1ID,
+		PayeeID:             payee2ID,
+		AccountID:           accountID,
+		AmountInCents:       -200,
+		TransactionDateUnix: 2,
+		Notes:               sql.NullString{String: "filter coffee", Valid: true},
+	})
+	deleted := add(Transaction{
+		CategoryID:     category1ID,
+		PayeeID:        payee1ID,
+		AccountID:      accountID,
+		AmountInCents:  -1,
+		DeleteDateUnix: sql.NullInt64{Int64: 1, Valid: true},
+	})
+	ofDeletedAccount := add(Transaction{CategoryID: category1ID, PayeeID: payee1ID, AccountID: deletedAccountID, AmountInCents: -1})
+	_, _ = DeleteAccount(testDB, deletedAccountID)
+
+	ids := func(filter TransactionFilter) []int {
+		filter.AccountIDs = append(filter.AccountIDs, accountID, deletedAccountID)
+		var ids []int
+		for _, transaction := range FilterTransactions(testDB, filter) {
+			ids = append(ids, transaction.ID)
+		}
+		return ids
+	}
+
+	cases := []struct {
+		name     string
+		filter   TransactionFilter
+		expected []int
+	}{
+		{"no filter", TransactionFilter{}, []int{smallExpense, expense, income}},
+		{"categories", TransactionFilter{CategoryIDs: []int{category1ID}}, []int{smallExpense, income}},
+		{"payees", TransactionFilter{PayeeIDs: []int{payee2ID}}, []int{smallExpense, expense}},
+		{"min amount", TransactionFilter{MinAmountInCents: sql.NullInt64{Int64: -200, Valid: true}}, []int{smallExpense, income}},
+		{"max amount", TransactionFilter{MaxAmountInCents: sql.NullInt64{Int64: -200, Valid: true}}, []int{smallExpense, expense}},
+		{"income", TransactionFilter{Sign: IncomeAmount}, []int{income}},
+		{"expense", TransactionFilter{Sign: ExpenseAmount}, []int{smallExpense, expense}},
+		{"dates", TransactionFilter{MinDate: time.Unix(date+1, 0), MaxDate: time.Unix(date+2, 0)}, []int{expense}},
+		{"text", TransactionFilter{Text: "coffee"}, []int{smallExpense}},
+		{"combined", TransactionFilter{CategoryIDs: []int{category1ID}, Sign: ExpenseAmount}, []int{smallExpense}},
+		{
+			"include deleted",
+			TransactionFilter{IncludeDeleted: true},
+			[]int{smallExpense, expense, ofDeletedAccount, deleted, income},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.expected, ids(c.filter))
+		})
+	}
+}
+
+func TestFilterTransactions_Subcategories(t *testing.T) {
+	payeeID, _ := AddPayee(testDB, Payee{Name: "TestFilterTransactions_Subcategories"})
+	accountID, _ := AddAccount(testDB, Account{Name: "TestFilterTransactions_Subcategories"})
+	carID, _ := AddCategoryPath(testDB, "TestFilterTransactions_Subcategories")
+	fuelID, _ := AddCategoryPath(testDB, "TestFilterTransactions_Subcategories:Fuel")
+
+	carTransactionID, _ := AddTransaction(testDB, Transaction{CategoryID: carID, PayeeID: payeeID, AccountID: accountID, AmountInCents: -1})
+	fuelTransactionID, _ := AddTransaction(testDB, Transaction{CategoryID: fuelID, PayeeID: payeeID, AccountID: accountID, AmountInCents: -2, TransactionDateUnix: 1})
+
+	ids := func(categoryID int) []int {
+		var ids []int
+		for _, transaction := range FilterTransactions(testDB, TransactionFilter{AccountIDs: []int{accountID}, CategoryIDs: []int{categoryID}}) {
+			ids = append(ids, transaction.ID)
+		}
+		return ids
+	}
+
+	assert.Equal(t, []int{fuelTransactionID, carTransactionID}, ids(carID))
+	assert.Equal(t, []int{fuelTransactionID}, ids(fuelID))
+}
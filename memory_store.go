@@ -0,0 +1,553 @@
+package ezex
+
+import (
+	"database/sql"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryStore is a Store keeping everything in memory, for tests and demos. It's safe for concurrent use.
+// Transactions can't be split, tagged or have attachments, filtering by tag matches no transaction
+type MemoryStore struct {
+	mu   sync.Mutex
+	data *memoryData
+	// inUnitOfWork is set on the Store given to UnitOfWork, whose data is a copy saved once the unit of work succeeds
+	inUnitOfWork bool
+}
+
+type memoryData struct {
+	accounts     map[int]memoryAccount
+	transactions map[int]Transaction
+	payees       map[int]Payee
+	// categories don't have Path and Depth, see memoryData.category
+	categories map[int]Category
+}
+
+type memoryAccount struct {
+	Account
+	deleteDateUnix sql.NullInt64
+}
+
+// NewMemoryStore returns an empty Store, with "no category" only like a new DB
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		data: &memoryData{
+			accounts:     map[int]memoryAccount{},
+			transactions: map[int]Transaction{},
+			payees:       map[int]Payee{},
+			categories:   map[int]Category{0: {ID: 0, Name: "no category"}},
+		},
+	}
+}
+
+func (s *MemoryStore) AddAccount(account Account) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.data.accountNameExists(account.Name, 0) {
+		return -1, fmt.Errorf("%w: account %s", ErrDuplicateName, account.Name)
+	}
+
+	account.ID = nextID(s.data.accounts)
+	s.data.accounts[account.ID] = memoryAccount{Account: account}
+
+	return account.ID, nil
+}
+
+func (s *MemoryStore) UpdateAccount(account Account) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.data.accounts[account.ID]
+	if !ok {
+		return 0, fmt.Errorf("%w: account %d", ErrNotFound, account.ID)
+	}
+	if s.data.accountNameExists(account.Name, account.ID) {
+		return 0, fmt.Errorf("%w: account %s", ErrDuplicateName, account.Name)
+	}
+
+	existing.Account = account
+	s.data.accounts[account.ID] = existing
+
+	return 1, nil
+}
+
+func (s *MemoryStore) DeleteAccount(id int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, ok := s.data.accounts[id]
+	if !ok {
+		return 0, fmt.Errorf("%w: account %d", ErrNotFound, id)
+	}
+
+	account.deleteDateUnix = sql.NullInt64{Int64: time.Now().Unix(), Valid: true}
+	s.data.accounts[id] = account
+
+	return 1, nil
+}
+
+func (s *MemoryStore) UpdateAccountBalance(accountID int, amountInCents int64) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, ok := s.data.accounts[accountID]
+	if !ok {
+		return 0, fmt.Errorf("%w: account %d", ErrNotFound, accountID)
+	}
+
+	account.BalanceInCents -= amountInCents
+	s.data.accounts[accountID] = account
+
+	return 1, nil
+}
+
+func (s *MemoryStore) GetAccounts() []Account {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var accounts []Account
+	for _, id := range idsNewestFirst(s.data.accounts) {
+		if account := s.data.accounts[id]; !account.deleteDateUnix.Valid {
+			accounts = append(accounts, account.Account)
+		}
+	}
+
+	return accounts
+}
+
+func (s *MemoryStore) GetAccount(id int) (Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, ok := s.data.accounts[id]
+	if !ok {
+		return Account{}, fmt.Errorf("%w: account %d", ErrNotFound, id)
+	}
+
+	return account.Account, nil
+}
+
+func (s *MemoryStore) AddTransaction(transaction Transaction) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.data.checkReferences(transaction); err != nil {
+		return -1, err
+	}
+
+	transaction.ID = nextID(s.data.transactions)
+	s.data.transactions[transaction.ID] = transaction
+
+	return transaction.ID, nil
+}
+
+func (s *MemoryStore) UpdateTransaction(transaction Transaction) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.data.transactions[transaction.ID]
+	if !ok {
+		return 0, fmt.Errorf("%w: transaction %d", ErrNotFound, transaction.ID)
+	}
+	if existing.Status == StatusReconciled {
+		return 0, ErrTransactionReconciled
+	}
+	if err := s.data.checkReferences(transaction); err != nil {
+		return 0, err
+	}
+
+	transaction.Status = existing.Status
+	s.data.transactions[transaction.ID] = transaction
+
+	return 1, nil
+}
+
+func (s *MemoryStore) DeleteTransaction(id int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	transaction, ok := s.data.transactions[id]
+	if !ok {
+		return 0, fmt.Errorf("%w: transaction %d", ErrNotFound, id)
+	}
+	if transaction.Status == StatusReconciled {
+		return 0, ErrTransactionReconciled
+	}
+
+	transaction.DeleteDateUnix = sql.NullInt64{Int64: time.Now().Unix(), Valid: true}
+	s.data.transactions[id] = transaction
+
+	return 1, nil
+}
+
+func (s *MemoryStore) UndeleteTransaction(id int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	transaction, ok := s.data.transactions[id]
+	if !ok {
+		return 0, fmt.Errorf("%w: transaction %d", ErrNotFound, id)
+	}
+
+	transaction.DeleteDateUnix = sql.NullInt64{}
+	s.data.transactions[id] = transaction
+
+	return 1, nil
+}
+
+func (s *MemoryStore) FilterTransactions(filter TransactionFilter) []TransactionView {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var views []TransactionView
+	for _, t := range s.data.transactions {
+		view := TransactionView{
+			ID:                  t.ID,
+			CategoryID:          t.CategoryID,
+			PayeeID:             t.PayeeID,
+			AccountID:           t.AccountID,
+			AmountInCents:       t.AmountInCents,
+			TransactionDateUnix: t.TransactionDateUnix,
+			UpdateDateUnix:      t.UpdateDateUnix,
+			DeleteDateUnix:      t.DeleteDateUnix,
+			Notes:               t.Notes,
+			CategoryName:        s.data.category(t.CategoryID).Path,
+			PayeeName:           s.data.payees[t.PayeeID].Name,
+			AccountName:         s.data.accounts[t.AccountID].Name,
+			Status:              t.Status,
+		}
+		if s.data.matches(filter, view) {
+			views = append(views, view)
+		}
+	}
+
+	sort.Slice(views, func(i, j int) bool {
+		if views[i].TransactionDateUnix != views[j].TransactionDateUnix {
+			return views[i].TransactionDateUnix > views[j].TransactionDateUnix
+		}
+
+		return views[i].ID > views[j].ID
+	})
+
+	return views
+}
+
+func (s *MemoryStore) AddPayee(payee Payee) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.data.payeeNameExists(payee.Name, 0) {
+		return -1, fmt.Errorf("%w: payee %s", ErrDuplicateName, payee.Name)
+	}
+
+	payee.ID = nextID(s.data.payees)
+	s.data.payees[payee.ID] = payee
+
+	return payee.ID, nil
+}
+
+func (s *MemoryStore) UpdatePayee(payee Payee) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.data.payees[payee.ID]; !ok {
+		return 0, fmt.Errorf("%w: payee %d", ErrNotFound, payee.ID)
+	}
+	if s.data.payeeNameExists(payee.Name, payee.ID) {
+		return 0, fmt.Errorf("%w: payee %s", ErrDuplicateName, payee.Name)
+	}
+
+	s.data.payees[payee.ID] = payee
+
+	return 1, nil
+}
+
+// DeletePayee deletes a payee, payees in use by transactions can't be deleted (ErrInUse)
+func (s *MemoryStore) DeletePayee(id int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.data.payees[id]; !ok {
+		return 0, fmt.Errorf("%w: payee %d", ErrNotFound, id)
+	}
+	for _, transaction := range s.data.transactions {
+		if transaction.PayeeID == id {
+			return 0, fmt.Errorf("%w: payee %d", ErrInUse, id)
+		}
+	}
+
+	delete(s.data.payees, id)
+
+	return 1, nil
+}
+
+func (s *MemoryStore) GetPayees() []Payee {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var payees []Payee
+	for _, id := range idsNewestFirst(s.data.payees) {
+		payees = append(payees, s.data.payees[id])
+	}
+
+	return payees
+}
+
+func (s *MemoryStore) AddCategory(category Category) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !isValidCategoryName(category.Name) {
+		return -1, ErrInvalidCategoryName
+	}
+	if _, ok := s.data.categories[category.ParentID]; !ok {
+		return -1, fmt.Errorf("%w: category %d", ErrNotFound, category.ParentID)
+	}
+	if s.data.categoryNameExists(category.ParentID, category.Name, -1) {
+		return -1, fmt.Errorf("%w: category %s", ErrDuplicateName, category.Name)
+	}
+
+	category.ID = nextID(s.data.categories)
+	category.Path, category.Depth = "", 0
+	s.data.categories[category.ID] = category
+
+	return category.ID, nil
+}
+
+// UpdateCategory updates a category name and description, trying to update ID 0 is not allowed (ErrProtectedCategory)
+func (s *MemoryStore) UpdateCategory(category Category) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if category.ID == 0 {
+		return 0, ErrProtectedCategory
+	}
+	if !isValidCategoryName(category.Name) {
+		return 0, ErrInvalidCategoryName
+	}
+
+	existing, ok := s.data.categories[category.ID]
+	if !ok {
+		return 0, fmt.Errorf("%w: category %d", ErrNotFound, category.ID)
+	}
+	if s.data.categoryNameExists(existing.ParentID, category.Name, category.ID) {
+		return 0, fmt.Errorf("%w: category %s", ErrDuplicateName, category.Name)
+	}
+
+	existing.Name = category.Name
+	existing.Description = category.Description
+	s.data.categories[category.ID] = existing
+
+	return 1, nil
+}
+
+// DeleteCategory deletes a category, trying to delete ID 0 is not allowed (ErrProtectedCategory).
+// Its subcategories are moved to its parent and its transactions to "no category"
+func (s *MemoryStore) DeleteCategory(id int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if id == 0 {
+		return 0, ErrProtectedCategory
+	}
+	deleted, ok := s.data.categories[id]
+	if !ok {
+		return 0, fmt.Errorf("%w: category %d", ErrNotFound, id)
+	}
+
+	for _, category := range s.data.categories {
+		if category.ParentID == id && s.data.categoryNameExists(deleted.ParentID, category.Name, -1) {
+			return 0, fmt.Errorf("%w: category %s", ErrDuplicateName, category.Name)
+		}
+	}
+	for _, category := range s.data.categories {
+		if category.ParentID == id {
+			category.ParentID = deleted.ParentID
+			s.data.categories[category.ID] = category
+		}
+	}
+	for _, transaction := range s.data.transactions {
+		if transaction.CategoryID == id {
+			transaction.CategoryID = 0
+			s.data.transactions[transaction.ID] = transaction
+		}
+	}
+	delete(s.data.categories, id)
+
+	return 1, nil
+}
+
+// GetCategories returns every category, newest first
+func (s *MemoryStore) GetCategories() []Category {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var categories []Category
+	for _, id := range idsNewestFirst(s.data.categories) {
+		categories = append(categories, s.data.category(id))
+	}
+
+	return categories
+}
+
+// UnitOfWork runs fn with a copy of the data, replacing the data with it once fn succeeds. Other calls wait for
+// the unit of work to end
+func (s *MemoryStore) UnitOfWork(fn func(s Store) error) error {
+	if s.inUnitOfWork {
+		return fn(s)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx := &MemoryStore{data: s.data.clone(), inUnitOfWork: true}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	s.data = tx.data
+
+	return nil
+}
+
+func (d *memoryData) clone() *memoryData {
+	return &memoryData{
+		accounts:     maps.Clone(d.accounts),
+		transactions: maps.Clone(d.transactions),
+		payees:       maps.Clone(d.payees),
+		categories:   maps.Clone(d.categories),
+	}
+}
+
+func (d *memoryData) accountNameExists(name string, exceptID int) bool {
+	for _, account := range d.accounts {
+		if account.Name == name && account.ID != exceptID {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (d *memoryData) payeeNameExists(name string, exceptID int) bool {
+	for _, payee := range d.payees {
+		if payee.Name == name && payee.ID != exceptID {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (d *memoryData) categoryNameExists(parentID int, name string, exceptID int) bool {
+	for _, category := range d.categories {
+		if category.ParentID == parentID && category.Name == name && category.ID != exceptID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// category returns a category with its Path and Depth
+func (d *memoryData) category(id int) Category {
+	category := d.categories[id]
+
+	names := []string{category.Name}
+	for parentID := category.ParentID; category.ID != 0 && parentID != 0; parentID = d.categories[parentID].ParentID {
+		names = append(names, d.categories[parentID].Name)
+	}
+	slices.Reverse(names)
+	category.Path = strings.Join(names, CategoryPathSeparator)
+	category.Depth = len(names) - 1
+
+	return category
+}
+
+// isInCategory reports whether a category is one of categoryIDs or one of their subcategories
+func (d *memoryData) isInCategory(id int, categoryIDs []int) bool {
+	if slices.Contains(categoryIDs, id) {
+		return true
+	}
+
+	for parentID := d.categories[id].ParentID; id != 0 && parentID != 0; parentID = d.categories[parentID].ParentID {
+		if slices.Contains(categoryIDs, parentID) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkReferences returns an error if the account, payee or category of a transaction doesn't exist
+func (d *memoryData) checkReferences(transaction Transaction) error {
+	if _, ok := d.accounts[transaction.AccountID]; !ok {
+		return fmt.Errorf("%w: account %d", ErrNotFound, transaction.AccountID)
+	}
+	if _, ok := d.payees[transaction.PayeeID]; !ok {
+		return fmt.Errorf("%w: payee %d", ErrNotFound, transaction.PayeeID)
+	}
+	if _, ok := d.categories[transaction.CategoryID]; !ok {
+		return fmt.Errorf("%w: category %d", ErrNotFound, transaction.CategoryID)
+	}
+
+	return nil
+}
+
+// matches reports whether a transaction matches the filter, like TransactionFilter.apply
+func (d *memoryData) matches(f TransactionFilter, t TransactionView) bool {
+	if !f.IncludeDeleted && (t.DeleteDateUnix.Valid || d.accounts[t.AccountID].deleteDateUnix.Valid) {
+		return false
+	}
+
+	switch {
+	case len(f.AccountIDs) > 0 && !slices.Contains(f.AccountIDs, t.AccountID),
+		len(f.CategoryIDs) > 0 && !d.isInCategory(t.CategoryID, f.CategoryIDs),
+		len(f.PayeeIDs) > 0 && !slices.Contains(f.PayeeIDs, t.PayeeID),
+		len(f.Statuses) > 0 && !slices.Contains(f.Statuses, t.Status),
+		len(f.TagIDs) > 0,
+		f.MinAmountInCents.Valid && t.AmountInCents < f.MinAmountInCents.Int64,
+		f.MaxAmountInCents.Valid && t.AmountInCents > f.MaxAmountInCents.Int64,
+		f.Sign == IncomeAmount && t.AmountInCents <= 0,
+		f.Sign == ExpenseAmount && t.AmountInCents >= 0,
+		!f.MinDate.IsZero() && t.TransactionDateUnix < f.MinDate.Unix(),
+		!f.MaxDate.IsZero() && t.TransactionDateUnix >= f.MaxDate.Unix():
+		return false
+	}
+
+	for _, word := range strings.Fields(strings.ToLower(f.Text)) {
+		if !strings.Contains(strings.ToLower(t.Notes.String), word) &&
+			!strings.Contains(strings.ToLower(t.PayeeName), word) &&
+			!strings.Contains(strings.ToLower(t.CategoryName), word) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// nextID returns the ID of a new entity, following SQLite's rowid (the biggest ID + 1)
+func nextID[T any](entities map[int]T) int {
+	id := 0
+	for existing := range entities {
+		id = max(id, existing)
+	}
+
+	return id + 1
+}
+
+// idsNewestFirst returns the entity IDs, newest (biggest) first
+func idsNewestFirst[T any](entities map[int]T) []int {
+	ids := make([]int, 0, len(entities))
+	for id := range entities {
+		ids = append(ids, id)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(ids)))
+
+	return ids
+}
@@ -0,0 +1,377 @@
+package ezex
+
+import (
+	"errors"
+	"fmt"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"math"
+	"math/bits"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Money is an amount in cents (hundredths of the currency unit) like every amount stored by ez-ex, along with its
+// currency. Parsing is exact and arithmetic fails on overflow, so cents are never lost or made up
+type Money struct {
+	Cents int64
+	// Currency is an ISO 4217 code (e.g. EUR), empty when unknown. Money of an unknown currency combines with any
+	Currency string
+}
+
+var (
+	// ErrInvalidAmount is returned when parsing an amount that isn't a number with at most 2 decimals
+	ErrInvalidAmount = errors.New("invalid amount")
+	// ErrAmountOverflow is returned when an amount doesn't fit in int64 cents
+	ErrAmountOverflow = errors.New("amount out of range")
+	// ErrCurrencyMismatch is returned when combining amounts of different currencies
+	ErrCurrencyMismatch = errors.New("amounts have different currencies")
+)
+
+// currencySymbols are the symbols allowed around parsed amounts, they're dropped since most are shared by several
+// currencies (e.g. $)
+var currencySymbols = []string{"$", "€", "£", "¥", "₹", "₽", "₩", "₺", "₪", "₫", "₴", "₦", "฿"}
+
+// groupSeparators are the thousands separators allowed in parsed amounts, along with `.` and `,`
+var groupSeparators = " '\u00a0\u202f"
+
+// ParseMoney parses an amount, such as `12`, `-12.5`, `+.50`, `1,234.56`, `1.234,56`, `1 234,56`, `(12.00)`,
+// `€ 12` or `12.00 EUR`. A 3 letters code sets the currency, symbols are ignored.
+// `.` and `,` are both accepted as decimal separator: when there's only one of them, followed by exactly 3 digits
+// (e.g. `1,234`), it's a thousands separator
+func ParseMoney(s string) (Money, error) {
+	str := strings.TrimSpace(s)
+	negative, parenthesized := false, false
+	if strings.HasPrefix(str, "(") && strings.HasSuffix(str, ")") {
+		negative, parenthesized = true, true
+		str = strings.TrimSpace(str[1 : len(str)-1])
+	}
+
+	// The sign may come before or after the currency (e.g. `-€12` or `€-12`)
+	str, signed := trimSign(str, &negative)
+	var m Money
+	str, m.Currency = trimCurrency(str)
+	if !signed {
+		str, signed = trimSign(str, &negative)
+	}
+	// Parentheses are the sign, e.g. `(-5)` is ambiguous
+	if parenthesized && signed {
+		return Money{}, fmt.Errorf("%w %q: %w", ErrInvalidAmount, s, errors.New("sign inside parentheses"))
+	}
+
+	cents, err := parseCents(str)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w %q: %w", ErrInvalidAmount, s, err)
+	}
+	if negative {
+		cents = -cents
+	}
+	m.Cents = cents
+
+	return m, nil
+}
+
+// trimSign removes a leading `+` or `-` from str, the latter toggles negative
+func trimSign(str string, negative *bool) (string, bool) {
+	switch {
+	case strings.HasPrefix(str, "-"):
+		*negative = !*negative
+	case !strings.HasPrefix(str, "+"):
+		return str, false
+	}
+
+	return strings.TrimSpace(str[1:]), true
+}
+
+// trimCurrency removes a currency code or symbol before or after the amount, returning the code
+func trimCurrency(str string) (string, string) {
+	for _, symbol := range currencySymbols {
+		if trimmed, ok := strings.CutPrefix(str, symbol); ok {
+			return strings.TrimSpace(trimmed), ""
+		}
+		if trimmed, ok := strings.CutSuffix(str, symbol); ok {
+			return strings.TrimSpace(trimmed), ""
+		}
+	}
+
+	if len(str) > 3 && isCurrencyCode(str[:3]) {
+		return strings.TrimSpace(str[3:]), strings.ToUpper(str[:3])
+	}
+	if len(str) > 3 && isCurrencyCode(str[len(str)-3:]) {
+		return strings.TrimSpace(str[:len(str)-3]), strings.ToUpper(str[len(str)-3:])
+	}
+
+	return str, ""
+}
+
+func isCurrencyCode(code string) bool {
+	for _, r := range code {
+		if r > unicode.MaxASCII || !unicode.IsLetter(r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// parseCents parses an unsigned amount, with optional thousands and decimal separators
+func parseCents(str string) (int64, error) {
+	integer, fraction := str, ""
+	if i := decimalSeparatorIndex(str); i >= 0 {
+		integer, fraction = str[:i], str[i+1:]
+		if fraction == "" || len(fraction) > 2 || !isDigits(fraction) {
+			return 0, errors.New("decimals must be 1 or 2 digits")
+		}
+	}
+	if integer == "" && fraction == "" {
+		return 0, errors.New("missing digits")
+	}
+
+	var units int64
+	if integer != "" {
+		integer, err := ungroup(integer)
+		if err != nil {
+			return 0, err
+		}
+
+		units, err = strconv.ParseInt(integer, 10, 64)
+		if errors.Is(err, strconv.ErrRange) {
+			return 0, ErrAmountOverflow
+		} else if err != nil {
+			return 0, errors.New("not a number")
+		}
+	}
+
+	cents, _ := strconv.ParseInt((fraction + "00")[:2], 10, 64)
+	if units > (math.MaxInt64-cents)/100 {
+		return 0, ErrAmountOverflow
+	}
+
+	return units*100 + cents, nil
+}
+
+// decimalSeparatorIndex returns the index of the `.` or `,` separating the decimals in str, -1 if there are none
+func decimalSeparatorIndex(str string) int {
+	i := strings.LastIndexAny(str, ".,")
+	if i < 0 {
+		return -1
+	}
+
+	other := "."
+	if str[i] == '.' {
+		other = ","
+	}
+	switch {
+	// The last of two different separators always separates the decimals (e.g. `1.234,56`)
+	case strings.Contains(str[:i], other):
+		return i
+	// A repeated separator groups thousands (e.g. `1,234,567`)
+	case strings.Count(str, str[i:i+1]) > 1:
+		return -1
+	// A lone separator followed by 3 digits groups thousands, unless there are no thousands (e.g. `0.125`)
+	case len(str)-i-1 == 3 && isDigits(str[i+1:]) && i > 0 && str[0] != '0':
+		return -1
+	}
+
+	return i
+}
+
+// ungroup removes the thousands separators of the integer part of an amount, checking that they group 3 digits
+func ungroup(integer string) (string, error) {
+	separators := groupSeparators + ".,"
+	if !strings.ContainsAny(integer, separators) {
+		if !isDigits(integer) {
+			return "", errors.New("not a number")
+		}
+
+		return integer, nil
+	}
+
+	separator, _ := utf8.DecodeRuneInString(integer[strings.IndexAny(integer, separators):])
+	groups := strings.FieldsFunc(integer, func(r rune) bool {
+		return strings.ContainsRune(separators, r)
+	})
+	if strings.Count(integer, string(separator)) != len(groups)-1 {
+		return "", errors.New("mixed or misplaced thousands separators")
+	}
+
+	for i, group := range groups {
+		if !isDigits(group) || len(group) > 3 || i > 0 && len(group) != 3 {
+			return "", errors.New("thousands separators must group 3 digits")
+		}
+	}
+
+	return strings.Join(groups, ""), nil
+}
+
+func isDigits(str string) bool {
+	for _, r := range str {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return str != ""
+}
+
+// Add returns m + other
+func (m Money) Add(other Money) (Money, error) {
+	currency, err := m.combinedCurrency(other)
+	if err != nil {
+		return Money{}, err
+	}
+
+	sum := m.Cents + other.Cents
+	if (sum > m.Cents) != (other.Cents > 0) {
+		return Money{}, ErrAmountOverflow
+	}
+
+	return Money{Cents: sum, Currency: currency}, nil
+}
+
+// Sub returns m - other
+func (m Money) Sub(other Money) (Money, error) {
+	currency, err := m.combinedCurrency(other)
+	if err != nil {
+		return Money{}, err
+	}
+
+	difference := m.Cents - other.Cents
+	if (difference < m.Cents) != (other.Cents > 0) {
+		return Money{}, ErrAmountOverflow
+	}
+
+	return Money{Cents: difference, Currency: currency}, nil
+}
+
+// Neg returns -m
+func (m Money) Neg() (Money, error) {
+	if m.Cents == math.MinInt64 {
+		return Money{}, ErrAmountOverflow
+	}
+
+	return Money{Cents: -m.Cents, Currency: m.Currency}, nil
+}
+
+// Mul returns m * n
+func (m Money) Mul(n int64) (Money, error) {
+	if m.Cents == 0 || n == 0 {
+		return Money{Currency: m.Currency}, nil
+	}
+
+	product := m.Cents * n
+	if product/n != m.Cents || (m.Cents == -1 && n == math.MinInt64) || (n == -1 && m.Cents == math.MinInt64) {
+		return Money{}, ErrAmountOverflow
+	}
+
+	return Money{Cents: product, Currency: m.Currency}, nil
+}
+
+// Allocate splits m proportionally to ratios (e.g. 1, 1, 2 gives a quarter, a quarter and a half).
+// The parts always add up to m: the cents left over by rounding go one each to the first parts
+func (m Money) Allocate(ratios ...int64) ([]Money, error) {
+	var total uint64
+	for _, ratio := range ratios {
+		if ratio < 0 {
+			return nil, fmt.Errorf("negative ratio: %d", ratio)
+		}
+		total += uint64(ratio)
+	}
+	if total == 0 || total > math.MaxInt64 {
+		return nil, errors.New("ratios must add up to between 1 and MaxInt64")
+	}
+
+	amount := m.magnitude()
+	parts := make([]Money, len(ratios))
+	left := amount
+	for i, ratio := range ratios {
+		// amount * ratio / total can't overflow, as ratio <= total
+		hi, lo := bits.Mul64(amount, uint64(ratio))
+		share, _ := bits.Div64(hi, lo, total)
+		parts[i] = Money{Cents: int64(share), Currency: m.Currency}
+		left -= share
+	}
+	for i := 0; left > 0; i = (i + 1) % len(parts) {
+		if ratios[i] > 0 {
+			parts[i].Cents++
+			left--
+		}
+	}
+
+	if m.Cents < 0 {
+		for i := range parts {
+			parts[i].Cents = -parts[i].Cents
+		}
+	}
+
+	return parts, nil
+}
+
+// Split splits m in n parts as equal as possible (see Allocate), e.g. 1.00 in 3 gives 0.34, 0.33 and 0.33
+func (m Money) Split(n int) ([]Money, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("can't split in %d parts", n)
+	}
+
+	ratios := make([]int64, n)
+	for i := range ratios {
+		ratios[i] = 1
+	}
+
+	return m.Allocate(ratios...)
+}
+
+// String formats m as ParseMoney parses it back, e.g. `-1234.50 EUR`
+func (m Money) String() string {
+	str := fmt.Sprintf("%s%d.%02d", m.sign(), m.magnitude()/100, m.magnitude()%100)
+	if m.Currency != "" {
+		str += " " + m.Currency
+	}
+
+	return str
+}
+
+// Format formats m with the thousands and decimal separators of locale, e.g. `-1,234.50 EUR` in English and
+// `-1.234,50 EUR` in German
+func (m Money) Format(locale language.Tag) string {
+	printer := message.NewPrinter(locale)
+	decimalSeparator := strings.Trim(printer.Sprintf("%.1f", 0.5), "05")
+
+	str := m.sign() + printer.Sprintf("%d", m.magnitude()/100) + decimalSeparator + fmt.Sprintf("%02d", m.magnitude()%100)
+	if m.Currency != "" {
+		str += " " + m.Currency
+	}
+
+	return str
+}
+
+func (m Money) sign() string {
+	if m.Cents < 0 {
+		return "-"
+	}
+
+	return ""
+}
+
+// magnitude returns the absolute value of m, math.MinInt64 included
+func (m Money) magnitude() uint64 {
+	if m.Cents < 0 {
+		return uint64(-(m.Cents + 1)) + 1
+	}
+
+	return uint64(m.Cents)
+}
+
+// combinedCurrency returns the currency of an amount combining m and other
+func (m Money) combinedCurrency(other Money) (string, error) {
+	switch {
+	case m.Currency == other.Currency || other.Currency == "":
+		return m.Currency, nil
+	case m.Currency == "":
+		return other.Currency, nil
+	}
+
+	return "", fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+}
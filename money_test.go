@@ -0,0 +1,123 @@
+package ezex
+
+import (
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/language"
+	"math"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	cases := []struct {
+		value string
+		money Money
+	}{
+		{"0", Money{}},
+		{"12", Money{Cents: 1200}},
+		{"1.5", Money{Cents: 150}},
+		{"1,5", Money{Cents: 150}},
+		{"-1.05", Money{Cents: -105}},
+		{"+.50", Money{Cents: 50}},
+		{" 123.00 ", Money{Cents: 12300}},
+		{"1,234", Money{Cents: 123400}},
+		{"1.234", Money{Cents: 123400}},
+		{"1,234.56", Money{Cents: 123456}},
+		{"1.234,56", Money{Cents: 123456}},
+		{"1,234,567", Money{Cents: 123456700}},
+		{"1 234,56", Money{Cents: 123456}},
+		{"1\u00a0234,56", Money{Cents: 123456}},
+		{"1'234.56", Money{Cents: 123456}},
+		{"(12.00)", Money{Cents: -1200}},
+		{"€12", Money{Cents: 1200}},
+		{"-€ 12", Money{Cents: -1200}},
+		{"$-12", Money{Cents: -1200}},
+		{"12.00 EUR", Money{Cents: 1200, Currency: "EUR"}},
+		{"usd -3", Money{Cents: -300, Currency: "USD"}},
+		{"92233720368547758.07", Money{Cents: math.MaxInt64}},
+	}
+	for _, c := range cases {
+		t.Run(c.value, func(t *testing.T) {
+			money, err := ParseMoney(c.value)
+			assert.Nil(t, err)
+			assert.Equal(t, c.money, money)
+		})
+	}
+}
+
+func TestParseMoney_Invalid(t *testing.T) {
+	for _, value := range []string{"", "-", ".", "12.", "0.125", "1.2345", "1,23,456", "12,34.5", "1 234.567,8", "1.2.3",
+		"abc", "12 EU", "--12", "1e3", "0x10", "(-5)", "(+5)", "(€ -5)"} {
+		t.Run(value, func(t *testing.T) {
+			_, err := ParseMoney(value)
+			assert.ErrorIs(t, err, ErrInvalidAmount)
+		})
+	}
+
+	_, err := ParseMoney("92233720368547758.08")
+	assert.ErrorIs(t, err, ErrAmountOverflow)
+	_, err = ParseMoney("100000000000000000000")
+	assert.ErrorIs(t, err, ErrAmountOverflow)
+}
+
+func TestMoney_Arithmetic(t *testing.T) {
+	sum, err := Money{Cents: 150, Currency: "EUR"}.Add(Money{Cents: -200})
+	assert.Nil(t, err)
+	assert.Equal(t, Money{Cents: -50, Currency: "EUR"}, sum)
+	_, err = Money{Cents: 150, Currency: "EUR"}.Add(Money{Cents: 1, Currency: "USD"})
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+	_, err = Money{Cents: math.MaxInt64}.Add(Money{Cents: 1})
+	assert.ErrorIs(t, err, ErrAmountOverflow)
+
+	difference, err := Money{Cents: -1}.Sub(Money{Cents: math.MinInt64})
+	assert.Nil(t, err)
+	assert.Equal(t, Money{Cents: math.MaxInt64}, difference)
+	_, err = Money{Cents: 0}.Sub(Money{Cents: math.MinInt64})
+	assert.ErrorIs(t, err, ErrAmountOverflow)
+
+	_, err = Money{Cents: math.MinInt64}.Neg()
+	assert.ErrorIs(t, err, ErrAmountOverflow)
+
+	product, err := Money{Cents: -250}.Mul(3)
+	assert.Nil(t, err)
+	assert.Equal(t, Money{Cents: -750}, product)
+	_, err = Money{Cents: math.MaxInt64 / 2}.Mul(3)
+	assert.ErrorIs(t, err, ErrAmountOverflow)
+	_, err = Money{Cents: math.MinInt64}.Mul(-1)
+	assert.ErrorIs(t, err, ErrAmountOverflow)
+}
+
+func TestMoney_Allocate(t *testing.T) {
+	parts, err := Money{Cents: 100, Currency: "EUR"}.Split(3)
+	assert.Nil(t, err)
+	assert.Equal(t, []Money{{34, "EUR"}, {33, "EUR"}, {33, "EUR"}}, parts)
+
+	parts, err = Money{Cents: -1001}.Allocate(1, 0, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, []Money{{Cents: -251}, {Cents: 0}, {Cents: -750}}, parts)
+
+	// Big amounts don't overflow while allocating
+	parts, err = Money{Cents: math.MaxInt64}.Allocate(math.MaxInt64/2, math.MaxInt64/2+1)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(math.MaxInt64), parts[0].Cents+parts[1].Cents)
+
+	_, err = Money{Cents: 100}.Allocate(0, 0)
+	assert.Error(t, err)
+	_, err = Money{Cents: 100}.Allocate(1, -1)
+	assert.Error(t, err)
+	_, err = Money{Cents: 100}.Split(0)
+	assert.Error(t, err)
+}
+
+func TestMoney_Format(t *testing.T) {
+	money := Money{Cents: -123456789, Currency: "EUR"}
+
+	assert.Equal(t, "-1234567.89 EUR", money.String())
+	assert.Equal(t, "-1,234,567.89 EUR", money.Format(language.English))
+	assert.Equal(t, "-1.234.567,89 EUR", money.Format(language.German))
+	assert.Equal(t, "0.05", Money{Cents: 5}.Format(language.English))
+	assert.Equal(t, "-92,233,720,368,547,758.08", Money{Cents: math.MinInt64}.Format(language.English))
+
+	parsed, err := ParseMoney(money.String())
+	assert.Nil(t, err)
+	assert.Equal(t, money, parsed)
+}
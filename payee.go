@@ -1,6 +1,7 @@
 package ezex
 
 import (
+	"context"
 	"database/sql"
 )
 
@@ -15,6 +16,14 @@ func (p Payee) GetName() string {
 }
 
 func AddPayee(db *sql.DB, payee Payee) (int, error) {
+	return addPayee(db, payee)
+}
+
+func AddPayeeContext(ctx context.Context, db *sql.DB, payee Payee) (int, error) {
+	return addPayee(withContext(ctx, db), payee)
+}
+
+func addPayee(db dbExecutor, payee Payee) (int, error) {
 	return dbAdd(
 		db,
 		`INSERT INTO payees (name, description) VALUES ($name, $description)`,
@@ -23,12 +32,29 @@ func AddPayee(db *sql.DB, payee Payee) (int, error) {
 	)
 }
 
-// DeletePayee deletes a payee, trying to delete a payee already in use will be noop
-func DeletePayee(db *sql.DB, id int) int {
+// DeletePayee deletes a payee and returns the number of affected rows, payees in use by transactions can't be
+// deleted (ErrInUse)
+func DeletePayee(db *sql.DB, id int) (int, error) {
+	return deletePayee(db, id)
+}
+
+func DeletePayeeContext(ctx context.Context, db *sql.DB, id int) (int, error) {
+	return deletePayee(withContext(ctx, db), id)
+}
+
+func deletePayee(db dbExecutor, id int) (int, error) {
 	return dbDelete(db, `DELETE FROM payees WHERE id = $id`, id)
 }
 
 func UpdatePayee(db *sql.DB, payee Payee) (int, error) {
+	return updatePayee(db, payee)
+}
+
+func UpdatePayeeContext(ctx context.Context, db *sql.DB, payee Payee) (int, error) {
+	return updatePayee(withContext(ctx, db), payee)
+}
+
+func updatePayee(db dbExecutor, payee Payee) (int, error) {
 	return dbUpdate(
 		db,
 		`
@@ -44,5 +70,13 @@ func UpdatePayee(db *sql.DB, payee Payee) (int, error) {
 }
 
 func GetPayees(db *sql.DB) []Payee {
+	return getPayees(db)
+}
+
+func GetPayeesContext(ctx context.Context, db *sql.DB) []Payee {
+	return getPayees(withContext(ctx, db))
+}
+
+func getPayees(db dbExecutor) []Payee {
 	return dbGet[Payee](db, `SELECT id, name, description FROM payees ORDER BY id DESC`)
 }
@@ -32,7 +32,7 @@ func TestAddPayee_Unique(t *testing.T) {
 		Description: sql.NullString{},
 	})
 
-	assert.Error(t, err)
+	assert.ErrorIs(t, err, ErrDuplicateName)
 }
 
 func TestDeletePayee(t *testing.T) {
@@ -40,9 +40,10 @@ func TestDeletePayee(t *testing.T) {
 		Name:        "TestDeletePayee",
 		Description: sql.NullString{},
 	})
-	n := DeletePayee(testDB, id)
+	n, err := DeletePayee(testDB, id)
 
 	assert.Greater(t, n, 0)
+	assert.Nil(t, err)
 }
 
 func TestUpdatePayee(t *testing.T) {
@@ -76,7 +77,7 @@ func TestUpdatePayee_Unique(t *testing.T) {
 	})
 
 	assert.Equal(t, 0, n)
-	assert.Error(t, err)
+	assert.ErrorIs(t, err, ErrDuplicateName)
 }
 
 func TestGetPayees(t *testing.T) {
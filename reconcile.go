@@ -0,0 +1,124 @@
+package ezex
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// AccountBalance splits an account balance by transaction status, each balance includes the initial one
+type AccountBalance struct {
+	// ReconciledInCents only counts reconciled transactions
+	ReconciledInCents int64
+	// ClearedInCents counts cleared and reconciled transactions
+	ClearedInCents int64
+	// WorkingInCents counts every transaction
+	WorkingInCents int64
+}
+
+// GetAccountBalance computes the balances of an account from its transactions, excluding deleted ones
+func GetAccountBalance(db *sql.DB, accountID int) (AccountBalance, error) {
+	return getAccountBalance(db, accountID)
+}
+
+func GetAccountBalanceContext(ctx context.Context, db *sql.DB, accountID int) (AccountBalance, error) {
+	return getAccountBalance(withContext(ctx, db), accountID)
+}
+
+func getAccountBalance(db dbExecutor, accountID int) (AccountBalance, error) {
+	results := dbGet[AccountBalance](
+		db,
+		`
+		SELECT		a.initial_balance_in_cents + COALESCE(SUM(CASE WHEN t.status = $reconciled THEN t.amount_in_cents END), 0),
+					a.initial_balance_in_cents + COALESCE(SUM(CASE WHEN t.status >= $cleared THEN t.amount_in_cents END), 0),
+					a.initial_balance_in_cents + COALESCE(SUM(t.amount_in_cents), 0)
+		FROM		accounts a
+		LEFT JOIN	transactions t
+		ON			t.account_id = a.id
+				AND t.delete_date_unix IS NULL
+		WHERE		a.id = $id
+		GROUP BY	a.id
+		`,
+		StatusReconciled,
+		StatusCleared,
+		accountID,
+	)
+
+	if len(results) == 0 {
+		return AccountBalance{}, fmt.Errorf("%w: account %d", ErrNotFound, accountID)
+	}
+
+	return results[0], nil
+}
+
+// SetTransactionStatus marks a transaction as uncommitted or cleared, reconciled transactions can only be
+// set with Reconcile and are locked afterwards
+func SetTransactionStatus(db *sql.DB, id int, status TransactionStatus) error {
+	return setTransactionStatus(db, id, status)
+}
+
+func SetTransactionStatusContext(ctx context.Context, db *sql.DB, id int, status TransactionStatus) error {
+	return setTransactionStatus(withContext(ctx, db), id, status)
+}
+
+func setTransactionStatus(db dbExecutor, id int, status TransactionStatus) error {
+	if status != StatusUncommitted && status != StatusCleared {
+		return fmt.Errorf("invalid transaction status: %v", status)
+	}
+	if isTransactionReconciled(db, id) {
+		return ErrTransactionReconciled
+	}
+
+	_, err := dbUpdate(db, `UPDATE transactions SET status = $status WHERE id = $id`, status, id)
+	if errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("%w: transaction %d", ErrNotFound, id)
+	}
+
+	return err
+}
+
+// Reconcile locks the transactions as reconciled once they match a bank statement,
+// every transaction must belong to the account and must not be deleted
+func Reconcile(db *sql.DB, accountID int, ids []int) error {
+	return reconcile(db, accountID, ids)
+}
+
+func ReconcileContext(ctx context.Context, db *sql.DB, accountID int, ids []int) error {
+	return reconcile(withContext(ctx, db), accountID, ids)
+}
+
+func reconcile(db dbExecutor, accountID int, ids []int) error {
+	return dbTransaction(db, func(tx dbExecutor) error {
+		for _, id := range ids {
+			_, err := dbUpdate(
+				tx,
+				`
+				UPDATE	transactions
+				SET		status = $status
+				WHERE	id = $id
+					AND account_id = $accountID
+					AND delete_date_unix IS NULL
+				`,
+				StatusReconciled,
+				id,
+				accountID,
+			)
+			if errors.Is(err, ErrNotFound) {
+				return fmt.Errorf("%w: transaction %d in account %d", ErrNotFound, id, accountID)
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func isTransactionReconciled(db dbExecutor, id int) bool {
+	type statusRow struct{ Status TransactionStatus }
+	rows := dbGet[statusRow](db, `SELECT status FROM transactions WHERE id = $id`, id)
+
+	return len(rows) > 0 && rows[0].Status == StatusReconciled
+}
@@ -0,0 +1,68 @@
+package ezex
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestTransactionStatus(t *testing.T) {
+	payeeID, _ := AddPayee(testDB, Payee{Name: "TestTransactionStatus"})
+	accountID, _ := AddAccount(testDB, Account{Name: "TestTransactionStatus"})
+	date := time.Date(2108, 1, 1, 0, 0, 0, 0, time.Local).Unix()
+	id, _ := AddTransaction(testDB, Transaction{PayeeID: payeeID, AccountID: accountID, AmountInCents: -100, TransactionDateUnix: date})
+
+	assert.Nil(t, SetTransactionStatus(testDB, id, StatusCleared))
+	cleared := FilterTransactions(testDB, TransactionFilter{AccountIDs: []int{accountID}, Statuses: []TransactionStatus{StatusCleared}})
+	assert.Len(t, cleared, 1)
+	assert.Equal(t, StatusCleared, cleared[0].Status)
+
+	assert.Error(t, SetTransactionStatus(testDB, id, StatusReconciled))
+	assert.Error(t, SetTransactionStatus(testDB, -1, StatusCleared))
+
+	assert.Nil(t, Reconcile(testDB, accountID, []int{id}))
+	assert.ErrorIs(t, SetTransactionStatus(testDB, id, StatusUncommitted), ErrTransactionReconciled)
+	_, err := DeleteTransaction(testDB, id)
+	assert.ErrorIs(t, err, ErrTransactionReconciled)
+	_, err = UpdateTransaction(testDB, Transaction{ID: id, AccountID: accountID, AmountInCents: -200, TransactionDateUnix: date})
+	assert.ErrorIs(t, err, ErrTransactionReconciled)
+}
+
+func TestReconcile(t *testing.T) {
+	accountID, _ := AddAccount(testDB, Account{Name: "TestReconcile", InitialBalanceInCents: 10000})
+	otherAccountID, _ := AddAccount(testDB, Account{Name: "TestReconcile_other"})
+	payeeID, _ := AddPayee(testDB, Payee{Name: "TestReconcile"})
+	date := time.Date(2108, 2, 1, 0, 0, 0, 0, time.Local).Unix()
+	add := func(accountID int, amountInCents int64) int {
+		id, _ := AddTransaction(testDB, Transaction{
+			AccountID:           accountID,
+			PayeeID:             payeeID,
+			AmountInCents:       amountInCents,
+			TransactionDateUnix: date,
+		})
+		return id
+	}
+
+	reconciledID := add(accountID, -1000)
+	clearedID := add(accountID, -2000)
+	add(accountID, 500)
+	deletedID := add(accountID, -300)
+	_, _ = DeleteTransaction(testDB, deletedID)
+	otherID := add(otherAccountID, -100)
+
+	assert.Error(t, Reconcile(testDB, accountID, []int{reconciledID, otherID}))
+	assert.Error(t, Reconcile(testDB, accountID, []int{deletedID}))
+	assert.Nil(t, Reconcile(testDB, accountID, []int{reconciledID}))
+	assert.Nil(t, SetTransactionStatus(testDB, clearedID, StatusCleared))
+
+	balance, err := GetAccountBalance(testDB, accountID)
+	assert.Nil(t, err)
+	assert.Equal(t, AccountBalance{ReconciledInCents: 9000, ClearedInCents: 7000, WorkingInCents: 7500}, balance)
+
+	// The failed reconciliation is rolled back
+	other, _ := GetAccountBalance(testDB, otherAccountID)
+	assert.Equal(t, int64(0), other.ReconciledInCents)
+
+	_, err = GetAccountBalance(testDB, -1)
+	assert.Error(t, err)
+}
@@ -0,0 +1,88 @@
+package report
+
+import ezex "github.com/armanimichael/ez-ex"
+
+const (
+	categoryBarWidth = 200
+
+	chartHeight     = 200
+	chartLabelSpace = 20
+	chartGroupWidth = 48
+	chartBarWidth   = 20
+)
+
+// bar is a single SVG rectangle, sizes are in px
+type bar struct {
+	X      int
+	Y      int
+	Width  int
+	Height int
+}
+
+// barChart is a grouped (income / expense) SVG bar chart, one group per month
+type barChart struct {
+	Width  int
+	Height int
+	// Baseline is the Y coordinate of the bars bottom
+	Baseline int
+	Groups   []barGroup
+}
+
+type barGroup struct {
+	Label   string
+	LabelX  int
+	Income  bar
+	Expense bar
+}
+
+// newBar returns a horizontal bar whose width is proportional to value / maxValue, capped to width
+func newBar(value int64, maxValue int64, width int) bar {
+	if maxValue <= 0 || value <= 0 {
+		return bar{Height: 12}
+	}
+
+	return bar{
+		Width:  min(width, max(1, int(value*int64(width)/maxValue))),
+		Height: 12,
+	}
+}
+
+func newCashFlowChart(months []ezex.MonthlyCashFlow) barChart {
+	var maxValue int64
+	for _, month := range months {
+		maxValue = max(maxValue, month.IncomeInCents, -month.ExpenseInCents)
+	}
+
+	chart := barChart{
+		Width:    max(1, len(months)) * chartGroupWidth,
+		Height:   chartHeight + chartLabelSpace,
+		Baseline: chartHeight,
+	}
+
+	for i, month := range months {
+		x := i * chartGroupWidth
+		chart.Groups = append(chart.Groups, barGroup{
+			Label:   month.Month,
+			LabelX:  x + chartGroupWidth/2,
+			Income:  newColumn(x+2, month.IncomeInCents, maxValue),
+			Expense: newColumn(x+2+chartBarWidth, -month.ExpenseInCents, maxValue),
+		})
+	}
+
+	return chart
+}
+
+// newColumn returns a vertical bar sitting on the chart baseline, whose height is proportional to value / maxValue
+func newColumn(x int, value int64, maxValue int64) bar {
+	height := 0
+	if maxValue > 0 && value > 0 {
+		height = max(1, int(value*chartHeight/maxValue))
+	}
+
+	return bar{
+		X:      x,
+		Y:      chartHeight - height,
+		Width:  chartBarWidth,
+		Height: height,
+	}
+}
@@ -0,0 +1,197 @@
+// Package report renders printable financial reports.
+package report
+
+import (
+	"database/sql"
+	_ "embed"
+	ezex "github.com/armanimichael/ez-ex"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"html/template"
+	"io"
+	"time"
+)
+
+//go:embed template.html
+var htmlTemplateSource string
+
+var htmlTemplate = template.Must(template.New("report").Parse(htmlTemplateSource))
+
+// Options sets the period covered by the report
+type Options struct {
+	// MinDate is the first included date
+	MinDate time.Time
+	// MaxDate is the first excluded date
+	MaxDate time.Time
+}
+
+type reportData struct {
+	From         string
+	To           string
+	GeneratedAt  string
+	TotalBalance string
+	Accounts     []accountRow
+	CashFlow     cashFlowRow
+	Months       []cashFlowRow
+	MonthsChart  barChart
+	Categories   []categoryRow
+	Tags         []tagRow
+	Transactions []transactionRow
+}
+
+type accountRow struct {
+	Name        string
+	Description string
+	Balance     string
+	Negative    bool
+}
+
+type cashFlowRow struct {
+	Label   string
+	Income  string
+	Expense string
+	Net     string
+}
+
+type categoryRow struct {
+	Name     string
+	Amount   string
+	Share    string
+	Negative bool
+	Bar      bar
+}
+
+type tagRow struct {
+	Name     string
+	Amount   string
+	Negative bool
+}
+
+type transactionRow struct {
+	Date     string
+	Account  string
+	Payee    string
+	Category string
+	Tags     string
+	Amount   string
+	Notes    string
+	Negative bool
+}
+
+// HTML writes a self-contained (inline CSS and SVG, no external assets) HTML report to w
+func HTML(db *sql.DB, w io.Writer, opts Options) error {
+	data := reportData{
+		From:        opts.MinDate.Format(time.DateOnly),
+		To:          opts.MaxDate.AddDate(0, 0, -1).Format(time.DateOnly),
+		GeneratedAt: time.Now().Format(time.DateTime),
+	}
+
+	var totalBalance int64
+	for _, account := range ezex.GetAccounts(db) {
+		totalBalance += account.BalanceInCents
+		data.Accounts = append(data.Accounts, accountRow{
+			Name:        account.Name,
+			Description: account.Description.String,
+			Balance:     formatCents(account.BalanceInCents),
+			Negative:    account.BalanceInCents < 0,
+		})
+	}
+	data.TotalBalance = formatCents(totalBalance)
+
+	cashFlow := ezex.GetCashFlow(db, opts.MinDate, opts.MaxDate)
+	data.CashFlow = newCashFlowRow("Total", cashFlow.IncomeInCents, cashFlow.ExpenseInCents)
+
+	months := ezex.GetMonthlyCashFlow(db, opts.MinDate, opts.MaxDate)
+	for _, month := range months {
+		data.Months = append(data.Months, newCashFlowRow(month.Month, month.IncomeInCents, month.ExpenseInCents))
+	}
+	data.MonthsChart = newCashFlowChart(months)
+
+	data.Categories = newCategoryRows(ezex.GetCategoryTotals(db, opts.MinDate, opts.MaxDate))
+	for _, total := range ezex.GetTagTotals(db, opts.MinDate, opts.MaxDate) {
+		data.Tags = append(data.Tags, tagRow{
+			Name:     "#" + total.TagName,
+			Amount:   formatCents(total.AmountInCents),
+			Negative: total.AmountInCents < 0,
+		})
+	}
+
+	filter := ezex.TransactionFilter{MinDate: opts.MinDate, MaxDate: opts.MaxDate}
+	err := ezex.WalkTransactions(db, filter, func(t ezex.TransactionView) error {
+		data.Transactions = append(data.Transactions, transactionRow{
+			Date:     time.Unix(t.TransactionDateUnix, 0).Format(time.DateOnly),
+			Account:  t.AccountName,
+			Payee:    t.PayeeName,
+			Category: t.CategoryName,
+			Tags:     ezex.FormatTags(t.Tags),
+			Amount:   formatCents(t.AmountInCents),
+			Notes:    t.Notes.String,
+			Negative: t.AmountInCents < 0,
+		})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return htmlTemplate.Execute(w, data)
+}
+
+func newCashFlowRow(label string, income int64, expense int64) cashFlowRow {
+	return cashFlowRow{
+		Label:   label,
+		Income:  formatCents(income),
+		Expense: formatCents(expense),
+		Net:     formatCents(income + expense),
+	}
+}
+
+// newCategoryRows maps category totals to rows, expenses bars are relative to the biggest expense
+// and incomes bars to the biggest income. Subcategories are rolled up into their ancestors,
+// so shares and bars are relative to the top-level categories
+func newCategoryRows(totals []ezex.CategoryTotal) []categoryRow {
+	var totalExpense, totalIncome, maxExpense, maxIncome int64
+	for _, total := range totals {
+		if total.ParentID != 0 {
+			continue
+		}
+
+		if total.AmountInCents < 0 {
+			totalExpense -= total.AmountInCents
+			maxExpense = max(maxExpense, -total.AmountInCents)
+		} else {
+			totalIncome += total.AmountInCents
+			maxIncome = max(maxIncome, total.AmountInCents)
+		}
+	}
+
+	rows := make([]categoryRow, 0, len(totals))
+	for _, total := range totals {
+		amount, sum, maxAmount := total.AmountInCents, totalIncome, maxIncome
+		if amount < 0 {
+			amount, sum, maxAmount = -amount, totalExpense, maxExpense
+		}
+
+		rows = append(rows, categoryRow{
+			Name:     total.Path,
+			Amount:   formatCents(total.AmountInCents),
+			Share:    formatShare(amount, sum),
+			Negative: total.AmountInCents < 0,
+			Bar:      newBar(amount, maxAmount, categoryBarWidth),
+		})
+	}
+
+	return rows
+}
+
+func formatCents(cents int64) string {
+	return ezex.Money{Cents: cents}.Format(language.English)
+}
+
+func formatShare(value int64, total int64) string {
+	if total == 0 {
+		return "-"
+	}
+
+	return message.NewPrinter(language.English).Sprintf("%.1f%%", float64(value)*100/float64(total))
+}
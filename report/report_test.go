@@ -0,0 +1,72 @@
+package report
+
+import (
+	"bytes"
+	"database/sql"
+	ezex "github.com/armanimichael/ez-ex"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"path"
+	"testing"
+	"time"
+)
+
+func TestHTML(t *testing.T) {
+	db, err := sql.Open("sqlite3", path.Join(t.TempDir(), "report-test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(db *sql.DB) {
+		_ = db.Close()
+	}(db)
+	if err = ezex.MigrateDB(db); err != nil {
+		t.Fatal(err)
+	}
+
+	accountID, _ := ezex.AddAccount(db, ezex.Account{Name: "Checking", BalanceInCents: 123456})
+	payeeID, _ := ezex.AddPayee(db, ezex.Payee{Name: "Hardware store"})
+	categoryID, _ := ezex.AddCategory(db, ezex.Category{Name: "Home"})
+	transactionID, _ := ezex.AddTransaction(db, ezex.Transaction{
+		CategoryID:          categoryID,
+		PayeeID:             payeeID,
+		AccountID:           accountID,
+		AmountInCents:       -4250,
+		TransactionDateUnix: time.Date(2023, 5, 4, 0, 0, 0, 0, time.Local).Unix(),
+		Notes:               sql.NullString{String: "<script>alert(1)</script>", Valid: true},
+	})
+
+	_ = ezex.TagTransaction(db, transactionID, "renovation")
+
+	buf := bytes.Buffer{}
+	err = HTML(db, &buf, Options{
+		MinDate: time.Date(2023, 1, 1, 0, 0, 0, 0, time.Local),
+		MaxDate: time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local),
+	})
+	html := buf.String()
+
+	assert.Nil(t, err)
+	assert.Contains(t, html, "2023-01-01 &ndash; 2023-12-31")
+	assert.Contains(t, html, "1,234.56")
+	assert.Contains(t, html, "Hardware store")
+	assert.Contains(t, html, "-42.50")
+	assert.Contains(t, html, "2023-05")
+	assert.Contains(t, html, "#renovation")
+	assert.Contains(t, html, "&lt;script&gt;")
+	assert.NotContains(t, html, "<script>")
+	assert.NotContains(t, html, "<link")
+	assert.NotContains(t, html, "src=")
+}
+
+func TestNewCashFlowChart(t *testing.T) {
+	chart := newCashFlowChart([]ezex.MonthlyCashFlow{
+		{Month: "2023-01", IncomeInCents: 1000, ExpenseInCents: -500},
+		{Month: "2023-02", IncomeInCents: 0, ExpenseInCents: -2000},
+	})
+
+	assert.Len(t, chart.Groups, 2)
+	assert.Equal(t, chartHeight/2, chart.Groups[0].Income.Height)
+	assert.Equal(t, chartHeight/4, chart.Groups[0].Expense.Height)
+	assert.Equal(t, 0, chart.Groups[1].Income.Height)
+	assert.Equal(t, chartHeight, chart.Groups[1].Expense.Height)
+	assert.Equal(t, 0, chart.Groups[1].Expense.Y)
+}
@@ -0,0 +1,478 @@
+package ezex
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Rule categorizes transactions automatically, when a transaction satisfies every condition the actions are applied.
+// Rules are evaluated by Position: the first matching rule setting a category (or payee) wins, tags are accumulated
+type Rule struct {
+	ID       int
+	Name     string
+	Position int
+	// PayeePattern is a regular expression matched (case-insensitively) against the payee name, empty matches any
+	PayeePattern string
+	// MinAmountInCents is the smallest matching amount
+	MinAmountInCents sql.NullInt64
+	// MaxAmountInCents is the biggest matching amount
+	MaxAmountInCents sql.NullInt64
+	// AccountID is the matching account, 0 matches any
+	AccountID int
+	// NotesPattern is a regular expression matched (case-insensitively) against the notes, empty matches any
+	NotesPattern string
+	// CategoryID is the category to set, 0 leaves it unchanged
+	CategoryID int
+	// PayeeName is the payee to set (e.g. `Amazon` for `AMZN Mktp`), created if missing, empty leaves it unchanged
+	PayeeName string
+	// Tags are the tag names to add, separated by spaces
+	Tags string
+}
+
+// RuleMatch is the outcome of the rules matching a transaction
+type RuleMatch struct {
+	// RuleIDs are the matching rules, in evaluation order
+	RuleIDs    []int
+	CategoryID int
+	PayeeName  string
+	Tags       []string
+}
+
+// RuleChange is a change applied (or, on dry runs, to apply) by the rules to a transaction,
+// zero values are left unchanged
+type RuleChange struct {
+	Transaction  TransactionView
+	CategoryID   int
+	CategoryPath string
+	PayeeName    string
+	// AddedTags are the tags the transaction didn't have yet
+	AddedTags []string
+	// Skipped is set on reconciled transactions, which are locked: their change is reported but never applied
+	Skipped bool
+}
+
+var (
+	// ErrInvalidRuleName is returned when a rule name is empty
+	ErrInvalidRuleName = errors.New("rule name can't be empty")
+	// ErrRuleWithoutActions is returned when a rule sets no category, payee or tags
+	ErrRuleWithoutActions = errors.New("rule must set a category, a payee or tags")
+	// ErrInvalidRuleAmountRange is returned when a rule min amount is bigger than its max amount
+	ErrInvalidRuleAmountRange = errors.New("rule min amount can't be bigger than its max amount")
+)
+
+// Matches reports whether the transaction satisfies every condition of the rule, invalid patterns never match
+func (r Rule) Matches(transaction TransactionView) bool {
+	compiled, err := compileRule(r)
+	return err == nil && compiled.matches(transaction)
+}
+
+// Validate checks the rule name, actions, amount range and patterns
+func (r Rule) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return ErrInvalidRuleName
+	}
+	if r.CategoryID == 0 && strings.TrimSpace(r.PayeeName) == "" && len(ParseTags(r.Tags)) == 0 {
+		return ErrRuleWithoutActions
+	}
+	if r.MinAmountInCents.Valid && r.MaxAmountInCents.Valid && r.MinAmountInCents.Int64 > r.MaxAmountInCents.Int64 {
+		return ErrInvalidRuleAmountRange
+	}
+	for _, name := range ParseTags(r.Tags) {
+		if !isValidTagName(name) {
+			return ErrInvalidTagName
+		}
+	}
+
+	_, err := compileRule(r)
+	return err
+}
+
+// AddRule creates a new rule, evaluated after the existing ones, and returns the new rule ID if successful
+func AddRule(db *sql.DB, rule Rule) (int, error) {
+	return addRule(db, rule)
+}
+
+func AddRuleContext(ctx context.Context, db *sql.DB, rule Rule) (int, error) {
+	return addRule(withContext(ctx, db), rule)
+}
+
+func addRule(db dbExecutor, rule Rule) (int, error) {
+	if err := rule.Validate(); err != nil {
+		return -1, err
+	}
+
+	return dbAdd(
+		db,
+		`
+		INSERT INTO rules	(name, position, payee_pattern, min_amount_in_cents, max_amount_in_cents, account_id, notes_pattern, category_id, payee_name, tags)
+		VALUES				($name, (SELECT COALESCE(MAX(position), 0) + 1 FROM rules), $payee_pattern, $min_amount_in_cents, $max_amount_in_cents, NULLIF($account_id, 0), $notes_pattern, NULLIF($category_id, 0), $payee_name, $tags)
+		`,
+		strings.TrimSpace(rule.Name),
+		rule.PayeePattern,
+		rule.MinAmountInCents,
+		rule.MaxAmountInCents,
+		rule.AccountID,
+		rule.NotesPattern,
+		rule.CategoryID,
+		strings.TrimSpace(rule.PayeeName),
+		strings.Join(ParseTags(rule.Tags), " "),
+	)
+}
+
+// UpdateRule updates the conditions and actions of a rule, its position is left unchanged
+func UpdateRule(db *sql.DB, rule Rule) (int, error) {
+	return updateRule(db, rule)
+}
+
+func UpdateRuleContext(ctx context.Context, db *sql.DB, rule Rule) (int, error) {
+	return updateRule(withContext(ctx, db), rule)
+}
+
+func updateRule(db dbExecutor, rule Rule) (int, error) {
+	if err := rule.Validate(); err != nil {
+		return 0, err
+	}
+
+	return dbUpdate(
+		db,
+		`
+		UPDATE	rules
+		SET		name				= $name,
+				payee_pattern		= $payee_pattern,
+				min_amount_in_cents	= $min_amount_in_cents,
+				max_amount_in_cents	= $max_amount_in_cents,
+				account_id			= NULLIF($account_id, 0),
+				notes_pattern		= $notes_pattern,
+				category_id			= NULLIF($category_id, 0),
+				payee_name			= $payee_name,
+				tags				= $tags
+		WHERE	id = $id
+		`,
+		strings.TrimSpace(rule.Name),
+		rule.PayeePattern,
+		rule.MinAmountInCents,
+		rule.MaxAmountInCents,
+		rule.AccountID,
+		rule.NotesPattern,
+		rule.CategoryID,
+		strings.TrimSpace(rule.PayeeName),
+		strings.Join(ParseTags(rule.Tags), " "),
+		rule.ID,
+	)
+}
+
+// DeleteRule deletes a rule and returns the number of affected rows, ErrNotFound if it doesn't exist
+func DeleteRule(db *sql.DB, id int) (int, error) {
+	return deleteRule(db, id)
+}
+
+func DeleteRuleContext(ctx context.Context, db *sql.DB, id int) (int, error) {
+	return deleteRule(withContext(ctx, db), id)
+}
+
+func deleteRule(db dbExecutor, id int) (int, error) {
+	return dbDelete(db, `DELETE FROM rules WHERE id = $id`, id)
+}
+
+// GetRules returns every rule in evaluation order
+func GetRules(db *sql.DB) []Rule {
+	return getRules(db)
+}
+
+func GetRulesContext(ctx context.Context, db *sql.DB) []Rule {
+	return getRules(withContext(ctx, db))
+}
+
+func getRules(db dbExecutor) []Rule {
+	return dbGet[Rule](
+		db,
+		`
+		SELECT		id,
+					name,
+					position,
+					payee_pattern,
+					min_amount_in_cents,
+					max_amount_in_cents,
+					COALESCE(account_id, 0),
+					notes_pattern,
+					COALESCE(category_id, 0),
+					payee_name,
+					tags
+		FROM		rules
+		ORDER BY	position, id
+		`,
+	)
+}
+
+// MoveRule moves a rule by offset positions in the evaluation order (negative = earlier), clamped to the first/last one
+func MoveRule(db *sql.DB, id int, offset int) error {
+	return moveRule(db, id, offset)
+}
+
+func MoveRuleContext(ctx context.Context, db *sql.DB, id int, offset int) error {
+	return moveRule(withContext(ctx, db), id, offset)
+}
+
+func moveRule(db dbExecutor, id int, offset int) error {
+	return dbTransaction(db, func(tx dbExecutor) error {
+		ids := dbGet[struct{ ID int }](tx, `SELECT id FROM rules ORDER BY position, id`)
+
+		from := -1
+		for i, rule := range ids {
+			if rule.ID == id {
+				from = i
+				break
+			}
+		}
+		if from == -1 {
+			return fmt.Errorf("%w: rule %d", ErrNotFound, id)
+		}
+
+		to := min(max(from+offset, 0), len(ids)-1)
+		moved := ids[from]
+		ids = append(ids[:from], ids[from+1:]...)
+		ids = append(ids[:to], append([]struct{ ID int }{moved}, ids[to:]...)...)
+
+		for i, rule := range ids {
+			if _, err := tx.Exec(`UPDATE rules SET position = $position WHERE id = $id`, i+1, rule.ID); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// MatchRules evaluates the rules, in the given order, against a transaction
+func MatchRules(rules []Rule, transaction TransactionView) (RuleMatch, error) {
+	compiled, err := compileRules(rules)
+	if err != nil {
+		return RuleMatch{}, err
+	}
+
+	return matchRules(compiled, transaction), nil
+}
+
+// ApplyRules applies the rules to the transactions matching the filter, returning the changes.
+// Categories are only set on uncategorized transactions unless overwrite is set, split transactions keep their
+// splits categories. Reconciled transactions are reported as skipped (see RuleChange.Skipped), nothing is saved on
+// dry runs
+func ApplyRules(db *sql.DB, filter TransactionFilter, overwrite bool, dryRun bool) ([]RuleChange, error) {
+	return applyRules(db, filterTransactions(db, filter), overwrite, dryRun)
+}
+
+func ApplyRulesContext(ctx context.Context, db *sql.DB, filter TransactionFilter, overwrite bool, dryRun bool) ([]RuleChange, error) {
+	executor := withContext(ctx, db)
+	return applyRules(executor, filterTransactions(executor, filter), overwrite, dryRun)
+}
+
+// ApplyTransactionRules applies the rules to a single transaction (e.g. just created or imported),
+// its category is only set if uncategorized. Reconciled transactions are left as they are (see RuleChange.Skipped)
+func ApplyTransactionRules(db *sql.DB, id int) (RuleChange, error) {
+	return applyTransactionRules(db, id)
+}
+
+func ApplyTransactionRulesContext(ctx context.Context, db *sql.DB, id int) (RuleChange, error) {
+	return applyTransactionRules(withContext(ctx, db), id)
+}
+
+func applyTransactionRules(db dbExecutor, id int) (RuleChange, error) {
+	transactions := dbGet[TransactionView](db, transactionViewQuery+`WHERE t.id = $id`, id)
+	if len(transactions) == 0 {
+		return RuleChange{}, fmt.Errorf("%w: transaction %d", ErrNotFound, id)
+	}
+
+	changes, err := applyRules(db, transactions, false, false)
+	if err != nil || len(changes) == 0 {
+		return RuleChange{Transaction: transactions[0]}, err
+	}
+
+	return changes[0], nil
+}
+
+func applyRules(db dbExecutor, transactions []TransactionView, overwrite bool, dryRun bool) ([]RuleChange, error) {
+	rules, err := compileRules(getRules(db))
+	if err != nil || len(rules) == 0 {
+		return nil, err
+	}
+
+	paths := make(map[int]string)
+	for _, category := range getCategoryTree(db) {
+		paths[category.ID] = category.Path
+	}
+
+	var changes []RuleChange
+	for _, transaction := range transactions {
+		match := matchRules(rules, transaction)
+		if len(match.RuleIDs) == 0 {
+			continue
+		}
+
+		change := RuleChange{Transaction: transaction}
+		path, exists := paths[match.CategoryID]
+		canCategorize := transaction.SplitCount == 0 && (overwrite || transaction.CategoryID == 0)
+		if exists && canCategorize && match.CategoryID != 0 && match.CategoryID != transaction.CategoryID {
+			change.CategoryID = match.CategoryID
+			change.CategoryPath = path
+		}
+		if match.PayeeName != "" && match.PayeeName != transaction.PayeeName {
+			change.PayeeName = match.PayeeName
+		}
+
+		existing := make(map[string]struct{})
+		for _, name := range strings.Fields(transaction.Tags) {
+			existing[strings.ToLower(name)] = struct{}{}
+		}
+		for _, name := range match.Tags {
+			if _, ok := existing[strings.ToLower(name)]; !ok {
+				change.AddedTags = append(change.AddedTags, name)
+			}
+		}
+
+		if change.CategoryID != 0 || change.PayeeName != "" || len(change.AddedTags) > 0 {
+			change.Skipped = transaction.Status == StatusReconciled
+			changes = append(changes, change)
+		}
+	}
+
+	if dryRun || len(changes) == 0 {
+		return changes, nil
+	}
+
+	err = dbTransaction(db, func(tx dbExecutor) error {
+		for _, change := range changes {
+			if change.Skipped {
+				continue
+			}
+			if err := applyRuleChange(tx, change); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+func applyRuleChange(tx dbExecutor, change RuleChange) error {
+	id := change.Transaction.ID
+	// The transaction may have been reconciled since it was read
+	if isTransactionReconciled(tx, id) {
+		return ErrTransactionReconciled
+	}
+
+	if change.CategoryID != 0 {
+		if _, err := tx.Exec(`UPDATE transactions SET category_id = $category_id WHERE id = $id`, change.CategoryID, id); err != nil {
+			return dbError(err, ErrNotFound)
+		}
+	}
+
+	if change.PayeeName != "" {
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO payees (name) VALUES ($name)`, change.PayeeName); err != nil {
+			return dbError(err, ErrNotFound)
+		}
+
+		_, err := tx.Exec(
+			`UPDATE transactions SET payee_id = (SELECT id FROM payees WHERE name = $name) WHERE id = $id`,
+			change.PayeeName,
+			id,
+		)
+		if err != nil {
+			return dbError(err, ErrNotFound)
+		}
+	}
+
+	return tagTransaction(tx, id, change.AddedTags)
+}
+
+// compiledRule is a rule along with its compiled patterns, nil patterns match any value
+type compiledRule struct {
+	Rule
+	payee *regexp.Regexp
+	notes *regexp.Regexp
+}
+
+func compileRule(rule Rule) (compiledRule, error) {
+	compiled := compiledRule{Rule: rule}
+
+	var err error
+	if rule.PayeePattern != "" {
+		if compiled.payee, err = regexp.Compile("(?i)" + rule.PayeePattern); err != nil {
+			return compiledRule{}, fmt.Errorf("invalid payee pattern: %w", err)
+		}
+	}
+	if rule.NotesPattern != "" {
+		if compiled.notes, err = regexp.Compile("(?i)" + rule.NotesPattern); err != nil {
+			return compiledRule{}, fmt.Errorf("invalid notes pattern: %w", err)
+		}
+	}
+
+	return compiled, nil
+}
+
+func compileRules(rules []Rule) ([]compiledRule, error) {
+	compiled := make([]compiledRule, len(rules))
+	for i, rule := range rules {
+		var err error
+		if compiled[i], err = compileRule(rule); err != nil {
+			return nil, fmt.Errorf("rule %q: %w", rule.Name, err)
+		}
+	}
+
+	return compiled, nil
+}
+
+func (r compiledRule) matches(transaction TransactionView) bool {
+	if r.AccountID != 0 && r.AccountID != transaction.AccountID {
+		return false
+	}
+	if r.MinAmountInCents.Valid && transaction.AmountInCents < r.MinAmountInCents.Int64 {
+		return false
+	}
+	if r.MaxAmountInCents.Valid && transaction.AmountInCents > r.MaxAmountInCents.Int64 {
+		return false
+	}
+	if r.payee != nil && !r.payee.MatchString(transaction.PayeeName) {
+		return false
+	}
+	if r.notes != nil && !r.notes.MatchString(transaction.Notes.String) {
+		return false
+	}
+
+	return true
+}
+
+func matchRules(rules []compiledRule, transaction TransactionView) RuleMatch {
+	match := RuleMatch{}
+	seen := make(map[string]struct{})
+
+	for _, rule := range rules {
+		if !rule.matches(transaction) {
+			continue
+		}
+
+		match.RuleIDs = append(match.RuleIDs, rule.ID)
+		if match.CategoryID == 0 {
+			match.CategoryID = rule.CategoryID
+		}
+		if match.PayeeName == "" {
+			match.PayeeName = rule.PayeeName
+		}
+		for _, name := range ParseTags(rule.Tags) {
+			if _, ok := seen[strings.ToLower(name)]; !ok {
+				seen[strings.ToLower(name)] = struct{}{}
+				match.Tags = append(match.Tags, name)
+			}
+		}
+	}
+
+	return match
+}
@@ -0,0 +1,224 @@
+package ezex
+
+import (
+	"database/sql"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestAddRule(t *testing.T) {
+	id, err := AddRule(testDB, Rule{Name: "TestAddRule", PayeePattern: "^TestAddRule", Tags: "#TestAddRule"})
+	assert.Nil(t, err)
+	assert.Greater(t, id, 0)
+	defer DeleteRule(testDB, id)
+
+	_, err = AddRule(testDB, Rule{Name: " ", Tags: "TestAddRule"})
+	assert.ErrorIs(t, err, ErrInvalidRuleName)
+	_, err = AddRule(testDB, Rule{Name: "TestAddRule", PayeePattern: "TestAddRule"})
+	assert.ErrorIs(t, err, ErrRuleWithoutActions)
+	_, err = AddRule(testDB, Rule{
+		Name:             "TestAddRule",
+		MinAmountInCents: sql.NullInt64{Int64: 100, Valid: true},
+		MaxAmountInCents: sql.NullInt64{Int64: -100, Valid: true},
+		Tags:             "TestAddRule",
+	})
+	assert.ErrorIs(t, err, ErrInvalidRuleAmountRange)
+	_, err = AddRule(testDB, Rule{Name: "TestAddRule", PayeePattern: "(", Tags: "TestAddRule"})
+	assert.ErrorContains(t, err, "invalid payee pattern")
+
+	rules := GetRules(testDB)
+	assert.Equal(t, id, rules[len(rules)-1].ID)
+	assert.Equal(t, "TestAddRule", rules[len(rules)-1].Tags)
+}
+
+func TestDeleteRule(t *testing.T) {
+	id, _ := AddRule(testDB, Rule{Name: "TestDeleteRule", Tags: "TestDeleteRule"})
+
+	n, err := DeleteRule(testDB, id)
+	assert.Equal(t, 1, n)
+	assert.Nil(t, err)
+
+	_, err = DeleteRule(testDB, id)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestMoveRule(t *testing.T) {
+	firstID, _ := AddRule(testDB, Rule{Name: "TestMoveRule_1", Tags: "TestMoveRule"})
+	secondID, _ := AddRule(testDB, Rule{Name: "TestMoveRule_2", Tags: "TestMoveRule"})
+	defer DeleteRule(testDB, firstID)
+	defer DeleteRule(testDB, secondID)
+
+	assert.Nil(t, MoveRule(testDB, secondID, -1))
+	assert.Equal(t, []int{secondID, firstID}, ruleIDs(GetRules(testDB), firstID, secondID))
+
+	// Moves are clamped
+	assert.Nil(t, MoveRule(testDB, secondID, 1000))
+	assert.Equal(t, []int{firstID, secondID}, ruleIDs(GetRules(testDB), firstID, secondID))
+
+	assert.Error(t, MoveRule(testDB, -1, 1))
+}
+
+func TestMatchRules(t *testing.T) {
+	rules := []Rule{
+		{ID: 1, Name: "fuel", PayeePattern: "^shell", MaxAmountInCents: sql.NullInt64{Valid: true}, CategoryID: 10},
+		{ID: 2, Name: "cards", AccountID: 3, CategoryID: 20, PayeeName: "Shell", Tags: "car"},
+		{ID: 3, Name: "trips", NotesPattern: "trip", Tags: "#car #vacation"},
+	}
+
+	match, err := MatchRules(rules, TransactionView{
+		AccountID:     3,
+		AmountInCents: -5000,
+		PayeeName:     "SHELL 1234",
+		Notes:         sql.NullString{String: "Road trip", Valid: true},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, RuleMatch{
+		RuleIDs:    []int{1, 2, 3},
+		CategoryID: 10,
+		PayeeName:  "Shell",
+		Tags:       []string{"car", "vacation"},
+	}, match)
+
+	// Incomes don't match the max amount
+	match, _ = MatchRules(rules, TransactionView{AccountID: 1, AmountInCents: 5000, PayeeName: "Shell"})
+	assert.Empty(t, match.RuleIDs)
+
+	_, err = MatchRules([]Rule{{Name: "invalid", NotesPattern: "["}}, TransactionView{})
+	assert.Error(t, err)
+}
+
+func TestApplyRules(t *testing.T) {
+	payeeID, _ := AddPayee(testDB, Payee{Name: "TestApplyRules AMZN Mktp"})
+	accountID, _ := AddAccount(testDB, Account{Name: "TestApplyRules"})
+	categoryID, _ := AddCategoryPath(testDB, "TestApplyRules:Shopping")
+	otherCategoryID, _ := AddCategory(testDB, Category{Name: "TestApplyRules_other"})
+
+	uncategorizedID, _ := AddTransaction(testDB, Transaction{PayeeID: payeeID, AccountID: accountID, AmountInCents: -100})
+	categorizedID, _ := AddTransaction(testDB, Transaction{
+		PayeeID:             payeeID,
+		AccountID:           accountID,
+		CategoryID:          otherCategoryID,
+		AmountInCents:       -200,
+		TransactionDateUnix: 1,
+	})
+
+	ruleID, err := AddRule(testDB, Rule{
+		Name:         "TestApplyRules",
+		PayeePattern: `^TestApplyRules (AMZN|Amazon)`,
+		AccountID:    accountID,
+		CategoryID:   categoryID,
+		PayeeName:    "TestApplyRules Amazon",
+		Tags:         "TestApplyRules",
+	})
+	assert.Nil(t, err)
+	defer DeleteRule(testDB, ruleID)
+
+	filter := TransactionFilter{AccountIDs: []int{accountID}}
+
+	// Dry runs don't change anything
+	changes, err := ApplyRules(testDB, filter, false, true)
+	assert.Nil(t, err)
+	assert.Len(t, changes, 2)
+	assert.Equal(t, categorizedID, changes[0].Transaction.ID)
+	assert.Equal(t, 0, changes[0].CategoryID)
+	assert.Equal(t, uncategorizedID, changes[1].Transaction.ID)
+	assert.Equal(t, categoryID, changes[1].CategoryID)
+	assert.Equal(t, "TestApplyRules:Shopping", changes[1].CategoryPath)
+	assert.Equal(t, "TestApplyRules Amazon", changes[1].PayeeName)
+	assert.Equal(t, []string{"TestApplyRules"}, changes[1].AddedTags)
+	assert.Equal(t, "TestApplyRules AMZN Mktp", FilterTransactions(testDB, filter)[1].PayeeName)
+
+	changes, err = ApplyRules(testDB, filter, false, false)
+	assert.Nil(t, err)
+	assert.Len(t, changes, 2)
+
+	transactions := FilterTransactions(testDB, filter)
+	assert.Equal(t, otherCategoryID, transactions[0].CategoryID)
+	assert.Equal(t, categoryID, transactions[1].CategoryID)
+	for _, transaction := range transactions {
+		assert.Equal(t, "TestApplyRules Amazon", transaction.PayeeName)
+		assert.Equal(t, "TestApplyRules", transaction.Tags)
+	}
+
+	// Already applied, only overwriting categories changes something
+	changes, _ = ApplyRules(testDB, filter, false, false)
+	assert.Empty(t, changes)
+	changes, _ = ApplyRules(testDB, filter, true, false)
+	assert.Len(t, changes, 1)
+	assert.Equal(t, categoryID, FilterTransactions(testDB, filter)[0].CategoryID)
+}
+
+func TestApplyRules_Reconciled(t *testing.T) {
+	payeeID, _ := AddPayee(testDB, Payee{Name: "TestApplyRules_Reconciled"})
+	accountID, _ := AddAccount(testDB, Account{Name: "TestApplyRules_Reconciled"})
+	categoryID, _ := AddCategory(testDB, Category{Name: "TestApplyRules_Reconciled"})
+	id, _ := AddTransaction(testDB, Transaction{PayeeID: payeeID, AccountID: accountID, AmountInCents: -100})
+	assert.Nil(t, Reconcile(testDB, accountID, []int{id}))
+
+	ruleID, _ := AddRule(testDB, Rule{
+		Name:         "TestApplyRules_Reconciled",
+		PayeePattern: "^TestApplyRules_Reconciled$",
+		CategoryID:   categoryID,
+		Tags:         "TestApplyRules_Reconciled",
+	})
+	defer DeleteRule(testDB, ruleID)
+
+	filter := TransactionFilter{AccountIDs: []int{accountID}}
+	changes, err := ApplyRules(testDB, filter, false, true)
+	assert.Nil(t, err)
+	assert.Len(t, changes, 1)
+	assert.True(t, changes[0].Skipped)
+
+	changes, err = ApplyRules(testDB, filter, false, false)
+	assert.Nil(t, err)
+	assert.Len(t, changes, 1)
+	transaction := FilterTransactions(testDB, filter)[0]
+	assert.Equal(t, 0, transaction.CategoryID)
+	assert.Empty(t, transaction.Tags)
+
+	change, err := ApplyTransactionRules(testDB, id)
+	assert.Nil(t, err)
+	assert.True(t, change.Skipped)
+	assert.Equal(t, 0, FilterTransactions(testDB, filter)[0].CategoryID)
+}
+
+func TestApplyTransactionRules(t *testing.T) {
+	payeeID, _ := AddPayee(testDB, Payee{Name: "TestApplyTransactionRules"})
+	accountID, _ := AddAccount(testDB, Account{Name: "TestApplyTransactionRules"})
+	categoryID, _ := AddCategory(testDB, Category{Name: "TestApplyTransactionRules"})
+	ruleID, _ := AddRule(testDB, Rule{
+		Name:         "TestApplyTransactionRules",
+		PayeePattern: "^TestApplyTransactionRules$",
+		NotesPattern: "weekly",
+		CategoryID:   categoryID,
+	})
+	defer DeleteRule(testDB, ruleID)
+
+	id, _ := AddTransaction(testDB, Transaction{
+		PayeeID:       payeeID,
+		AccountID:     accountID,
+		AmountInCents: -100,
+		Notes:         sql.NullString{String: "Weekly groceries", Valid: true},
+	})
+	change, err := ApplyTransactionRules(testDB, id)
+	assert.Nil(t, err)
+	assert.Equal(t, categoryID, change.CategoryID)
+	assert.Equal(t, categoryID, FilterTransactions(testDB, TransactionFilter{AccountIDs: []int{accountID}})[0].CategoryID)
+
+	_, err = ApplyTransactionRules(testDB, -1)
+	assert.Error(t, err)
+}
+
+// ruleIDs returns the IDs of the rules in ids, in the rules order
+func ruleIDs(rules []Rule, ids ...int) []int {
+	var filtered []int
+	for _, rule := range rules {
+		for _, id := range ids {
+			if rule.ID == id {
+				filtered = append(filtered, rule.ID)
+			}
+		}
+	}
+
+	return filtered
+}
@@ -0,0 +1,81 @@
+package ezex
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+)
+
+// SearchTransactions returns the transactions matching the filter whose notes, payee name or category paths
+// (including the split ones) contain every word of query (as a prefix when the full-text index is available), most recent first.
+// An empty query returns no results
+func SearchTransactions(db *sql.DB, query string, filter TransactionFilter) []TransactionView {
+	return searchTransactions(db, query, filter)
+}
+
+func SearchTransactionsContext(ctx context.Context, db *sql.DB, query string, filter TransactionFilter) []TransactionView {
+	return searchTransactions(withContext(ctx, db), query, filter)
+}
+
+func searchTransactions(db dbExecutor, query string, filter TransactionFilter) []TransactionView {
+	if strings.TrimSpace(query) == "" {
+		return nil
+	}
+
+	filter.Text = query
+	return filterTransactions(db, filter)
+}
+
+// whereText adds the condition matching every word against notes, payee names and the paths of the category and
+// split categories, using the full-text index when available and plain pattern matching otherwise
+func whereText(db dbExecutor, q *queryBuilder, words []string) {
+	if hasSearchIndex(db) {
+		q.where(
+			"t.id IN (SELECT rowid FROM transactions_search WHERE transactions_search MATCH ?)",
+			toFTSQuery(words),
+		)
+		return
+	}
+
+	for _, word := range words {
+		pattern := "%" + escapeLikePattern(word) + "%"
+		q.where(
+			`(t.notes LIKE ? ESCAPE '\' OR p.name LIKE ? ESCAPE '\' OR cp.path LIKE ? ESCAPE '\' OR EXISTS (
+				SELECT	1
+				FROM	transaction_splits s
+				JOIN	category_paths sp ON sp.id = s.category_id
+				WHERE	s.transaction_id = t.id AND sp.path LIKE ? ESCAPE '\'
+			))`,
+			pattern,
+			pattern,
+			pattern,
+			pattern,
+		)
+	}
+}
+
+// hasSearchIndex reports whether the FTS5 index has been created by MigrateDB and can be queried
+func hasSearchIndex(db dbExecutor) bool {
+	if !isFTS5Available(db) {
+		return false
+	}
+
+	var count int
+	err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'transactions_search'`).Scan(&count)
+
+	return err == nil && count > 0
+}
+
+// toFTSQuery quotes each word as an FTS5 prefix query, so that user input is never parsed as FTS5 syntax
+func toFTSQuery(words []string) string {
+	terms := make([]string, len(words))
+	for i, word := range words {
+		terms[i] = `"` + strings.ReplaceAll(word, `"`, `""`) + `"*`
+	}
+
+	return strings.Join(terms, " ")
+}
+
+func escapeLikePattern(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,147 @@
+package ezex
+
+import (
+	"database/sql"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestSearchTransactions(t *testing.T) {
+	payeeID, _ := AddPayee(testDB, Payee{Name: "TestSearchTransactions Hardware"})
+	account1ID, _ := AddAccount(testDB, Account{Name: "TestSearchTransactions1"})
+	account2ID, _ := AddAccount(testDB, Account{Name: "TestSearchTransactions2"})
+	categoryID, _ := AddCategory(testDB, Category{Name: "TestSearchTransactions Home"})
+
+	date := time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC).Unix()
+	id1, _ := AddTransaction(testDB, Transaction{
+		CategoryID:          categoryID,
+		PayeeID:             payeeID,
+		AccountID:           account1ID,
+		TransactionDateUnix: date,
+		Notes:               sql.NullString{String: "zyxwvut screws and bolts", Valid: true},
+	})
+	id2, _ := AddTransaction(testDB, Transaction{
+		PayeeID:             payeeID,
+		AccountID:           account2ID,
+		TransactionDateUnix: date + 1,
+		Notes:               sql.NullString{String: "zyxwvut paint", Valid: true},
+	})
+	deletedID, _ := AddTransaction(testDB, Transaction{
+		PayeeID:             payeeID,
+		AccountID:           account2ID,
+		TransactionDateUnix: date,
+		Notes:               sql.NullString{String: "zyxwvut deleted", Valid: true},
+	})
+	_, _ = DeleteTransaction(testDB, deletedID)
+
+	ids := func(transactions []TransactionView) []int {
+		var ids []int
+		for _, transaction := range transactions {
+			ids = append(ids, transaction.ID)
+		}
+		return ids
+	}
+
+	assert.Equal(t, []int{id2, id1}, ids(SearchTransactions(testDB, "zyxwvut", TransactionFilter{})))
+	assert.Equal(t, []int{id1}, ids(SearchTransactions(testDB, "zyxwvut screws", TransactionFilter{})))
+	assert.Equal(t, []int{id1}, ids(SearchTransactions(testDB, "zyxwvut home", TransactionFilter{})))
+	assert.Equal(t, []int{id2}, ids(SearchTransactions(testDB, "zyxwvut", TransactionFilter{AccountIDs: []int{account2ID}})))
+	assert.Equal(t, []int{id1}, ids(SearchTransactions(testDB, "zyxwvut", TransactionFilter{MaxDate: time.Unix(date+1, 0)})))
+	assert.Empty(t, SearchTransactions(testDB, "", TransactionFilter{}))
+	assert.Empty(t, SearchTransactions(testDB, `zyxwvut "OR*`, TransactionFilter{}))
+}
+
+func TestSearchTransactions_RenamedPayee(t *testing.T) {
+	payeeID, _ := AddPayee(testDB, Payee{Name: "TestSearchTransactions_RenamedPayee"})
+	accountID, _ := AddAccount(testDB, Account{Name: "TestSearchTransactions_RenamedPayee"})
+	id, _ := AddTransaction(testDB, Transaction{PayeeID: payeeID, AccountID: accountID})
+
+	_, _ = UpdatePayee(testDB, Payee{ID: payeeID, Name: "Qwertyuiop renamed"})
+
+	results := SearchTransactions(testDB, "qwertyuiop", TransactionFilter{})
+	assert.Len(t, results, 1)
+	assert.Equal(t, id, results[0].ID)
+}
+
+func TestSearchTransactions_CategoryPaths(t *testing.T) {
+	payeeID, _ := AddPayee(testDB, Payee{Name: "TestSearchTransactions_CategoryPaths"})
+	accountID, _ := AddAccount(testDB, Account{Name: "TestSearchTransactions_CategoryPaths"})
+	parentID, _ := AddCategory(testDB, Category{Name: "Plokmijn"})
+	childID, _ := AddCategory(testDB, Category{Name: "Uhbygvtf", ParentID: parentID})
+	splitCategoryID, _ := AddCategory(testDB, Category{Name: "Wsxzaqed"})
+	id, _ := AddTransaction(testDB, Transaction{PayeeID: payeeID, AccountID: accountID, CategoryID: childID})
+	splitID, _ := AddSplitTransaction(
+		testDB,
+		Transaction{PayeeID: payeeID, AccountID: accountID, AmountInCents: -1000},
+		[]TransactionSplit{{CategoryID: childID, AmountInCents: -400}, {CategoryID: splitCategoryID, AmountInCents: -600}},
+	)
+
+	ids := func(query string) []int {
+		var ids []int
+		for _, transaction := range SearchTransactions(testDB, query, TransactionFilter{AccountIDs: []int{accountID}}) {
+			ids = append(ids, transaction.ID)
+		}
+		return ids
+	}
+
+	assert.ElementsMatch(t, []int{id, splitID}, ids("plokmijn uhbygvtf"))
+	assert.Equal(t, []int{splitID}, ids("wsxzaqed"))
+
+	// Renaming or moving a category changes the paths of its subcategories
+	_, _ = UpdateCategory(testDB, Category{ID: parentID, Name: "Rfvedcws"})
+	assert.Empty(t, ids("plokmijn"))
+	assert.ElementsMatch(t, []int{id, splitID}, ids("rfvedcws"))
+
+	newParentID, _ := AddCategory(testDB, Category{Name: "Tgbnhyuj"})
+	assert.Nil(t, MoveCategory(testDB, childID, newParentID))
+	assert.Empty(t, ids("rfvedcws"))
+	assert.ElementsMatch(t, []int{id, splitID}, ids("tgbnhyuj uhbygvtf"))
+
+	assert.Nil(t, SetTransactionSplits(testDB, splitID, []TransactionSplit{{CategoryID: childID, AmountInCents: -1000}}))
+	assert.Empty(t, ids("wsxzaqed"))
+}
+
+func TestMigrateDB_SearchIndex(t *testing.T) {
+	if !isFTS5Available(testDB) {
+		t.Skip("SQLite compiled without FTS5")
+	}
+
+	db, err := OpenDB(WithInMemory())
+	assert.Nil(t, err)
+	defer func(db *sql.DB) {
+		_ = db.Close()
+	}(db)
+	assert.Nil(t, MigrateDB(db))
+
+	payeeID, _ := AddPayee(db, Payee{Name: "TestMigrateDB_SearchIndex"})
+	accountID, _ := AddAccount(db, Account{Name: "TestMigrateDB_SearchIndex"})
+	parentID, _ := AddCategory(db, Category{Name: "Plokmijn"})
+	childID, _ := AddCategory(db, Category{Name: "Uhbygvtf", ParentID: parentID})
+	id, _ := AddTransaction(db, Transaction{PayeeID: payeeID, AccountID: accountID, CategoryID: childID})
+
+	// Index of the category names only
+	_, err = db.Exec(`
+		DROP TRIGGER tr_transactions_search_insert;
+		CREATE TRIGGER tr_transactions_search_insert AFTER INSERT ON transactions
+		BEGIN
+			INSERT INTO transactions_search (rowid, notes, payee, category)
+			VALUES (NEW.id,
+					NEW.notes,
+					(SELECT name FROM payees WHERE id = NEW.payee_id),
+					(SELECT name FROM categories WHERE id = NEW.category_id));
+		END;
+		UPDATE transactions_search SET category = 'Uhbygvtf';
+	`)
+	assert.Nil(t, err)
+	assert.Empty(t, SearchTransactions(db, "plokmijn", TransactionFilter{}))
+
+	assert.Nil(t, MigrateDB(db))
+	results := SearchTransactions(db, "plokmijn", TransactionFilter{})
+	assert.Len(t, results, 1)
+	assert.Equal(t, id, results[0].ID)
+
+	// Already migrated DBs are left as they are
+	assert.Nil(t, MigrateDB(db))
+	assert.Len(t, SearchTransactions(db, "plokmijn uhbygvtf", TransactionFilter{}), 1)
+}
@@ -0,0 +1,150 @@
+package ezex
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// TransactionSplit is a line of a transaction split across multiple categories,
+// the sum of the splits amounts must equal the transaction amount
+type TransactionSplit struct {
+	ID            int
+	TransactionID int
+	CategoryID    int
+	AmountInCents int64
+	Notes         sql.NullString
+}
+
+// ErrSplitAmountMismatch is returned when the splits amounts don't add up to the transaction amount
+var ErrSplitAmountMismatch = errors.New("splits amounts must add up to the transaction amount")
+
+// AddSplitTransaction creates a new transaction along with its splits and returns the new transaction ID if successful
+func AddSplitTransaction(db *sql.DB, transaction Transaction, splits []TransactionSplit) (int, error) {
+	return addSplitTransaction(db, transaction, splits)
+}
+
+func AddSplitTransactionContext(ctx context.Context, db *sql.DB, transaction Transaction, splits []TransactionSplit) (int, error) {
+	return addSplitTransaction(withContext(ctx, db), transaction, splits)
+}
+
+func addSplitTransaction(db dbExecutor, transaction Transaction, splits []TransactionSplit) (int, error) {
+	if err := validateSplits(transaction.AmountInCents, splits); err != nil {
+		return -1, err
+	}
+
+	id := -1
+	err := dbTransaction(db, func(tx dbExecutor) error {
+		var err error
+		if id, err = addTransaction(tx, transaction); err != nil {
+			return err
+		}
+
+		return addSplits(tx, id, splits)
+	})
+
+	return id, err
+}
+
+// SetTransactionSplits replaces the splits of a transaction, an empty list removes them.
+// Reconciled transactions can't be split again
+func SetTransactionSplits(db *sql.DB, transactionID int, splits []TransactionSplit) error {
+	return setTransactionSplits(db, transactionID, splits)
+}
+
+func SetTransactionSplitsContext(ctx context.Context, db *sql.DB, transactionID int, splits []TransactionSplit) error {
+	return setTransactionSplits(withContext(ctx, db), transactionID, splits)
+}
+
+func setTransactionSplits(db dbExecutor, transactionID int, splits []TransactionSplit) error {
+	return dbTransaction(db, func(tx dbExecutor) error {
+		amounts := dbGet[struct{ AmountInCents int64 }](
+			tx,
+			`SELECT amount_in_cents FROM transactions WHERE id = $id`,
+			transactionID,
+		)
+		if len(amounts) == 0 {
+			return fmt.Errorf("%w: transaction %d", ErrNotFound, transactionID)
+		}
+		if isTransactionReconciled(tx, transactionID) {
+			return ErrTransactionReconciled
+		}
+
+		if len(splits) > 0 {
+			if err := validateSplits(amounts[0].AmountInCents, splits); err != nil {
+				return err
+			}
+		}
+
+		if _, err := tx.Exec(`DELETE FROM transaction_splits WHERE transaction_id = $id`, transactionID); err != nil {
+			return err
+		}
+
+		return addSplits(tx, transactionID, splits)
+	})
+}
+
+// GetTransactionSplits returns the splits of a transaction, empty if it's not split
+func GetTransactionSplits(db *sql.DB, transactionID int) []TransactionSplit {
+	return getTransactionSplits(db, transactionID)
+}
+
+func GetTransactionSplitsContext(ctx context.Context, db *sql.DB, transactionID int) []TransactionSplit {
+	return getTransactionSplits(withContext(ctx, db), transactionID)
+}
+
+func getTransactionSplits(db dbExecutor, transactionID int) []TransactionSplit {
+	return dbGet[TransactionSplit](
+		db,
+		`
+		SELECT		id,
+					transaction_id,
+					category_id,
+					amount_in_cents,
+					notes
+		FROM		transaction_splits
+		WHERE		transaction_id = $transactionID
+		ORDER BY	id
+		`,
+		transactionID,
+	)
+}
+
+func addSplits(db dbExecutor, transactionID int, splits []TransactionSplit) error {
+	for _, split := range splits {
+		_, err := dbAdd(
+			db,
+			`
+			INSERT INTO transaction_splits	(transaction_id, category_id, amount_in_cents, notes)
+			VALUES							($transaction_id, $category_id, $amount_in_cents, $notes)
+			`,
+			transactionID,
+			split.CategoryID,
+			split.AmountInCents,
+			split.Notes,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validateSplits(amountInCents int64, splits []TransactionSplit) error {
+	if len(splits) == 0 {
+		return errors.New("a split transaction needs at least one split")
+	}
+
+	var sum int64
+	for _, split := range splits {
+		sum += split.AmountInCents
+	}
+
+	if sum != amountInCents {
+		return ErrSplitAmountMismatch
+	}
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package ezex
+
+import (
+	"database/sql"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestAddSplitTransaction(t *testing.T) {
+	payeeID, _ := AddPayee(testDB, Payee{Name: "TestAddSplitTransaction"})
+	accountID, _ := AddAccount(testDB, Account{Name: "TestAddSplitTransaction"})
+	groceriesID, _ := AddCategory(testDB, Category{Name: "TestAddSplitTransaction groceries"})
+	pharmacyID, _ := AddCategory(testDB, Category{Name: "TestAddSplitTransaction pharmacy"})
+
+	date := time.Date(2104, 1, 10, 0, 0, 0, 0, time.UTC)
+	id, err := AddSplitTransaction(
+		testDB,
+		Transaction{PayeeID: payeeID, AccountID: accountID, AmountInCents: -3000, TransactionDateUnix: date.Unix()},
+		[]TransactionSplit{
+			{CategoryID: groceriesID, AmountInCents: -2000},
+			{CategoryID: pharmacyID, AmountInCents: -1000, Notes: sql.NullString{String: "aspirin", Valid: true}},
+		},
+	)
+
+	assert.Nil(t, err)
+	assert.Greater(t, id, 0)
+
+	splits := GetTransactionSplits(testDB, id)
+	assert.Len(t, splits, 2)
+	assert.Equal(t, groceriesID, splits[0].CategoryID)
+	assert.Equal(t, "aspirin", splits[1].Notes.String)
+
+	transactions := GetTransactions(testDB, accountID, date, date.AddDate(0, 0, 1))
+	assert.Equal(t, 2, transactions[0].SplitCount)
+
+	totals := GetCategoryTotals(testDB, date, date.AddDate(0, 0, 1))
+	assert.Equal(t, []CategoryTotal{
+		{CategoryID: groceriesID, CategoryName: "TestAddSplitTransaction groceries", Path: "TestAddSplitTransaction groceries", AmountInCents: -2000},
+		{CategoryID: pharmacyID, CategoryName: "TestAddSplitTransaction pharmacy", Path: "TestAddSplitTransaction pharmacy", AmountInCents: -1000},
+	}, totals)
+
+	filtered := FilterTransactions(testDB, TransactionFilter{AccountIDs: []int{accountID}, CategoryIDs: []int{pharmacyID}})
+	assert.Len(t, filtered, 1)
+}
+
+func TestAddSplitTransaction_Mismatch(t *testing.T) {
+	id, err := AddSplitTransaction(
+		testDB,
+		Transaction{AmountInCents: -3000},
+		[]TransactionSplit{{AmountInCents: -2000}, {AmountInCents: -999}},
+	)
+
+	assert.Equal(t, -1, id)
+	assert.ErrorIs(t, err, ErrSplitAmountMismatch)
+}
+
+func TestSetTransactionSplits(t *testing.T) {
+	payeeID, _ := AddPayee(testDB, Payee{Name: "TestSetTransactionSplits"})
+	accountID, _ := AddAccount(testDB, Account{Name: "TestSetTransactionSplits"})
+	transaction := Transaction{PayeeID: payeeID, AccountID: accountID, AmountInCents: 500}
+	id, _ := AddTransaction(testDB, transaction)
+	transaction.ID = id
+
+	err := SetTransactionSplits(testDB, id, []TransactionSplit{{AmountInCents: 200}, {AmountInCents: 300}})
+	assert.Nil(t, err)
+	assert.Len(t, GetTransactionSplits(testDB, id), 2)
+
+	err = SetTransactionSplits(testDB, id, []TransactionSplit{{AmountInCents: 200}})
+	assert.ErrorIs(t, err, ErrSplitAmountMismatch)
+	assert.Len(t, GetTransactionSplits(testDB, id), 2)
+
+	// The amount can't change under the splits
+	transaction.AmountInCents = 600
+	_, err = UpdateTransaction(testDB, transaction)
+	assert.ErrorIs(t, err, ErrSplitAmountMismatch)
+	transaction.AmountInCents = 500
+	_, err = UpdateTransaction(testDB, transaction)
+	assert.Nil(t, err)
+
+	err = SetTransactionSplits(testDB, id, nil)
+	assert.Nil(t, err)
+	assert.Empty(t, GetTransactionSplits(testDB, id))
+
+	assert.Nil(t, Reconcile(testDB, accountID, []int{id}))
+	err = SetTransactionSplits(testDB, id, []TransactionSplit{{AmountInCents: 500}})
+	assert.ErrorIs(t, err, ErrTransactionReconciled)
+	assert.Empty(t, GetTransactionSplits(testDB, id))
+}
+
+func TestSetTransactionSplits_NoMatch(t *testing.T) {
+	err := SetTransactionSplits(testDB, -1, nil)
+	assert.Error(t, err)
+}
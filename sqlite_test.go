@@ -8,20 +8,18 @@ import (
 	"testing"
 )
 
-const testDBName = "./test-db.db"
+const testDBName = "test-db.db"
 
 var testDB *sql.DB
 
-func cleanTestDB() {
-	pwd, _ := os.Getwd()
-	_ = os.Remove(path.Join(pwd, testDBName))
-}
-
 func TestMain(m *testing.M) {
-	cleanTestDB()
+	// The DB is shared by the tests of the package, in a directory of its own so that parallel runs don't collide
+	dir, err := os.MkdirTemp("", "ez-ex-test-*")
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	var err error
-	testDB, err = sql.Open("sqlite3", testDBName)
+	testDB, err = sql.Open("sqlite3", path.Join(dir, testDBName))
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -37,7 +35,7 @@ func TestMain(m *testing.M) {
 			log.Fatal(err)
 		}
 
-		cleanTestDB()
+		_ = os.RemoveAll(dir)
 		os.Exit(code)
 	}(testDB, code)
 }
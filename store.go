@@ -0,0 +1,139 @@
+package ezex
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Store persists accounts, transactions, payees and categories. Methods behave like the functions of the same name
+// (e.g. Store.AddAccount like AddAccount), SQLiteStore saves into a SQLite DB and MemoryStore keeps everything in memory.
+// It covers the core entities only: splits, tags, rules, reports and the other features keep taking a *sql.DB, the TUI
+// uses a Store for the changes saved in a single unit of work (e.g. deleting a transaction and adjusting the balance)
+type Store interface {
+	AddAccount(account Account) (int, error)
+	UpdateAccount(account Account) (int, error)
+	DeleteAccount(id int) (int, error)
+	UpdateAccountBalance(accountID int, amountInCents int64) (int, error)
+	GetAccounts() []Account
+	GetAccount(id int) (Account, error)
+
+	AddTransaction(transaction Transaction) (int, error)
+	UpdateTransaction(transaction Transaction) (int, error)
+	DeleteTransaction(id int) (int, error)
+	UndeleteTransaction(id int) (int, error)
+	// FilterTransactions returns the transactions matching the filter, most recent first. Splits, tags and attachments
+	// are only supported by SQLiteStore
+	FilterTransactions(filter TransactionFilter) []TransactionView
+
+	AddPayee(payee Payee) (int, error)
+	UpdatePayee(payee Payee) (int, error)
+	DeletePayee(id int) (int, error)
+	GetPayees() []Payee
+
+	AddCategory(category Category) (int, error)
+	UpdateCategory(category Category) (int, error)
+	DeleteCategory(id int) (int, error)
+	GetCategories() []Category
+
+	// UnitOfWork runs fn with a Store whose changes are saved only if fn succeeds, all of them or none.
+	// fn must only use the Store it's given, units of work started by it are part of the outer one
+	UnitOfWork(fn func(s Store) error) error
+}
+
+// SQLiteStore is a Store saving into a SQLite DB (see OpenDB), which must be migrated (see MigrateDB)
+type SQLiteStore struct {
+	db dbExecutor
+}
+
+// NewSQLiteStore returns a Store saving into db
+func NewSQLiteStore(db *sql.DB) *SQLiteStore {
+	return &SQLiteStore{db: db}
+}
+
+// NewSQLiteStoreContext returns a Store saving into db, running its queries with ctx
+func NewSQLiteStoreContext(ctx context.Context, db *sql.DB) *SQLiteStore {
+	return &SQLiteStore{db: withContext(ctx, db)}
+}
+
+func (s *SQLiteStore) AddAccount(account Account) (int, error) {
+	return addAccount(s.db, account)
+}
+
+func (s *SQLiteStore) UpdateAccount(account Account) (int, error) {
+	return updateAccount(s.db, account)
+}
+
+func (s *SQLiteStore) DeleteAccount(id int) (int, error) {
+	return deleteAccount(s.db, id)
+}
+
+func (s *SQLiteStore) UpdateAccountBalance(accountID int, amountInCents int64) (int, error) {
+	return updateAccountBalance(s.db, accountID, amountInCents)
+}
+
+func (s *SQLiteStore) GetAccounts() []Account {
+	return getAccounts(s.db)
+}
+
+func (s *SQLiteStore) GetAccount(id int) (Account, error) {
+	return getAccount(s.db, id)
+}
+
+func (s *SQLiteStore) AddTransaction(transaction Transaction) (int, error) {
+	return addTransaction(s.db, transaction)
+}
+
+func (s *SQLiteStore) UpdateTransaction(transaction Transaction) (int, error) {
+	return updateTransaction(s.db, transaction)
+}
+
+func (s *SQLiteStore) DeleteTransaction(id int) (int, error) {
+	return deleteTransaction(s.db, id)
+}
+
+func (s *SQLiteStore) UndeleteTransaction(id int) (int, error) {
+	return undeleteTransaction(s.db, id)
+}
+
+func (s *SQLiteStore) FilterTransactions(filter TransactionFilter) []TransactionView {
+	return filterTransactions(s.db, filter)
+}
+
+func (s *SQLiteStore) AddPayee(payee Payee) (int, error) {
+	return addPayee(s.db, payee)
+}
+
+func (s *SQLiteStore) UpdatePayee(payee Payee) (int, error) {
+	return updatePayee(s.db, payee)
+}
+
+func (s *SQLiteStore) DeletePayee(id int) (int, error) {
+	return deletePayee(s.db, id)
+}
+
+func (s *SQLiteStore) GetPayees() []Payee {
+	return getPayees(s.db)
+}
+
+func (s *SQLiteStore) AddCategory(category Category) (int, error) {
+	return addCategory(s.db, category)
+}
+
+func (s *SQLiteStore) UpdateCategory(category Category) (int, error) {
+	return updateCategory(s.db, category)
+}
+
+func (s *SQLiteStore) DeleteCategory(id int) (int, error) {
+	return deleteCategory(s.db, id)
+}
+
+func (s *SQLiteStore) GetCategories() []Category {
+	return getCategories(s.db)
+}
+
+// UnitOfWork runs fn inside a DB transaction
+func (s *SQLiteStore) UnitOfWork(fn func(s Store) error) error {
+	return dbTransaction(s.db, func(tx dbExecutor) error {
+		return fn(&SQLiteStore{db: tx})
+	})
+}
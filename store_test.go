@@ -0,0 +1,337 @@
+package ezex
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"path"
+	"testing"
+	"time"
+)
+
+func TestSQLiteStore(t *testing.T) {
+	testStore(t, func(t *testing.T) Store {
+		db, err := OpenDB(WithDBPath(path.Join(t.TempDir(), "store-test.db")))
+		assert.Nil(t, err)
+		assert.Nil(t, MigrateDB(db))
+		t.Cleanup(func() {
+			_ = db.Close()
+		})
+
+		return NewSQLiteStore(db)
+	})
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, func(t *testing.T) Store {
+		return NewMemoryStore()
+	})
+}
+
+// testStore is the conformance suite every Store must pass, newStore returns an empty Store
+func testStore(t *testing.T, newStore func(t *testing.T) Store) {
+	t.Run("Accounts", func(t *testing.T) {
+		s := newStore(t)
+		assert.Len(t, s.GetAccounts(), 0)
+
+		checkingID, err := s.AddAccount(Account{Name: "Checking", InitialBalanceInCents: 1000, BalanceInCents: 1000})
+		assert.Nil(t, err)
+		savingsID, err := s.AddAccount(Account{Name: "Savings"})
+		assert.Nil(t, err)
+		_, err = s.AddAccount(Account{Name: "Checking"})
+		assert.ErrorIs(t, err, ErrDuplicateName)
+
+		assert.Equal(t, []Account{
+			{ID: savingsID, Name: "Savings"},
+			{ID: checkingID, Name: "Checking", InitialBalanceInCents: 1000, BalanceInCents: 1000},
+		}, s.GetAccounts())
+
+		n, err := s.UpdateAccount(Account{
+			ID:          checkingID,
+			Name:        "Main",
+			Description: sql.NullString{String: "Salary", Valid: true},
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, n)
+		_, err = s.UpdateAccount(Account{ID: checkingID, Name: "Savings"})
+		assert.ErrorIs(t, err, ErrDuplicateName)
+		n, err = s.UpdateAccount(Account{ID: savingsID + 100, Name: "Missing"})
+		assert.Equal(t, 0, n)
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		account, err := s.GetAccount(checkingID)
+		assert.Nil(t, err)
+		assert.Equal(t, Account{ID: checkingID, Name: "Main", Description: sql.NullString{String: "Salary", Valid: true}}, account)
+		_, err = s.GetAccount(savingsID + 100)
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		// Deleted accounts are hidden but can still be read
+		n, err = s.DeleteAccount(savingsID)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, n)
+		assert.Equal(t, []Account{account}, s.GetAccounts())
+		_, err = s.GetAccount(savingsID)
+		assert.Nil(t, err)
+		n, err = s.DeleteAccount(savingsID + 100)
+		assert.Equal(t, 0, n)
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		// The amount is subtracted from the balance, like UpdateAccountBalance
+		n, err = s.UpdateAccountBalance(checkingID, -250)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, n)
+		account, _ = s.GetAccount(checkingID)
+		assert.Equal(t, int64(250), account.BalanceInCents)
+		n, err = s.UpdateAccountBalance(savingsID+100, -250)
+		assert.Equal(t, 0, n)
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("Payees", func(t *testing.T) {
+		s := newStore(t)
+		accountID, _ := s.AddAccount(Account{Name: "Checking"})
+
+		grocerID, err := s.AddPayee(Payee{Name: "Grocer"})
+		assert.Nil(t, err)
+		bakerID, err := s.AddPayee(Payee{Name: "Baker", Description: sql.NullString{String: "Bread", Valid: true}})
+		assert.Nil(t, err)
+		_, err = s.AddPayee(Payee{Name: "Grocer"})
+		assert.ErrorIs(t, err, ErrDuplicateName)
+
+		n, err := s.UpdatePayee(Payee{ID: grocerID, Name: "Greengrocer"})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, n)
+		_, err = s.UpdatePayee(Payee{ID: grocerID, Name: "Baker"})
+		assert.ErrorIs(t, err, ErrDuplicateName)
+		assert.Equal(t, []Payee{
+			{ID: bakerID, Name: "Baker", Description: sql.NullString{String: "Bread", Valid: true}},
+			{ID: grocerID, Name: "Greengrocer"},
+		}, s.GetPayees())
+
+		// Payees in use can't be deleted
+		_, err = s.AddTransaction(Transaction{AccountID: accountID, PayeeID: grocerID, AmountInCents: -100})
+		assert.Nil(t, err)
+		n, err = s.DeletePayee(grocerID)
+		assert.Equal(t, 0, n)
+		assert.ErrorIs(t, err, ErrInUse)
+		n, err = s.DeletePayee(bakerID)
+		assert.Equal(t, 1, n)
+		assert.Nil(t, err)
+		n, err = s.DeletePayee(bakerID)
+		assert.Equal(t, 0, n)
+		assert.ErrorIs(t, err, ErrNotFound)
+		_, err = s.UpdatePayee(Payee{ID: bakerID, Name: "Baker"})
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.Equal(t, []Payee{{ID: grocerID, Name: "Greengrocer"}}, s.GetPayees())
+	})
+
+	t.Run("Categories", func(t *testing.T) {
+		s := newStore(t)
+		assert.Equal(t, []Category{{ID: 0, Name: "no category", Path: "no category"}}, s.GetCategories())
+
+		carID, err := s.AddCategory(Category{Name: "Car"})
+		assert.Nil(t, err)
+		fuelID, err := s.AddCategory(Category{Name: "Fuel", ParentID: carID})
+		assert.Nil(t, err)
+		_, err = s.AddCategory(Category{Name: "Fuel", ParentID: carID})
+		assert.ErrorIs(t, err, ErrDuplicateName)
+		_, err = s.AddCategory(Category{Name: "Car:Fuel"})
+		assert.ErrorIs(t, err, ErrInvalidCategoryName)
+		_, err = s.AddCategory(Category{Name: "Orphan", ParentID: fuelID + 100})
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		assert.Equal(t, []Category{
+			{ID: fuelID, Name: "Fuel", ParentID: carID, Path: "Car:Fuel", Depth: 1},
+			{ID: carID, Name: "Car", Path: "Car"},
+			{ID: 0, Name: "no category", Path: "no category"},
+		}, s.GetCategories())
+
+		n, err := s.UpdateCategory(Category{ID: carID, Name: "Vehicle"})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, n)
+		n, err = s.UpdateCategory(Category{ID: 0, Name: "Renamed"})
+		assert.Equal(t, 0, n)
+		assert.ErrorIs(t, err, ErrProtectedCategory)
+		_, err = s.UpdateCategory(Category{ID: carID, Name: ""})
+		assert.ErrorIs(t, err, ErrInvalidCategoryName)
+		_, err = s.UpdateCategory(Category{ID: fuelID + 100, Name: "Missing"})
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		// Subcategories move to the parent, transactions to "no category"
+		accountID, _ := s.AddAccount(Account{Name: "Checking"})
+		payeeID, _ := s.AddPayee(Payee{Name: "Garage"})
+		_, _ = s.AddTransaction(Transaction{AccountID: accountID, PayeeID: payeeID, CategoryID: carID, AmountInCents: -100})
+		n, err = s.DeleteCategory(0)
+		assert.Equal(t, 0, n)
+		assert.ErrorIs(t, err, ErrProtectedCategory)
+		n, err = s.DeleteCategory(carID)
+		assert.Equal(t, 1, n)
+		assert.Nil(t, err)
+		assert.Equal(t, []Category{
+			{ID: fuelID, Name: "Fuel", Path: "Fuel"},
+			{ID: 0, Name: "no category", Path: "no category"},
+		}, s.GetCategories())
+		assert.Equal(t, 0, s.FilterTransactions(TransactionFilter{})[0].CategoryID)
+		_, err = s.DeleteCategory(carID)
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("Transactions", func(t *testing.T) {
+		s := newStore(t)
+		accountID, _ := s.AddAccount(Account{Name: "Checking"})
+		payeeID, _ := s.AddPayee(Payee{Name: "Grocer"})
+		categoryID, _ := s.AddCategory(Category{Name: "Groceries"})
+
+		_, err := s.AddTransaction(Transaction{AccountID: accountID + 100, PayeeID: payeeID})
+		assert.ErrorIs(t, err, ErrNotFound)
+		_, err = s.AddTransaction(Transaction{AccountID: accountID, PayeeID: payeeID + 100})
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		date := time.Date(2109, 1, 10, 0, 0, 0, 0, time.UTC).Unix()
+		id, err := s.AddTransaction(Transaction{
+			AccountID:           accountID,
+			PayeeID:             payeeID,
+			CategoryID:          categoryID,
+			AmountInCents:       -1250,
+			TransactionDateUnix: date,
+			Notes:               sql.NullString{String: "Weekly shopping", Valid: true},
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, []TransactionView{{
+			ID:                  id,
+			CategoryID:          categoryID,
+			PayeeID:             payeeID,
+			AccountID:           accountID,
+			AmountInCents:       -1250,
+			TransactionDateUnix: date,
+			Notes:               sql.NullString{String: "Weekly shopping", Valid: true},
+			CategoryName:        "Groceries",
+			PayeeName:           "Grocer",
+			AccountName:         "Checking",
+		}}, s.FilterTransactions(TransactionFilter{}))
+
+		n, err := s.UpdateTransaction(Transaction{
+			ID:                  id,
+			AccountID:           accountID,
+			PayeeID:             payeeID,
+			AmountInCents:       -1500,
+			TransactionDateUnix: date,
+			// The status can't be updated
+			Status: StatusReconciled,
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, n)
+		view := s.FilterTransactions(TransactionFilter{})[0]
+		assert.Equal(t, int64(-1500), view.AmountInCents)
+		assert.Equal(t, 0, view.CategoryID)
+		assert.Equal(t, StatusUncommitted, view.Status)
+
+		reconciledID, _ := s.AddTransaction(Transaction{AccountID: accountID, PayeeID: payeeID, Status: StatusReconciled})
+		_, err = s.UpdateTransaction(Transaction{ID: reconciledID, AccountID: accountID, PayeeID: payeeID})
+		assert.ErrorIs(t, err, ErrTransactionReconciled)
+		_, err = s.DeleteTransaction(reconciledID)
+		assert.ErrorIs(t, err, ErrTransactionReconciled)
+
+		n, err = s.DeleteTransaction(id)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, n)
+		assert.Len(t, s.FilterTransactions(TransactionFilter{}), 1)
+		assert.Len(t, s.FilterTransactions(TransactionFilter{IncludeDeleted: true}), 2)
+
+		n, err = s.UndeleteTransaction(id)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, n)
+		assert.Len(t, s.FilterTransactions(TransactionFilter{}), 2)
+
+		n, err = s.UpdateTransaction(Transaction{ID: reconciledID + 100, AccountID: accountID, PayeeID: payeeID})
+		assert.Equal(t, 0, n)
+		assert.ErrorIs(t, err, ErrNotFound)
+		n, err = s.DeleteTransaction(reconciledID + 100)
+		assert.Equal(t, 0, n)
+		assert.ErrorIs(t, err, ErrNotFound)
+		n, err = s.UndeleteTransaction(reconciledID + 100)
+		assert.Equal(t, 0, n)
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("FilterTransactions", func(t *testing.T) {
+		s := newStore(t)
+		checkingID, _ := s.AddAccount(Account{Name: "Checking"})
+		savingsID, _ := s.AddAccount(Account{Name: "Savings"})
+		grocerID, _ := s.AddPayee(Payee{Name: "Grocer"})
+		employerID, _ := s.AddPayee(Payee{Name: "Employer"})
+		carID, _ := s.AddCategory(Category{Name: "Car"})
+		fuelID, _ := s.AddCategory(Category{Name: "Fuel", ParentID: carID})
+
+		day := func(d int) int64 {
+			return time.Date(2109, 2, d, 0, 0, 0, 0, time.UTC).Unix()
+		}
+		add := func(transaction Transaction) int {
+			id, err := s.AddTransaction(transaction)
+			assert.Nil(t, err)
+			return id
+		}
+		fuel := add(Transaction{AccountID: checkingID, PayeeID: grocerID, CategoryID: fuelID, AmountInCents: -4000, TransactionDateUnix: day(3)})
+		salary := add(Transaction{AccountID: checkingID, PayeeID: employerID, AmountInCents: 200000, TransactionDateUnix: day(1), Status: StatusCleared})
+		food := add(Transaction{AccountID: checkingID, PayeeID: grocerID, AmountInCents: -2500, TransactionDateUnix: day(2), Notes: sql.NullString{String: "Weekly shopping", Valid: true}})
+		// Same date, newest ID first
+		bonus := add(Transaction{AccountID: savingsID, PayeeID: employerID, AmountInCents: 50000, TransactionDateUnix: day(2)})
+
+		ids := func(filter TransactionFilter) []int {
+			var ids []int
+			for _, transaction := range s.FilterTransactions(filter) {
+				ids = append(ids, transaction.ID)
+			}
+			return ids
+		}
+		assert.Equal(t, []int{fuel, bonus, food, salary}, ids(TransactionFilter{}))
+		assert.Equal(t, []int{fuel, food, salary}, ids(TransactionFilter{AccountIDs: []int{checkingID}}))
+		assert.Equal(t, []int{fuel}, ids(TransactionFilter{CategoryIDs: []int{carID}}))
+		assert.Equal(t, []int{bonus, food, salary}, ids(TransactionFilter{CategoryIDs: []int{0}}))
+		assert.Equal(t, []int{bonus, salary}, ids(TransactionFilter{PayeeIDs: []int{employerID}}))
+		assert.Equal(t, []int{salary}, ids(TransactionFilter{Statuses: []TransactionStatus{StatusCleared}}))
+		assert.Equal(t, []int{fuel, food}, ids(TransactionFilter{Sign: ExpenseAmount}))
+		assert.Equal(t, []int{bonus, salary}, ids(TransactionFilter{MinAmountInCents: sql.NullInt64{Int64: 0, Valid: true}}))
+		assert.Equal(t, []int{fuel}, ids(TransactionFilter{MaxAmountInCents: sql.NullInt64{Int64: -3000, Valid: true}}))
+		assert.Equal(t, []int{bonus, food}, ids(TransactionFilter{MinDate: time.Unix(day(2), 0), MaxDate: time.Unix(day(3), 0)}))
+		assert.Equal(t, []int{food}, ids(TransactionFilter{Text: "weekly grocer"}))
+		assert.Equal(t, []int{fuel}, ids(TransactionFilter{Text: "fuel"}))
+
+		_, _ = s.DeleteAccount(savingsID)
+		assert.Equal(t, []int{fuel, food, salary}, ids(TransactionFilter{}))
+		assert.Equal(t, []int{fuel, bonus, food, salary}, ids(TransactionFilter{IncludeDeleted: true}))
+	})
+
+	t.Run("UnitOfWork", func(t *testing.T) {
+		s := newStore(t)
+		payeeID, _ := s.AddPayee(Payee{Name: "Grocer"})
+
+		err := s.UnitOfWork(func(tx Store) error {
+			accountID, err := tx.AddAccount(Account{Name: "Checking"})
+			if err != nil {
+				return err
+			}
+
+			// Nested units of work are part of the outer one
+			return tx.UnitOfWork(func(tx Store) error {
+				_, err := tx.AddTransaction(Transaction{AccountID: accountID, PayeeID: payeeID, AmountInCents: -100})
+				return err
+			})
+		})
+		assert.Nil(t, err)
+		assert.Len(t, s.GetAccounts(), 1)
+		assert.Len(t, s.FilterTransactions(TransactionFilter{}), 1)
+
+		errRollback := errors.New("rollback")
+		err = s.UnitOfWork(func(tx Store) error {
+			accountID, _ := tx.AddAccount(Account{Name: "Savings"})
+			_, _ = tx.AddTransaction(Transaction{AccountID: accountID, PayeeID: payeeID, AmountInCents: 100})
+			assert.Len(t, tx.GetAccounts(), 2)
+
+			return errRollback
+		})
+		assert.ErrorIs(t, err, errRollback)
+		assert.Len(t, s.GetAccounts(), 1)
+		assert.Len(t, s.FilterTransactions(TransactionFilter{}), 1)
+	})
+}
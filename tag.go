@@ -0,0 +1,225 @@
+package ezex
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// Tag is a free-form label of transactions (e.g. `#vacation2026`), names are stored without `#`
+type Tag struct {
+	ID   int
+	Name string
+}
+
+// ErrInvalidTagName is returned when a tag name is empty or contains spaces or commas
+var ErrInvalidTagName = errors.New("tag names can't be empty or contain spaces or commas")
+
+func (t Tag) GetName() string {
+	return t.Name
+}
+
+// ParseTags splits a list of tags separated by spaces or commas (e.g. `#vacation2026, #reimbursable`),
+// removing the leading `#` and duplicates (case-insensitive)
+func ParseTags(value string) []string {
+	fields := strings.FieldsFunc(value, func(r rune) bool {
+		return unicode.IsSpace(r) || r == ','
+	})
+
+	var names []string
+	seen := make(map[string]struct{}, len(fields))
+	for _, field := range fields {
+		name := strings.TrimLeft(field, "#")
+		key := strings.ToLower(name)
+		if _, ok := seen[key]; ok || name == "" {
+			continue
+		}
+
+		seen[key] = struct{}{}
+		names = append(names, name)
+	}
+
+	return names
+}
+
+// FormatTags prefixes each of the space separated tag names (see TransactionView.Tags) with `#`
+func FormatTags(tags string) string {
+	names := strings.Fields(tags)
+	for i, name := range names {
+		names[i] = "#" + name
+	}
+
+	return strings.Join(names, " ")
+}
+
+// AddTag creates a new tag and returns the new tag ID if successful
+func AddTag(db *sql.DB, tag Tag) (int, error) {
+	return addTag(db, tag)
+}
+
+func AddTagContext(ctx context.Context, db *sql.DB, tag Tag) (int, error) {
+	return addTag(withContext(ctx, db), tag)
+}
+
+func addTag(db dbExecutor, tag Tag) (int, error) {
+	if !isValidTagName(tag.Name) {
+		return -1, ErrInvalidTagName
+	}
+
+	return dbAdd(db, `INSERT INTO tags (name) VALUES ($name)`, tag.Name)
+}
+
+// DeleteTag deletes a tag, removing it from its transactions, returns the number of affected rows,
+// ErrNotFound if it doesn't exist
+func DeleteTag(db *sql.DB, id int) (int, error) {
+	return deleteTag(db, id)
+}
+
+func DeleteTagContext(ctx context.Context, db *sql.DB, id int) (int, error) {
+	return deleteTag(withContext(ctx, db), id)
+}
+
+func deleteTag(db dbExecutor, id int) (int, error) {
+	n := 0
+	err := dbTransaction(db, func(tx dbExecutor) error {
+		if _, err := tx.Exec(`DELETE FROM transaction_tags WHERE tag_id = $id`, id); err != nil {
+			return err
+		}
+
+		var err error
+		n, err = dbDelete(tx, `DELETE FROM tags WHERE id = $id`, id)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
+
+// GetTags returns every tag sorted by name
+func GetTags(db *sql.DB) []Tag {
+	return getTags(db)
+}
+
+func GetTagsContext(ctx context.Context, db *sql.DB) []Tag {
+	return getTags(withContext(ctx, db))
+}
+
+func getTags(db dbExecutor) []Tag {
+	return dbGet[Tag](db, `SELECT id, name FROM tags ORDER BY name COLLATE NOCASE`)
+}
+
+// GetTransactionTags returns the tags of a transaction sorted by name
+func GetTransactionTags(db *sql.DB, transactionID int) []Tag {
+	return getTransactionTags(db, transactionID)
+}
+
+func GetTransactionTagsContext(ctx context.Context, db *sql.DB, transactionID int) []Tag {
+	return getTransactionTags(withContext(ctx, db), transactionID)
+}
+
+func getTransactionTags(db dbExecutor, transactionID int) []Tag {
+	return dbGet[Tag](
+		db,
+		`
+		SELECT		tg.id,
+					tg.name
+		FROM		transaction_tags tt
+		JOIN		tags tg
+		ON			tg.id = tt.tag_id
+		WHERE		tt.transaction_id = $transactionID
+		ORDER BY	tg.name COLLATE NOCASE
+		`,
+		transactionID,
+	)
+}
+
+// TagTransaction adds the tags (by name, matched case-insensitively) to a transaction, creating the new ones.
+// Reconciled transactions can't be tagged
+func TagTransaction(db *sql.DB, transactionID int, names ...string) error {
+	return dbTransaction(db, func(tx dbExecutor) error {
+		return tagTransaction(tx, transactionID, names)
+	})
+}
+
+func TagTransactionContext(ctx context.Context, db *sql.DB, transactionID int, names ...string) error {
+	return dbTransaction(withContext(ctx, db), func(tx dbExecutor) error {
+		return tagTransaction(tx, transactionID, names)
+	})
+}
+
+// UntagTransaction removes the tags (by name, matched case-insensitively) from a transaction,
+// reconciled transactions can't be untagged
+func UntagTransaction(db *sql.DB, transactionID int, names ...string) error {
+	return untagTransaction(db, transactionID, names...)
+}
+
+func UntagTransactionContext(ctx context.Context, db *sql.DB, transactionID int, names ...string) error {
+	return untagTransaction(withContext(ctx, db), transactionID, names...)
+}
+
+func untagTransaction(db dbExecutor, transactionID int, names ...string) error {
+	return dbTransaction(db, func(tx dbExecutor) error {
+		if isTransactionReconciled(tx, transactionID) {
+			return ErrTransactionReconciled
+		}
+
+		for _, name := range names {
+			_, err := tx.Exec(
+				`
+				DELETE FROM transaction_tags
+				WHERE		transaction_id = $transactionID
+						AND tag_id = (SELECT id FROM tags WHERE name = $name)
+				`,
+				transactionID,
+				name,
+			)
+			if err != nil {
+				return dbError(err, ErrNotFound)
+			}
+		}
+
+		return nil
+	})
+}
+
+func tagTransaction(db dbExecutor, transactionID int, names []string) error {
+	if isTransactionReconciled(db, transactionID) {
+		return ErrTransactionReconciled
+	}
+
+	for _, name := range names {
+		if !isValidTagName(name) {
+			return ErrInvalidTagName
+		}
+
+		if _, err := db.Exec(`INSERT OR IGNORE INTO tags (name) VALUES ($name)`, name); err != nil {
+			return dbError(err, ErrNotFound)
+		}
+
+		_, err := db.Exec(
+			`
+			INSERT OR IGNORE INTO transaction_tags	(transaction_id, tag_id)
+			SELECT									$transactionID, id
+			FROM									tags
+			WHERE									name = $name
+			`,
+			transactionID,
+			name,
+		)
+		if err != nil {
+			return dbError(err, ErrNotFound)
+		}
+	}
+
+	return nil
+}
+
+func isValidTagName(name string) bool {
+	return name != "" && !strings.ContainsFunc(name, func(r rune) bool {
+		return unicode.IsSpace(r) || r == ','
+	})
+}
@@ -0,0 +1,112 @@
+package ezex
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestParseTags(t *testing.T) {
+	assert.Equal(t, []string{"vacation2026", "reimbursable"}, ParseTags(" #vacation2026,#reimbursable  #Vacation2026 # "))
+	assert.Empty(t, ParseTags(""))
+}
+
+func TestAddTag(t *testing.T) {
+	id, err := AddTag(testDB, Tag{Name: "TestAddTag"})
+	assert.Nil(t, err)
+	assert.Greater(t, id, 0)
+
+	// Names are case-insensitive
+	_, err = AddTag(testDB, Tag{Name: "testaddtag"})
+	assert.Error(t, err)
+
+	_, err = AddTag(testDB, Tag{Name: "TestAddTag invalid"})
+	assert.ErrorIs(t, err, ErrInvalidTagName)
+}
+
+func TestTagTransaction(t *testing.T) {
+	payeeID, _ := AddPayee(testDB, Payee{Name: "TestTagTransaction"})
+	accountID, _ := AddAccount(testDB, Account{Name: "TestTagTransaction"})
+	id, _ := AddTransaction(testDB, Transaction{PayeeID: payeeID, AccountID: accountID, AmountInCents: -100})
+	otherID, _ := AddTransaction(testDB, Transaction{PayeeID: payeeID, AccountID: accountID, AmountInCents: -200, TransactionDateUnix: 1})
+
+	assert.Nil(t, TagTransaction(testDB, id, "TestTagTransaction_b", "TestTagTransaction_a"))
+	// Existing tags and links are reused
+	assert.Nil(t, TagTransaction(testDB, id, "testtagtransaction_a"))
+	assert.Nil(t, TagTransaction(testDB, otherID, "TestTagTransaction_b"))
+	assert.ErrorIs(t, TagTransaction(testDB, id, ""), ErrInvalidTagName)
+
+	tags := GetTransactionTags(testDB, id)
+	assert.Len(t, tags, 2)
+	assert.Equal(t, "TestTagTransaction_a", tags[0].Name)
+
+	transactions := FilterTransactions(testDB, TransactionFilter{AccountIDs: []int{accountID}})
+	assert.Equal(t, "TestTagTransaction_b", transactions[0].Tags)
+	assert.Equal(t, "TestTagTransaction_a TestTagTransaction_b", transactions[1].Tags)
+
+	filtered := FilterTransactions(testDB, TransactionFilter{AccountIDs: []int{accountID}, TagIDs: []int{tags[0].ID}})
+	assert.Len(t, filtered, 1)
+	assert.Equal(t, id, filtered[0].ID)
+
+	assert.Nil(t, UntagTransaction(testDB, id, "TestTagTransaction_a"))
+	assert.Len(t, GetTransactionTags(testDB, id), 1)
+	// Reconciled transactions are locked
+	assert.Nil(t, Reconcile(testDB, accountID, []int{otherID}))
+	assert.ErrorIs(t, TagTransaction(testDB, otherID, "TestTagTransaction_a"), ErrTransactionReconciled)
+	assert.ErrorIs(t, UntagTransaction(testDB, otherID, "TestTagTransaction_b"), ErrTransactionReconciled)
+	assert.Len(t, GetTransactionTags(testDB, otherID), 1)
+}
+
+func TestDeleteTag(t *testing.T) {
+	payeeID, _ := AddPayee(testDB, Payee{Name: "TestDeleteTag"})
+	accountID, _ := AddAccount(testDB, Account{Name: "TestDeleteTag"})
+	id, _ := AddTransaction(testDB, Transaction{PayeeID: payeeID, AccountID: accountID})
+	_ = TagTransaction(testDB, id, "TestDeleteTag")
+
+	tags := GetTransactionTags(testDB, id)
+	n, err := DeleteTag(testDB, tags[0].ID)
+
+	assert.Equal(t, 1, n)
+	assert.Nil(t, err)
+	assert.Empty(t, GetTransactionTags(testDB, id))
+
+	_, err = DeleteTag(testDB, tags[0].ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestGetTagTotals(t *testing.T) {
+	payeeID, _ := AddPayee(testDB, Payee{Name: "TestGetTagTotals"})
+	accountID, _ := AddAccount(testDB, Account{Name: "TestGetTagTotals"})
+	tripID, _ := AddTag(testDB, Tag{Name: "TestGetTagTotals_trip"})
+	refundID, _ := AddTag(testDB, Tag{Name: "TestGetTagTotals_refund"})
+
+	date := time.Date(2106, 1, 10, 0, 0, 0, 0, time.UTC)
+	add := func(amount int64, tags ...string) {
+		id, _ := AddTransaction(testDB, Transaction{PayeeID: payeeID, AccountID: accountID, AmountInCents: amount, TransactionDateUnix: date.Unix()})
+		_ = TagTransaction(testDB, id, tags...)
+	}
+	add(-1000, "TestGetTagTotals_trip")
+	add(-500, "TestGetTagTotals_trip", "TestGetTagTotals_refund")
+	add(300, "TestGetTagTotals_refund")
+	add(-50)
+
+	totals := GetTagTotals(testDB, date, date.AddDate(0, 0, 1))
+
+	assert.Equal(t, []TagTotal{
+		{TagID: tripID, TagName: "TestGetTagTotals_trip", AmountInCents: -1500},
+		{TagID: refundID, TagName: "TestGetTagTotals_refund", AmountInCents: -200},
+	}, totals)
+}
+
+func TestFormatTags(t *testing.T) {
+	assert.Equal(t, "#a #b", FormatTags("a b"))
+	assert.Equal(t, "", FormatTags(""))
+}
+
+func TestTagTransaction_NotFound(t *testing.T) {
+	// Foreign keys are enabled by OpenDB
+	db := openAuditTestDB(t)
+
+	assert.ErrorIs(t, TagTransaction(db, 1, "TestTagTransaction_NotFound"), ErrNotFound)
+	assert.ErrorIs(t, DismissDuplicate(db, 1, 2), ErrNotFound)
+}
@@ -1,10 +1,38 @@
 package ezex
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"time"
 )
 
+// TransactionStatus tracks whether the bank confirmed a transaction
+type TransactionStatus int
+
+const (
+	// StatusUncommitted transactions aren't confirmed by the bank yet
+	StatusUncommitted TransactionStatus = iota
+	// StatusCleared transactions are confirmed by the bank
+	StatusCleared
+	// StatusReconciled transactions are matched against a bank statement (see Reconcile) and can't be changed
+	StatusReconciled
+)
+
+// ErrTransactionReconciled is returned when changing or deleting a reconciled transaction
+var ErrTransactionReconciled = errors.New("reconciled transactions can't be changed")
+
+func (s TransactionStatus) String() string {
+	switch s {
+	case StatusCleared:
+		return "cleared"
+	case StatusReconciled:
+		return "reconciled"
+	}
+
+	return "uncommitted"
+}
+
 type Transaction struct {
 	ID                  int
 	CategoryID          int
@@ -15,8 +43,11 @@ type Transaction struct {
 	UpdateDateUnix      sql.NullInt64
 	DeleteDateUnix      sql.NullInt64
 	Notes               sql.NullString
+	// Status is only set on creation, see SetTransactionStatus and Reconcile
+	Status TransactionStatus
 }
 
+// TransactionView is a transaction along with its category path (see Category.Path), payee and account names
 type TransactionView struct {
 	ID                  int
 	CategoryID          int
@@ -30,14 +61,29 @@ type TransactionView struct {
 	CategoryName        string
 	PayeeName           string
 	AccountName         string
+	// SplitCount is the number of splits of the transaction (see TransactionSplit), 0 if not split
+	SplitCount int
+	// Tags are the transaction tag names (see Tag), sorted and separated by spaces
+	Tags string
+	// AttachmentCount is the number of files attached to the transaction (see Attachment)
+	AttachmentCount int
+	Status          TransactionStatus
 }
 
 func AddTransaction(db *sql.DB, transaction Transaction) (int, error) {
+	return addTransaction(db, transaction)
+}
+
+func AddTransactionContext(ctx context.Context, db *sql.DB, transaction Transaction) (int, error) {
+	return addTransaction(withContext(ctx, db), transaction)
+}
+
+func addTransaction(db dbExecutor, transaction Transaction) (int, error) {
 	return dbAdd(
 		db,
 		`
-		INSERT INTO transactions	(category_id, payee_id, account_id, amount_in_cents, transaction_date_unix, update_date_unix, delete_date_unix, notes)
-		VALUES 						($category_id, $payee_id, $account_id, $amount_in_cents, $transaction_date_unix, $update_date_unix, $delete_date_unix, $notes)
+		INSERT INTO transactions	(category_id, payee_id, account_id, amount_in_cents, transaction_date_unix, update_date_unix, delete_date_unix, notes, status)
+		VALUES 						($category_id, $payee_id, $account_id, $amount_in_cents, $transaction_date_unix, $update_date_unix, $delete_date_unix, $notes, $status)
 		`,
 		transaction.CategoryID,
 		transaction.PayeeID,
@@ -47,11 +93,109 @@ func AddTransaction(db *sql.DB, transaction Transaction) (int, error) {
 		transaction.UpdateDateUnix,
 		transaction.DeleteDateUnix,
 		transaction.Notes,
+		transaction.Status,
 	)
 }
 
-// DeleteTransaction soft-deletes the transaction and returns the number of affected rows
+// NewTransaction is a transaction to create along with its payee, categories, splits and tags (see CreateTransaction)
+type NewTransaction struct {
+	Transaction Transaction
+	// PayeeName is the payee created when Transaction.PayeeID is 0
+	PayeeName string
+	// CategoryPath is the category created, along with its missing parents, when Transaction.CategoryID is 0
+	// (see AddCategoryPath), no category is set when empty
+	CategoryPath string
+	// Splits split the transaction across categories, the ones with no CategoryID are created from their path
+	Splits []NewTransactionSplit
+	Tags   []string
+}
+
+// NewTransactionSplit is a split of a NewTransaction
+type NewTransactionSplit struct {
+	TransactionSplit
+	// CategoryPath is the category created, along with its missing parents, when CategoryID is 0
+	CategoryPath string
+}
+
+// CreateTransaction creates a transaction along with its new payee and categories, its splits and tags, applies the
+// rules (see ApplyTransactionRules) and adjusts the account balance by its amount (see UpdateAccountBalance).
+// Everything is saved in a single DB transaction, returns the new transaction ID
+func CreateTransaction(db *sql.DB, transaction NewTransaction) (int, error) {
+	return createTransaction(db, transaction)
+}
+
+func CreateTransactionContext(ctx context.Context, db *sql.DB, transaction NewTransaction) (int, error) {
+	return createTransaction(withContext(ctx, db), transaction)
+}
+
+func createTransaction(db dbExecutor, n NewTransaction) (int, error) {
+	id := -1
+	err := dbTransaction(db, func(tx dbExecutor) error {
+		var err error
+		t := n.Transaction
+		if t.PayeeID == 0 {
+			if t.PayeeID, err = addPayee(tx, Payee{Name: n.PayeeName}); err != nil {
+				return err
+			}
+		}
+		if t.CategoryID == 0 && n.CategoryPath != "" {
+			if t.CategoryID, err = addCategoryPath(tx, n.CategoryPath); err != nil {
+				return err
+			}
+		}
+
+		if len(n.Splits) == 0 {
+			id, err = addTransaction(tx, t)
+		} else {
+			splits := make([]TransactionSplit, len(n.Splits))
+			for i, split := range n.Splits {
+				splits[i] = split.TransactionSplit
+				if split.CategoryID == 0 && split.CategoryPath != "" {
+					// Existing paths are reused, so each new category is only created once
+					if splits[i].CategoryID, err = addCategoryPath(tx, split.CategoryPath); err != nil {
+						return err
+					}
+				}
+			}
+			id, err = addSplitTransaction(tx, t, splits)
+		}
+		if err != nil {
+			return err
+		}
+
+		if err = tagTransaction(tx, id, n.Tags); err != nil {
+			return err
+		}
+		// Rules only fill the category when it's left empty, the payee may be renamed
+		if _, err = applyTransactionRules(tx, id); err != nil {
+			return err
+		}
+
+		_, err = updateAccountBalance(tx, t.AccountID, t.AmountInCents)
+		return err
+	})
+	if err != nil {
+		return -1, err
+	}
+
+	return id, nil
+}
+
+// DeleteTransaction soft-deletes the transaction and returns the number of affected rows,
+// reconciled transactions can't be deleted
 func DeleteTransaction(db *sql.DB, id int) (int, error) {
+	return deleteTransaction(db, id)
+}
+
+func DeleteTransactionContext(ctx context.Context, db *sql.DB, id int) (int, error) {
+	return deleteTransaction(withContext(ctx, db), id)
+}
+
+func deleteTransaction(db dbExecutor, id int) (int, error) {
+	if isTransactionReconciled(db, id) {
+		return 0, ErrTransactionReconciled
+	}
+
 	return dbUpdate(
 		db,
 		`UPDATE transactions SET delete_date_unix = $date WHERE id = $id`,
@@ -60,38 +204,76 @@ func DeleteTransaction(db *sql.DB, id int) (int, error) {
 	)
 }
 
+// UndeleteTransaction restores a soft-deleted transaction (see DeleteTransaction) and returns the number of affected rows
+func UndeleteTransaction(db *sql.DB, id int) (int, error) {
+	return undeleteTransaction(db, id)
+}
+
+func UndeleteTransactionContext(ctx context.Context, db *sql.DB, id int) (int, error) {
+	return undeleteTransaction(withContext(ctx, db), id)
+}
+
+func undeleteTransaction(db dbExecutor, id int) (int, error) {
+	return dbUpdate(db, `UPDATE transactions SET delete_date_unix = NULL WHERE id = $id`, id)
+}
+
+// UpdateTransaction updates a transaction, except for its status (see SetTransactionStatus),
+// reconciled transactions can't be updated. The amount of a split transaction must still match its splits
+// (see SetTransactionSplits), ErrSplitAmountMismatch is returned otherwise
 func UpdateTransaction(db *sql.DB, transaction Transaction) (int, error) {
-	return dbUpdate(
-		db,
-		`
-		UPDATE	transactions
-		SET		category_id				= $category_id,
-				payee_id 				= $payee_id,
-				account_id 				= $account_id,
-				amount_in_cents 		= $amount_in_cents,
-				transaction_date_unix	= $transaction_date_unix,
-				update_date_unix 		= $update_date_unix,
-				delete_date_unix 		= $delete_date_unix,
-				notes 					= $notes
-		WHERE	id = $id
-		`,
-		transaction.CategoryID,
-		transaction.PayeeID,
-		transaction.AccountID,
-		transaction.AmountInCents,
-		transaction.TransactionDateUnix,
-		transaction.UpdateDateUnix,
-		transaction.DeleteDateUnix,
-		transaction.Notes,
-		transaction.ID,
-	)
+	return updateTransaction(db, transaction)
 }
 
-// GetTransactions returns a list of transaction for a given account between minDate and maxDate (excluded)
-func GetTransactions(db *sql.DB, accountID int, minDate time.Time, maxDate time.Time) []TransactionView {
-	return dbGet[TransactionView](
-		db,
-		`
+func UpdateTransactionContext(ctx context.Context, db *sql.DB, transaction Transaction) (int, error) {
+	return updateTransaction(withContext(ctx, db), transaction)
+}
+
+func updateTransaction(db dbExecutor, transaction Transaction) (int, error) {
+	var affected int
+	err := dbTransaction(db, func(tx dbExecutor) error {
+		if isTransactionReconciled(tx, transaction.ID) {
+			return ErrTransactionReconciled
+		}
+		if splits := getTransactionSplits(tx, transaction.ID); len(splits) > 0 {
+			if err := validateSplits(transaction.AmountInCents, splits); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		affected, err = dbUpdate(
+			tx,
+			`
+			UPDATE	transactions
+			SET		category_id				= $category_id,
+					payee_id 				= $payee_id,
+					account_id 				= $account_id,
+					amount_in_cents 		= $amount_in_cents,
+					transaction_date_unix	= $transaction_date_unix,
+					update_date_unix 		= $update_date_unix,
+					delete_date_unix 		= $delete_date_unix,
+					notes 					= $notes
+			WHERE	id = $id
+			`,
+			transaction.CategoryID,
+			transaction.PayeeID,
+			transaction.AccountID,
+			transaction.AmountInCents,
+			transaction.TransactionDateUnix,
+			transaction.UpdateDateUnix,
+			transaction.DeleteDateUnix,
+			transaction.Notes,
+			transaction.ID,
+		)
+
+		return err
+	})
+
+	return affected, err
+}
+
+// transactionViewQuery selects TransactionView rows, callers append their own WHERE/ORDER BY clauses
+const transactionViewQuery = `
 		SELECT		t.id,
 					t.category_id,
 					t.payee_id,
@@ -101,24 +283,129 @@ func GetTransactions(db *sql.DB, accountID int, minDate time.Time, maxDate time.
 					t.update_date_unix,
 					t.delete_date_unix,
 					t.notes,
-					c.name                      AS CategoryName,
+					cp.path                     AS CategoryName,
 					p.name                      AS PayeeName,
-					a.name                      AS AccountName
+					a.name                      AS AccountName,
+					(
+						SELECT	COUNT(*)
+						FROM	transaction_splits s
+						WHERE	s.transaction_id = t.id
+					)							AS SplitCount,
+					(
+						SELECT	COALESCE(GROUP_CONCAT(name, ' '), '')
+						FROM	(
+									SELECT		tg.name
+									FROM		transaction_tags tt
+									JOIN		tags tg
+									ON			tg.id = tt.tag_id
+									WHERE		tt.transaction_id = t.id
+									ORDER BY	tg.name COLLATE NOCASE
+								)
+					)							AS Tags,
+					(
+						SELECT	COUNT(*)
+						FROM	attachments at
+						WHERE	at.transaction_id = t.id
+					)							AS AttachmentCount,
+					t.status
 		FROM		transactions t
 		JOIN        accounts a
 		ON          a.id = t.account_id
-		JOIN        categories c
-		ON          c.id = t.category_id
+		JOIN        category_paths cp
+		ON          cp.id = t.category_id
 		JOIN        payees p
 		ON          p.id = t.payee_id
-		WHERE			t.account_id = $accountID
-					AND	t.transaction_date_unix >= $minDateUnix
-					AND t.transaction_date_unix < $maxDateUnix
-					AND t.delete_date_unix IS NULL
-		ORDER BY	t.transaction_date_unix DESC, t.id DESC
-		`,
-		accountID,
-		minDate.Unix(),
-		maxDate.Unix(),
-	)
+		`
+
+const (
+	newestFirst = "t.transaction_date_unix DESC, t.id DESC"
+	oldestFirst = "t.transaction_date_unix, t.id"
+)
+
+// selectTransactions builds the TransactionView query for the given filter, order and limit (0 = no limit)
+func selectTransactions(db dbExecutor, filter TransactionFilter, orderBy string, limit int) (string, []any) {
+	q := queryBuilder{}
+	filter.apply(db, &q)
+
+	query := transactionViewQuery + q.whereClause() + "\n\t\tORDER BY " + orderBy
+	if limit > 0 {
+		query += "\n\t\tLIMIT ?"
+		q.args = append(q.args, limit)
+	}
+
+	return query, q.args
+}
+
+// GetTransactions returns a list of transaction for a given account between minDate and maxDate (excluded)
+func GetTransactions(db *sql.DB, accountID int, minDate time.Time, maxDate time.Time) []TransactionView {
+	return getTransactions(db, accountID, minDate, maxDate)
+}
+
+func GetTransactionsContext(ctx context.Context, db *sql.DB, accountID int, minDate time.Time, maxDate time.Time) []TransactionView {
+	return getTransactions(withContext(ctx, db), accountID, minDate, maxDate)
+}
+
+func getTransactions(db dbExecutor, accountID int, minDate time.Time, maxDate time.Time) []TransactionView {
+	return filterTransactions(db, TransactionFilter{
+		AccountIDs: []int{accountID},
+		MinDate:    minDate,
+		MaxDate:    maxDate,
+	})
+}
+
+// FilterTransactions returns the transactions matching the filter, most recent first
+func FilterTransactions(db *sql.DB, filter TransactionFilter) []TransactionView {
+	return filterTransactions(db, filter)
+}
+
+func FilterTransactionsContext(ctx context.Context, db *sql.DB, filter TransactionFilter) []TransactionView {
+	return filterTransactions(withContext(ctx, db), filter)
+}
+
+func filterTransactions(db dbExecutor, filter TransactionFilter) []TransactionView {
+	query, args := selectTransactions(db, filter, newestFirst, 0)
+	return dbGet[TransactionView](db, query, args...)
+}
+
+// GetRecentTransactions returns the latest `limit` transactions across all accounts dated before maxDate (excluded)
+func GetRecentTransactions(db *sql.DB, maxDate time.Time, limit int) []TransactionView {
+	return getRecentTransactions(db, maxDate, limit)
+}
+
+func GetRecentTransactionsContext(ctx context.Context, db *sql.DB, maxDate time.Time, limit int) []TransactionView {
+	return getRecentTransactions(withContext(ctx, db), maxDate, limit)
+}
+
+func getRecentTransactions(db dbExecutor, maxDate time.Time, limit int) []TransactionView {
+	query, args := selectTransactions(db, TransactionFilter{MaxDate: maxDate}, newestFirst, limit)
+	return dbGet[TransactionView](db, query, args...)
+}
+
+// GetUpcomingTransactions returns the first `limit` transactions across all accounts dated from minDate onwards
+func GetUpcomingTransactions(db *sql.DB, minDate time.Time, limit int) []TransactionView {
+	return getUpcomingTransactions(db, minDate, limit)
+}
+
+func GetUpcomingTransactionsContext(ctx context.Context, db *sql.DB, minDate time.Time, limit int) []TransactionView {
+	return getUpcomingTransactions(withContext(ctx, db), minDate, limit)
+}
+
+func getUpcomingTransactions(db dbExecutor, minDate time.Time, limit int) []TransactionView {
+	query, args := selectTransactions(db, TransactionFilter{MinDate: minDate}, oldestFirst, limit)
+	return dbGet[TransactionView](db, query, args...)
+}
+
+// WalkTransactions streams the transactions matching the filter to fn, one at a time, ordered by date.
+// Walking stops at the first error returned by fn
+func WalkTransactions(db *sql.DB, filter TransactionFilter, fn func(TransactionView) error) error {
+	return walkTransactions(db, filter, fn)
+}
+
+func WalkTransactionsContext(ctx context.Context, db *sql.DB, filter TransactionFilter, fn func(TransactionView) error) error {
+	return walkTransactions(withContext(ctx, db), filter, fn)
+}
+
+func walkTransactions(db dbExecutor, filter TransactionFilter, fn func(TransactionView) error) error {
+	query, args := selectTransactions(db, filter, oldestFirst, 0)
+	return dbEach[TransactionView](db, fn, query, args...)
 }
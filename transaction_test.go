@@ -52,6 +52,66 @@ func TestDeleteTransaction(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestUndeleteTransaction(t *testing.T) {
+	payeeID, _ := AddPayee(testDB, Payee{Name: "TestUndeleteTransaction"})
+	accountID, _ := AddAccount(testDB, Account{Name: "TestUndeleteTransaction"})
+	id, _ := AddTransaction(testDB, Transaction{PayeeID: payeeID, AccountID: accountID})
+	_, _ = DeleteTransaction(testDB, id)
+
+	n, err := UndeleteTransaction(testDB, id)
+
+	assert.Equal(t, 1, n)
+	assert.Nil(t, err)
+	var deleteDate sql.NullInt64
+	_ = testDB.QueryRow(`SELECT delete_date_unix FROM transactions WHERE id = $id`, id).Scan(&deleteDate)
+	assert.False(t, deleteDate.Valid)
+}
+
+func TestCreateTransaction(t *testing.T) {
+	accountID, _ := AddAccount(testDB, Account{Name: "TestCreateTransaction"})
+
+	id, err := CreateTransaction(testDB, NewTransaction{
+		Transaction:  Transaction{AccountID: accountID, AmountInCents: -500},
+		PayeeName:    "TestCreateTransaction",
+		CategoryPath: "TestCreateTransaction:Sub",
+		Tags:         []string{"TestCreateTransaction"},
+	})
+
+	assert.Nil(t, err)
+	transactions := FilterTransactions(testDB, TransactionFilter{AccountIDs: []int{accountID}})
+	assert.Len(t, transactions, 1)
+	assert.Equal(t, id, transactions[0].ID)
+	assert.Equal(t, "TestCreateTransaction", transactions[0].PayeeName)
+	assert.Equal(t, "TestCreateTransaction:Sub", transactions[0].CategoryName)
+	assert.Equal(t, "TestCreateTransaction", transactions[0].Tags)
+	// The amount is subtracted from the balance (see UpdateAccountBalance)
+	account, _ := GetAccount(testDB, accountID)
+	assert.Equal(t, int64(500), account.BalanceInCents)
+
+	// Nothing is saved when any step fails
+	_, err = CreateTransaction(testDB, NewTransaction{
+		Transaction:  Transaction{AccountID: accountID, AmountInCents: -500},
+		PayeeName:    "TestCreateTransaction Rollback",
+		CategoryPath: "TestCreateTransaction Rollback",
+		Splits: []NewTransactionSplit{
+			{TransactionSplit: TransactionSplit{AmountInCents: -200}, CategoryPath: "TestCreateTransaction Rollback:A"},
+			{TransactionSplit: TransactionSplit{AmountInCents: -300}, CategoryPath: "TestCreateTransaction Rollback:B"},
+		},
+		Tags: []string{"invalid tag"},
+	})
+
+	assert.ErrorIs(t, err, ErrInvalidTagName)
+	assert.Len(t, FilterTransactions(testDB, TransactionFilter{AccountIDs: []int{accountID}}), 1)
+	account, _ = GetAccount(testDB, accountID)
+	assert.Equal(t, int64(500), account.BalanceInCents)
+	for _, payee := range GetPayees(testDB) {
+		assert.NotEqual(t, "TestCreateTransaction Rollback", payee.Name)
+	}
+	for _, category := range GetCategories(testDB) {
+		assert.NotContains(t, category.Path, "TestCreateTransaction Rollback")
+	}
+}
+
 func TestUpdateTransaction(t *testing.T) {
 	id, _ := AddTransaction(testDB, Transaction{
 		CategoryID:          0,
@@ -234,3 +294,48 @@ func TestGetTransactions(t *testing.T) {
 	})
 	assert.NotContains(t, transactionsWithoutID, transactionDeleted)
 }
+
+func TestGetRecentTransactions(t *testing.T) {
+	payeeID, _ := AddPayee(testDB, Payee{Name: "TestGetRecentTransactions"})
+	accountID, _ := AddAccount(testDB, Account{Name: "TestGetRecentTransactions"})
+
+	maxDate := time.Date(2200, 1, 1, 0, 0, 0, 0, time.UTC)
+	recentID, _ := AddTransaction(testDB, Transaction{
+		PayeeID:             payeeID,
+		AccountID:           accountID,
+		TransactionDateUnix: maxDate.AddDate(0, 0, -1).Unix(),
+	})
+	_, _ = AddTransaction(testDB, Transaction{
+		PayeeID:             payeeID,
+		AccountID:           accountID,
+		TransactionDateUnix: maxDate.Unix(),
+	})
+
+	transactions := GetRecentTransactions(testDB, maxDate, 1)
+
+	assert.Len(t, transactions, 1)
+	assert.Equal(t, recentID, transactions[0].ID)
+	assert.Equal(t, "TestGetRecentTransactions", transactions[0].AccountName)
+}
+
+func TestGetUpcomingTransactions(t *testing.T) {
+	payeeID, _ := AddPayee(testDB, Payee{Name: "TestGetUpcomingTransactions"})
+	accountID, _ := AddAccount(testDB, Account{Name: "TestGetUpcomingTransactions"})
+
+	minDate := time.Date(2300, 1, 1, 0, 0, 0, 0, time.UTC)
+	upcomingID, _ := AddTransaction(testDB, Transaction{
+		PayeeID:             payeeID,
+		AccountID:           accountID,
+		TransactionDateUnix: minDate.Unix(),
+	})
+	_, _ = AddTransaction(testDB, Transaction{
+		PayeeID:             payeeID,
+		AccountID:           accountID,
+		TransactionDateUnix: minDate.AddDate(0, 0, 1).Unix(),
+	})
+
+	transactions := GetUpcomingTransactions(testDB, minDate, 1)
+
+	assert.Len(t, transactions, 1)
+	assert.Equal(t, upcomingID, transactions[0].ID)
+}